@@ -12,6 +12,7 @@ import (
 	"timely/libs"
 	"timely/scheduler"
 
+	"github.com/google/uuid"
 	"go.uber.org/zap"
 )
 
@@ -76,6 +77,7 @@ func processAsyncJob(ctx context.Context, tra *scheduler.RabbitMqTransport, even
 		if jitterFail() {
 			err := tra.Publish(ctx, string(scheduler.JobStatusExchange),
 				string(scheduler.JobStatusRoutingKey), libs.JobStatusEvent{
+					EventId:    uuid.New(),
 					ScheduleId: event.ScheduleId,
 					GroupId:    event.GroupId,
 					JobRunId:   event.JobRunId,
@@ -96,6 +98,7 @@ func processAsyncJob(ctx context.Context, tra *scheduler.RabbitMqTransport, even
 
 	err := tra.Publish(ctx, string(scheduler.JobStatusExchange),
 		string(scheduler.JobStatusRoutingKey), libs.JobStatusEvent{
+			EventId:    uuid.New(),
 			ScheduleId: event.ScheduleId,
 			GroupId:    event.GroupId,
 			JobRunId:   event.JobRunId,
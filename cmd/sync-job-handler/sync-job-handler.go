@@ -10,6 +10,7 @@ import (
 	"time"
 	"timely/libs"
 
+	"github.com/google/uuid"
 	"github.com/gorilla/mux"
 	"go.uber.org/zap"
 )
@@ -57,6 +58,7 @@ func processSyncJob(event libs.ScheduleJobEvent, logger *zap.SugaredLogger) {
 	for i := 0; i < 5; i++ {
 		if jitterFail() {
 			jobFailed := libs.JobStatusEvent{
+				EventId:    uuid.New(),
 				ScheduleId: event.ScheduleId,
 				GroupId:    event.GroupId,
 				JobRunId:   event.JobRunId,
@@ -80,6 +82,7 @@ func processSyncJob(event libs.ScheduleJobEvent, logger *zap.SugaredLogger) {
 	}
 
 	jobSuccess := libs.JobStatusEvent{
+		EventId:    uuid.New(),
 		ScheduleId: event.ScheduleId,
 		GroupId:    event.GroupId,
 		JobRunId:   event.JobRunId,
@@ -11,12 +11,16 @@ import (
 )
 
 type CreateScheduleCommand struct {
-	Description   string                   `json:"description"`
-	Frequency     string                   `json:"frequency"`
-	Job           JobConfiguration         `json:"job"`
-	RetryPolicy   RetryPolicyConfiguration `json:"retryPolicy"`
-	ScheduleStart *time.Time               `json:"scheduleStart"`
-	Configuration ScheduleConfiguration    `json:"configuration"`
+	Namespace       string                   `json:"namespace"`
+	Description     string                   `json:"description"`
+	Labels          map[string]string        `json:"labels"`
+	Frequency       string                   `json:"frequency"`
+	Job             JobConfiguration         `json:"job"`
+	RetryPolicy     RetryPolicyConfiguration `json:"retryPolicy"`
+	ScheduleStart   *time.Time               `json:"scheduleStart"`
+	Jitter          string                   `json:"jitter"`
+	JobRunRetention string                   `json:"jobRunRetention"`
+	Configuration   ScheduleConfiguration    `json:"configuration"`
 }
 
 type JobConfiguration struct {
@@ -33,12 +37,15 @@ type RetryPolicyConfiguration struct {
 type ScheduleConfiguration struct {
 	TransportType scheduler.TransportType `json:"transportType"`
 	Url           string                  `json:"url"`
+	Exchange      string                  `json:"exchange"`
+	RoutingKey    string                  `json:"routingKey"`
+	ExternalQueue bool                    `json:"externalQueue"`
 }
 
 type CreateScheduleHandler struct {
-	Storage        scheduler.StorageDriver
-	AsyncTransport scheduler.AsyncTransportDriver
-	Logger         *zap.SugaredLogger
+	Storage         scheduler.StorageDriver
+	AsyncTransports map[scheduler.TransportType]scheduler.AsyncTransportDriver
+	Logger          *zap.SugaredLogger
 }
 
 type CreateScheduleResponse struct {
@@ -61,9 +68,15 @@ func (h CreateScheduleHandler) Handle(ctx context.Context, c CreateScheduleComma
 
 	schedule := scheduler.NewSchedule(c.Description, c.Frequency, time.Now,
 		scheduler.WithScheduleStart(c.ScheduleStart),
+		scheduler.WithNamespace(c.Namespace),
+		scheduler.WithLabels(c.Labels),
 		scheduler.WithRetryPolicy(retryPolicy),
+		scheduler.WithJitter(c.Jitter),
+		scheduler.WithJobRunRetention(c.JobRunRetention),
 		scheduler.WithJob(c.Job.Slug, c.Job.Data),
-		scheduler.WithConfiguration(c.Configuration.TransportType, c.Configuration.Url))
+		scheduler.WithConfiguration(c.Configuration.TransportType, c.Configuration.Url),
+		scheduler.WithRouting(c.Configuration.Exchange, c.Configuration.RoutingKey),
+		scheduler.WithExternalQueue(c.Configuration.ExternalQueue))
 
 	if err = h.Storage.Add(ctx, schedule); err != nil {
 		return nil, err
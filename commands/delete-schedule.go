@@ -13,9 +13,9 @@ type DeleteSchedule struct {
 }
 
 type DeleteScheduleHandler struct {
-	AsyncTransport scheduler.AsyncTransportDriver
-	Storage        scheduler.StorageDriver
-	Logger         *zap.SugaredLogger
+	AsyncTransports map[scheduler.TransportType]scheduler.AsyncTransportDriver
+	Storage         scheduler.StorageDriver
+	Logger          *zap.SugaredLogger
 }
 
 var (
@@ -44,8 +44,9 @@ func (h DeleteScheduleHandler) Handle(ctx context.Context, c DeleteSchedule) err
 		return err
 	}
 
-	if sch.Configuration.TransportType == scheduler.Rabbitmq {
-		err := h.AsyncTransport.DeleteQueue(sch.Job.Slug)
+	// queue is shared by schedules of the same job, it is removed with the last of them
+	if transport, ok := h.AsyncTransports[scheduler.Rabbitmq]; ok && sch.UsesJobQueue() {
+		_, err = scheduler.ReleaseJobQueue(ctx, h.Storage, transport, sch.Job.Slug)
 		if err != nil {
 			h.Logger.Errorf("error during deleting queue - %s", err)
 			return ErrTransportError
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net"
+	"timely/libs/jobspb"
+	"timely/scheduler"
+
+	"github.com/google/uuid"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+)
+
+// jobStatusServer ingests job statuses reported over grpc, same way as processJobEvent does over http
+type jobStatusServer struct {
+	jobspb.UnimplementedJobStatusServiceServer
+	app Application
+}
+
+// serveGrpc listens for job statuses reported over grpc, server uses TLS when certificate is configured
+func serveGrpc(app Application, logger *zap.SugaredLogger) {
+	opts := make([]grpc.ServerOption, 0)
+	if certFile := viper.GetString("transport.grpc.server.certFile"); certFile != "" {
+		creds, err := credentials.NewServerTLSFromFile(certFile, viper.GetString("transport.grpc.server.keyFile"))
+		if err != nil {
+			logger.Panicf("grpc server certificate error - %v", err)
+		}
+		opts = append(opts, grpc.Creds(creds))
+	}
+
+	listener, err := net.Listen("tcp", viper.GetString("transport.grpc.server.address"))
+	if err != nil {
+		logger.Panicf("grpc listen error - %v", err)
+	}
+
+	server := grpc.NewServer(opts...)
+	registerGrpcServices(server, app)
+
+	logger.Infof("grpc listening on %v", listener.Addr())
+	if err = server.Serve(listener); err != nil {
+		logger.Error(err)
+	}
+}
+
+func registerGrpcServices(server *grpc.Server, app Application) {
+	jobspb.RegisterJobStatusServiceServer(server, jobStatusServer{app: app})
+}
+
+func (s jobStatusServer) ReportStatus(ctx context.Context,
+	req *jobspb.ReportStatusRequest) (*jobspb.ReportStatusResponse, error) {
+	event, err := newJobStatusEvent(req)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	if err = s.app.Scheduler.ApplyJobStatusEvent(ctx, event); err != nil {
+		return nil, jobStatusError(err)
+	}
+
+	return &jobspb.ReportStatusResponse{}, nil
+}
+
+// jobStatusError maps scheduler errors to status codes, only unexpected errors are worth retrying as is
+func jobStatusError(err error) error {
+	if errors.Is(err, scheduler.ErrReceivedStatusForUnknownSchedule) ||
+		errors.Is(err, scheduler.ErrReceivedStatusForUnknownJobRun) {
+		return status.Error(codes.NotFound, err.Error())
+	}
+
+	var e *scheduler.Error
+	if errors.As(err, &e) {
+		return status.Error(codes.FailedPrecondition, err.Error())
+	}
+
+	return status.Error(codes.Internal, err.Error())
+}
+
+func newJobStatusEvent(req *jobspb.ReportStatusRequest) (scheduler.JobStatusEvent, error) {
+	var err error
+	event := scheduler.JobStatusEvent{Status: req.GetStatus(), Reason: req.GetReason()}
+
+	// event id is optional, it is derived from job run and status when missing
+	if req.GetEventId() != "" {
+		event.EventId, err = parseId(err, req.GetEventId(), "invalid event id")
+	}
+	event.ScheduleId, err = parseId(err, req.GetScheduleId(), "invalid schedule id")
+	event.GroupId, err = parseId(err, req.GetGroupId(), "invalid group id")
+	event.JobRunId, err = parseId(err, req.GetJobRunId(), "invalid job run id")
+
+	if event.Status == "" {
+		err = errors.Join(err, errors.New("missing status"))
+	}
+
+	return event, err
+}
+
+// parseId parses id and joins validation error to already found ones
+func parseId(err error, value, msg string) (uuid.UUID, error) {
+	id, parseErr := uuid.Parse(value)
+	if parseErr != nil {
+		return uuid.Nil, errors.Join(err, errors.New(msg))
+	}
+
+	return id, err
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"timely/scheduler"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestJobStatusError(t *testing.T) {
+	tests := map[string]struct {
+		err error
+
+		expected codes.Code
+	}{
+		"unknown_schedule": {
+			err:      scheduler.ErrReceivedStatusForUnknownSchedule,
+			expected: codes.NotFound,
+		},
+		"unknown_job_run": {
+			err:      scheduler.ErrReceivedStatusForUnknownJobRun,
+			expected: codes.NotFound,
+		},
+		"scheduler_error": {
+			err:      errors.Join(scheduler.ErrInvalidJobStatus, errors.New("unknown")),
+			expected: codes.FailedPrecondition,
+		},
+		"unexpected_error": {
+			err:      errors.New("connection refused"),
+			expected: codes.Internal,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if code := status.Code(jobStatusError(test.err)); code != test.expected {
+				t.Errorf("expect result %+v, got %+v", test.expected, code)
+			}
+		})
+	}
+}
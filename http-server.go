@@ -6,7 +6,9 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 	"timely/commands"
 	"timely/queries"
@@ -23,8 +25,49 @@ func registerApiRoutes(router *mux.Router, app Application) {
 	getSchedule(v1, app)
 	getSchedules(v1, app)
 	deleteSchedule(v1, app)
+	getScheduleJobRuns(v1, app)
+
+	getScheduleStats(v1, app)
+
+	getJobRuns(v1, app)
+	getJobRun(v1, app)
+
+	getStats(v1, app)
 
 	processJobEvent(v1, app)
+	claimJob(v1, app)
+	connectWorker(v1, app)
+
+	health(router, app)
+}
+
+// health reports state of each dependency, 503 is returned when any of them is unavailable
+func health(router *mux.Router, app Application) {
+	router.HandleFunc("/health", func(w http.ResponseWriter, req *http.Request) {
+		statusCode := http.StatusOK
+		components := map[string]string{}
+
+		for name, err := range app.Scheduler.Health(req.Context()) {
+			if err != nil {
+				statusCode = http.StatusServiceUnavailable
+				components[name] = err.Error()
+				continue
+			}
+
+			components[name] = "up"
+		}
+
+		status := "up"
+		if statusCode != http.StatusOK {
+			status = "down"
+		}
+
+		w.Header().Set(scheduler.ContentTypeHeader, scheduler.ApplicationJson)
+		w.WriteHeader(statusCode)
+
+		data, _ := json.Marshal(map[string]any{"status": status, "components": components})
+		_, _ = w.Write(data)
+	}).Methods("GET")
 }
 
 func createSchedule(v1 *mux.Router, app Application) {
@@ -36,8 +79,8 @@ func createSchedule(v1 *mux.Router, app Application) {
 		}
 
 		h := commands.CreateScheduleHandler{
-			Storage:        app.Scheduler.Storage,
-			AsyncTransport: app.Scheduler.AsyncTransport,
+			Storage:         app.Scheduler.Storage,
+			AsyncTransports: app.Scheduler.AsyncTransports,
 		}
 
 		result, err := h.Handle(req.Context(), c)
@@ -64,6 +107,16 @@ func validateCreateSchedule(req *http.Request) (commands.CreateScheduleCommand,
 		err = errors.Join(err, errors.New("invalid description"))
 	}
 
+	if comm.Namespace != "" {
+		if nsErr := scheduler.ValidateNamespace(comm.Namespace); nsErr != nil {
+			err = errors.Join(err, nsErr)
+		}
+	}
+
+	if labelsErr := scheduler.ValidateLabels(comm.Labels); labelsErr != nil {
+		err = errors.Join(err, labelsErr)
+	}
+
 	if comm.Frequency == "" {
 		err = errors.Join(err, errors.New("missing frequency configuration"))
 	}
@@ -75,6 +128,20 @@ func validateCreateSchedule(req *http.Request) (commands.CreateScheduleCommand,
 		}
 	}
 
+	if comm.Jitter != "" {
+		jitter, jitterErr := time.ParseDuration(comm.Jitter)
+		if jitterErr != nil || jitter < time.Second {
+			err = errors.Join(err, errors.New("invalid jitter"))
+		}
+	}
+
+	if comm.JobRunRetention != "" {
+		retention, retentionErr := time.ParseDuration(comm.JobRunRetention)
+		if retentionErr != nil || retention < time.Hour {
+			err = errors.Join(err, errors.New("invalid job run retention"))
+		}
+	}
+
 	if comm.ScheduleStart != nil && time.Now().After(*comm.ScheduleStart) {
 		err = errors.Join(err, errors.New("invalid schedule start"))
 	}
@@ -91,11 +158,20 @@ func validateCreateSchedule(req *http.Request) (commands.CreateScheduleCommand,
 		err = errors.Join(err, errors.New("missing schedule configuration"))
 	}
 
-	if comm.Configuration.TransportType != scheduler.Http &&
-		comm.Configuration.TransportType != scheduler.Rabbitmq {
+	if !slices.Contains([]scheduler.TransportType{scheduler.Http, scheduler.Grpc, scheduler.Rabbitmq, scheduler.Kafka,
+		scheduler.Nats, scheduler.Redis, scheduler.Pull, scheduler.Websocket}, comm.Configuration.TransportType) {
 		err = errors.Join(err, errors.New("invalid transport type"))
 	}
 
+	if slices.Contains([]scheduler.TransportType{scheduler.Http, scheduler.Grpc, scheduler.Pull, scheduler.Websocket},
+		comm.Configuration.TransportType) && (comm.Configuration.Exchange != "" || comm.Configuration.RoutingKey != "") {
+		err = errors.Join(err, errors.New("exchange and routing key are supported only by async transports"))
+	}
+
+	if comm.Configuration.Exchange == "" && comm.Configuration.RoutingKey != "" {
+		err = errors.Join(err, errors.New("routing key requires custom exchange"))
+	}
+
 	if comm.Configuration.TransportType == scheduler.Http {
 		if comm.Configuration.Url == "" {
 			err = errors.Join(err, errors.New("missing url for http transport"))
@@ -107,6 +183,17 @@ func validateCreateSchedule(req *http.Request) (commands.CreateScheduleCommand,
 		}
 	}
 
+	if comm.Configuration.TransportType == scheduler.Grpc {
+		if _, _, endpointErr := scheduler.ParseGrpcEndpoint(comm.Configuration.Url); endpointErr != nil {
+			err = errors.Join(err, errors.New("invalid url for grpc transport"))
+		}
+	}
+
+	if (comm.Configuration.TransportType == scheduler.Pull || comm.Configuration.TransportType == scheduler.Websocket) &&
+		comm.Configuration.Url != "" {
+		err = errors.Join(err, errors.New("url is not supported by transports of connected workers"))
+	}
+
 	if err != nil {
 		return commands.CreateScheduleCommand{}, err
 	}
@@ -141,23 +228,132 @@ func getSchedule(v1 *mux.Router, app Application) {
 	}).Methods("GET")
 }
 
+const totalCountHeader = "X-Total-Count"
+
 func getSchedules(v1 *mux.Router, app Application) {
 	v1.HandleFunc("/schedules", func(w http.ResponseWriter, req *http.Request) {
-		vars := req.URL.Query()
-		page, err := strconv.Atoi(vars.Get("page"))
-		if err != nil || page <= 0 {
-			problem(w, http.StatusBadRequest, errors.New("invalid page"))
+		q, err := validateGetSchedules(req)
+		if err != nil {
+			problem(w, http.StatusBadRequest, err)
+			return
+		}
+
+		h := queries.GetSchedulesHandler{Storage: app.Scheduler.Storage}
+		result, err := h.Handle(req.Context(), q)
+
+		if err != nil {
+			problem(w, http.StatusUnprocessableEntity, err)
 			return
 		}
 
-		pageSize, err := strconv.Atoi(vars.Get("pageSize"))
-		if err != nil || pageSize > 100 || pageSize <= 0 {
-			problem(w, http.StatusBadRequest, errors.New("invalid pageSize"))
+		// offset pagination keeps bare array of schedules for existing clients, total is sent in header
+		if q.Page > 0 {
+			w.Header().Set(totalCountHeader, strconv.Itoa(*result.Total))
+			ok(w, result.Items)
 			return
 		}
 
-		q := queries.GetSchedules{Page: page, PageSize: pageSize}
-		h := queries.GetSchedulesHandler{Storage: app.Scheduler.Storage}
+		ok(w, result)
+	}).Methods("GET")
+}
+
+func validateGetSchedules(req *http.Request) (queries.GetSchedules, error) {
+	vars := req.URL.Query()
+
+	page, pageSize, cursor, err := validatePaging(vars)
+
+	filter := scheduler.ScheduleFilter{
+		Namespace:        vars.Get("namespace"),
+		Status:           scheduler.ScheduleStatus(vars.Get("status")),
+		TransportType:    scheduler.TransportType(vars.Get("transportType")),
+		JobSlug:          vars.Get("jobSlug"),
+		LastJobRunStatus: scheduler.JobRunStatus(vars.Get("lastJobRunStatus")),
+		Search:           vars.Get("search"),
+		SortBy:           scheduler.ScheduleSortField(vars.Get("sortBy")),
+		SortOrder:        scheduler.SortOrder(vars.Get("sortOrder")),
+	}
+
+	if filter.Status != "" && filter.Status != scheduler.Waiting && filter.Status != scheduler.Scheduled &&
+		filter.Status != scheduler.Finished {
+		err = errors.Join(err, errors.New("invalid status"))
+	}
+
+	if filter.LastJobRunStatus != "" && filter.LastJobRunStatus != scheduler.JobWaiting &&
+		filter.LastJobRunStatus != scheduler.JobSucceed && filter.LastJobRunStatus != scheduler.JobFailed {
+		err = errors.Join(err, errors.New("invalid lastJobRunStatus"))
+	}
+
+	if filter.SortBy != "" && filter.SortBy != scheduler.SortByLastExecutionDate &&
+		filter.SortBy != scheduler.SortByNextExecutionDate && filter.SortBy != scheduler.SortByDescription {
+		err = errors.Join(err, errors.New("invalid sortBy"))
+	}
+
+	if filter.SortOrder != "" && filter.SortOrder != scheduler.Ascending &&
+		filter.SortOrder != scheduler.Descending {
+		err = errors.Join(err, errors.New("invalid sortOrder"))
+	}
+
+	if page == 0 && (filter.SortBy != "" || filter.SortOrder != "") {
+		err = errors.Join(err, errors.New("sorting is supported only with page"))
+	}
+
+	labels, labelsErr := scheduler.ParseLabelSelector(vars.Get("labels"))
+	if labelsErr != nil {
+		err = errors.Join(err, labelsErr)
+	}
+	filter.Labels = labels
+
+	if err != nil {
+		return queries.GetSchedules{}, err
+	}
+
+	return queries.GetSchedules{Page: page, PageSize: pageSize, Cursor: cursor, Filter: filter}, nil
+}
+
+// validatePaging reads offset (page, pageSize) or keyset (cursor, pageSize) pagination,
+// page keeps offset pagination for compatibility, without it keyset pagination is used
+func validatePaging(vars url.Values) (int, int, *scheduler.Cursor, error) {
+	var err error
+
+	page := 0
+	if vars.Has("page") {
+		var pageErr error
+		page, pageErr = strconv.Atoi(vars.Get("page"))
+		if pageErr != nil || page <= 0 {
+			err = errors.Join(err, errors.New("invalid page"))
+		}
+	}
+
+	var cursor *scheduler.Cursor
+	if vars.Get("cursor") != "" {
+		c, cursorErr := scheduler.DecodeCursor(vars.Get("cursor"))
+		if cursorErr != nil {
+			err = errors.Join(err, cursorErr)
+		}
+		cursor = &c
+
+		if vars.Has("page") {
+			err = errors.Join(err, errors.New("page cannot be used with cursor"))
+		}
+	}
+
+	pageSize, pageSizeErr := strconv.Atoi(vars.Get("pageSize"))
+	if pageSizeErr != nil || pageSize > 100 || pageSize <= 0 {
+		err = errors.Join(err, errors.New("invalid pageSize"))
+	}
+
+	return page, pageSize, cursor, err
+}
+
+func getJobRuns(v1 *mux.Router, app Application) {
+	v1.HandleFunc("/job-runs", func(w http.ResponseWriter, req *http.Request) {
+		q, err := validateGetJobRuns(req, uuid.Nil)
+		if err != nil {
+			problem(w, http.StatusBadRequest, err)
+			return
+		}
+
+		h := queries.GetJobRunsHandler{Storage: app.Scheduler.Storage}
 		result, err := h.Handle(req.Context(), q)
 
 		if err != nil {
@@ -169,6 +365,193 @@ func getSchedules(v1 *mux.Router, app Application) {
 	}).Methods("GET")
 }
 
+func getScheduleJobRuns(v1 *mux.Router, app Application) {
+	v1.HandleFunc("/schedules/{id}/job-runs", func(w http.ResponseWriter, req *http.Request) {
+		vars := mux.Vars(req)
+		id, err := uuid.Parse(vars["id"])
+
+		if err != nil {
+			problem(w, http.StatusBadRequest, errors.New("invalid schedule id"))
+			return
+		}
+
+		q, err := validateGetJobRuns(req, id)
+		if err != nil {
+			problem(w, http.StatusBadRequest, err)
+			return
+		}
+
+		h := queries.GetJobRunsHandler{Storage: app.Scheduler.Storage}
+		result, err := h.Handle(req.Context(), q)
+
+		if err != nil {
+			if errors.Is(err, queries.ErrScheduleNotFound) {
+				problem(w, http.StatusNotFound, err)
+				return
+			}
+
+			problem(w, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		ok(w, result)
+	}).Methods("GET")
+}
+
+func validateGetJobRuns(req *http.Request, scheduleId uuid.UUID) (queries.GetJobRuns, error) {
+	vars := req.URL.Query()
+
+	page, pageSize, cursor, err := validatePaging(vars)
+
+	filter := scheduler.JobRunFilter{
+		ScheduleId: scheduleId,
+		Status:     scheduler.JobRunStatus(vars.Get("status")),
+		Reason:     vars.Get("reason"),
+	}
+
+	if filter.Status != "" && filter.Status != scheduler.JobWaiting &&
+		filter.Status != scheduler.JobSucceed && filter.Status != scheduler.JobFailed {
+		err = errors.Join(err, errors.New("invalid status"))
+	}
+
+	if vars.Get("groupId") != "" {
+		groupId, groupErr := uuid.Parse(vars.Get("groupId"))
+		if groupErr != nil {
+			err = errors.Join(err, errors.New("invalid groupId"))
+		}
+		filter.GroupId = groupId
+	}
+
+	if vars.Get("from") != "" {
+		from, fromErr := time.Parse(time.RFC3339, vars.Get("from"))
+		if fromErr != nil {
+			err = errors.Join(err, errors.New("invalid from date"))
+		}
+		filter.From = &from
+	}
+
+	if vars.Get("to") != "" {
+		to, toErr := time.Parse(time.RFC3339, vars.Get("to"))
+		if toErr != nil {
+			err = errors.Join(err, errors.New("invalid to date"))
+		}
+		filter.To = &to
+	}
+
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		err = errors.Join(err, errors.New("from date has to be before to date"))
+	}
+
+	if err != nil {
+		return queries.GetJobRuns{}, err
+	}
+
+	return queries.GetJobRuns{Page: page, PageSize: pageSize, Cursor: cursor, Filter: filter}, nil
+}
+
+func getJobRun(v1 *mux.Router, app Application) {
+	v1.HandleFunc("/job-runs/{id}", func(w http.ResponseWriter, req *http.Request) {
+		vars := mux.Vars(req)
+		id, err := uuid.Parse(vars["id"])
+
+		if err != nil {
+			problem(w, http.StatusBadRequest, errors.New("invalid job run id"))
+			return
+		}
+
+		h := queries.GetJobRunHandler{Storage: app.Scheduler.Storage}
+		result, err := h.Handle(req.Context(), queries.GetJobRun{JobRunId: id})
+
+		if err != nil {
+			if errors.Is(err, queries.ErrJobRunNotFound) {
+				problem(w, http.StatusNotFound, err)
+				return
+			}
+
+			problem(w, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		ok(w, result)
+	}).Methods("GET")
+}
+
+func getScheduleStats(v1 *mux.Router, app Application) {
+	v1.HandleFunc("/schedules/{id}/stats", func(w http.ResponseWriter, req *http.Request) {
+		vars := mux.Vars(req)
+		id, err := uuid.Parse(vars["id"])
+
+		if err != nil {
+			problem(w, http.StatusBadRequest, errors.New("invalid schedule id"))
+			return
+		}
+
+		q, err := validateGetJobRunStats(req, id)
+		if err != nil {
+			problem(w, http.StatusBadRequest, err)
+			return
+		}
+
+		h := queries.GetJobRunStatsHandler{
+			Storage:    app.Scheduler.Storage,
+			UseRollups: app.Scheduler.RollupsEnabled(),
+		}
+		result, err := h.Handle(req.Context(), q)
+
+		if err != nil {
+			if errors.Is(err, queries.ErrScheduleNotFound) {
+				problem(w, http.StatusNotFound, err)
+				return
+			}
+
+			problem(w, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		ok(w, result)
+	}).Methods("GET")
+}
+
+func getStats(v1 *mux.Router, app Application) {
+	v1.HandleFunc("/stats", func(w http.ResponseWriter, req *http.Request) {
+		q, err := validateGetJobRunStats(req, uuid.Nil)
+		if err != nil {
+			problem(w, http.StatusBadRequest, err)
+			return
+		}
+
+		h := queries.GetJobRunStatsHandler{
+			Storage:    app.Scheduler.Storage,
+			UseRollups: app.Scheduler.RollupsEnabled(),
+		}
+		result, err := h.Handle(req.Context(), q)
+
+		if err != nil {
+			problem(w, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		ok(w, result)
+	}).Methods("GET")
+}
+
+func validateGetJobRunStats(req *http.Request, scheduleId uuid.UUID) (queries.GetJobRunStats, error) {
+	window := scheduler.StatsWindow(req.URL.Query().Get("window"))
+	if window == "" {
+		window = scheduler.LastDay
+	}
+
+	if _, err := window.Duration(); err != nil {
+		return queries.GetJobRunStats{}, err
+	}
+
+	return queries.GetJobRunStats{
+		ScheduleId: scheduleId,
+		JobSlug:    req.URL.Query().Get("jobSlug"),
+		Window:     window,
+	}, nil
+}
+
 func deleteSchedule(v1 *mux.Router, app Application) {
 	v1.HandleFunc("/schedules/{id}", func(w http.ResponseWriter, req *http.Request) {
 		vars := mux.Vars(req)
@@ -180,9 +563,9 @@ func deleteSchedule(v1 *mux.Router, app Application) {
 		}
 
 		h := commands.DeleteScheduleHandler{
-			AsyncTransport: app.Scheduler.AsyncTransport,
-			Storage:        app.Scheduler.Storage,
-			Logger:         app.Logger,
+			AsyncTransports: app.Scheduler.AsyncTransports,
+			Storage:         app.Scheduler.Storage,
+			Logger:          app.Logger,
 		}
 		err = h.Handle(req.Context(), commands.DeleteSchedule{Id: id})
 
@@ -213,6 +596,88 @@ func processJobEvent(v1 *mux.Router, app Application) {
 	})
 }
 
+// claimJob leases next job to worker of pull transport, request waits for job up to wait,
+// 204 is returned when no job appeared meanwhile
+func claimJob(v1 *mux.Router, app Application) {
+	v1.HandleFunc("/workers/claim", func(w http.ResponseWriter, req *http.Request) {
+		claim, err := validateClaimJob(req)
+		if err != nil {
+			problem(w, http.StatusBadRequest, err)
+			return
+		}
+
+		result, err := app.Scheduler.ClaimJob(req.Context(), claim)
+		if err != nil {
+			if errors.Is(err, scheduler.ErrPullDisabled) {
+				problem(w, http.StatusNotFound, err)
+				return
+			}
+
+			problem(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		if result == nil {
+			noContent(w)
+			return
+		}
+
+		ok(w, result)
+	}).Methods("POST")
+}
+
+func validateClaimJob(req *http.Request) (scheduler.PullClaim, error) {
+	vars := req.URL.Query()
+
+	var err error
+	claim := scheduler.PullClaim{}
+
+	for _, slug := range strings.Split(vars.Get("slugs"), ",") {
+		if slug = strings.TrimSpace(slug); slug != "" {
+			claim.Slugs = append(claim.Slugs, slug)
+		}
+	}
+
+	if len(claim.Slugs) == 0 {
+		err = errors.Join(err, errors.New("missing slugs"))
+	}
+
+	if vars.Has("wait") {
+		wait, waitErr := time.ParseDuration(vars.Get("wait"))
+		if waitErr != nil || wait < 0 {
+			err = errors.Join(err, errors.New("invalid wait"))
+		}
+		claim.Wait = wait
+	}
+
+	if vars.Has("lease") {
+		lease, leaseErr := time.ParseDuration(vars.Get("lease"))
+		if leaseErr != nil || lease < time.Second {
+			err = errors.Join(err, errors.New("invalid lease"))
+		}
+		claim.Lease = lease
+	}
+
+	if err != nil {
+		return scheduler.PullClaim{}, err
+	}
+
+	return claim, nil
+}
+
+// connectWorker upgrades connection of websocket worker, worker receives jobs of declared slugs
+// and reports their statuses over the connection
+func connectWorker(v1 *mux.Router, app Application) {
+	v1.HandleFunc("/workers/connect", func(w http.ResponseWriter, req *http.Request) {
+		if app.Websocket == nil || !slices.Contains(scheduler.Supports, string(scheduler.Websocket)) {
+			problem(w, http.StatusNotFound, errors.New("websocket transport is not enabled"))
+			return
+		}
+
+		app.Websocket.Serve(w, req, app.Scheduler.ApplyJobStatusEvent)
+	}).Methods("GET")
+}
+
 func ok(w http.ResponseWriter, data any) {
 	w.Header().Set(scheduler.ContentTypeHeader, scheduler.ApplicationJson)
 	w.WriteHeader(http.StatusOK)
@@ -1,8 +1,13 @@
 package libs
 
-import "github.com/google/uuid"
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
 
 type JobStatusEvent struct {
+	EventId    uuid.UUID `json:"eventId"` // unique per sent status, used for deduplication
 	ScheduleId uuid.UUID `json:"scheduleId"`
 	GroupId    uuid.UUID `json:"groupId"`
 	JobRunId   uuid.UUID `json:"jobRunId"`
@@ -11,10 +16,13 @@ type JobStatusEvent struct {
 }
 
 type ScheduleJobEvent struct {
-	ScheduleId uuid.UUID       `json:"scheduleId"`
-	GroupId    uuid.UUID       `json:"groupId"`
-	JobRunId   uuid.UUID       `json:"jobRunId"`
-	Data       *map[string]any `json:"data"`
+	Job           string          `json:"job"` // job slug, tells jobs apart on shared topic
+	ScheduleId    uuid.UUID       `json:"scheduleId"`
+	GroupId       uuid.UUID       `json:"groupId"`
+	JobRunId      uuid.UUID       `json:"jobRunId"`
+	Attempt       int             `json:"attempt"`
+	ScheduledDate time.Time       `json:"scheduledDate"`
+	Data          *map[string]any `json:"data"`
 }
 
 type JobRunStatus string
@@ -5,6 +5,8 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"os"
+	"strconv"
 	"timely/scheduler"
 
 	"github.com/gorilla/handlers"
@@ -17,6 +19,7 @@ var supported []string
 
 type Application struct {
 	Scheduler *scheduler.Scheduler
+	Websocket *scheduler.WebsocketTransport
 	Logger    *zap.SugaredLogger
 }
 
@@ -32,6 +35,14 @@ func main() {
 
 	loadConfig(logger) // TODO: config validation
 
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(ctx, os.Args[2:]); err != nil {
+			logger.Fatalf("migration error - %v", err)
+		}
+
+		return
+	}
+
 	r := mux.NewRouter()
 	srv := &http.Server{
 		Addr: ":7468",
@@ -45,6 +56,10 @@ func main() {
 	app := buildDependencies(ctx, logger)
 	registerApiRoutes(r, app)
 
+	if viper.GetBool("transport.grpc.enabled") {
+		go serveGrpc(app, logger)
+	}
+
 	logger.Infof("listening on %v", srv.Addr)
 	if err := srv.ListenAndServe(); err != nil {
 		logger.Error(err)
@@ -66,6 +81,44 @@ func loadConfig(log *zap.SugaredLogger) {
 	}
 }
 
+// runMigrate handles migrate subcommand - migrate [up | down <steps> | version]
+func runMigrate(ctx context.Context, args []string) error {
+	pg, err := scheduler.NewPgsqlConnection(ctx, viper.GetString("database.postgres.connectionString"))
+	if err != nil {
+		return err
+	}
+
+	command := "up"
+	if len(args) > 0 {
+		command = args[0]
+	}
+
+	switch command {
+	case "up":
+		return pg.MigrateUp(ctx)
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps <= 0 {
+				return fmt.Errorf("invalid steps %s", args[1])
+			}
+		}
+
+		return pg.MigrateDown(ctx, steps)
+	case "version":
+		version, err := pg.SchemaVersion(ctx)
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(version)
+		return nil
+	}
+
+	return fmt.Errorf("unknown migrate command %s", command)
+}
+
 func buildDependencies(ctx context.Context, logger *zap.SugaredLogger) Application {
 	var pgStorage *scheduler.Pgsql
 	if viper.IsSet("database.postgres") {
@@ -75,13 +128,40 @@ func buildDependencies(ctx context.Context, logger *zap.SugaredLogger) Applicati
 			logger.Panic(err)
 		}
 
+		if viper.GetBool("database.postgres.migrateOnStartup") {
+			if err = pg.MigrateUp(ctx); err != nil {
+				logger.Panicf("migration error - %v", err)
+			}
+		}
+
+		if err = pg.CheckSchemaVersion(ctx); err != nil {
+			logger.Panicf("schema version error - %v", err)
+		}
+
 		pgStorage = pg
 	}
 
-	var rabbitMqTransport *scheduler.RabbitMqTransport
+	asyncTransports := map[scheduler.TransportType]scheduler.AsyncTransportDriver{}
 	if viper.IsSet("transport.rabbitmq") && viper.GetBool("transport.rabbitmq.enabled") {
 		rabbitMq, err := scheduler.NewRabbitMqTransport(
-			viper.GetString("transport.rabbitmq.connectionString"), logger)
+			viper.GetString("transport.rabbitmq.connectionString"), logger,
+			scheduler.WithConfirmTimeout(viper.GetDuration("transport.rabbitmq.confirmTimeout")),
+			scheduler.WithTopology(scheduler.TopologyConfig{
+				Prefix:             viper.GetString("transport.rabbitmq.topology.prefix"),
+				Vhost:              viper.GetString("transport.rabbitmq.topology.vhost"),
+				QueueType:          scheduler.QueueType(viper.GetString("transport.rabbitmq.topology.queueType")),
+				ExchangeType:       viper.GetString("transport.rabbitmq.topology.exchangeType"),
+				DeadLetterExchange: viper.GetString("transport.rabbitmq.topology.deadLetterExchange"),
+				MessageTTL:         viper.GetDuration("transport.rabbitmq.topology.messageTtl"),
+			}),
+			scheduler.WithManagement(viper.GetString("transport.rabbitmq.management.url"),
+				viper.GetString("transport.rabbitmq.management.username"),
+				viper.GetString("transport.rabbitmq.management.password")),
+			scheduler.WithSubscribeDefaults(
+				scheduler.WithPrefetch(viper.GetInt("transport.rabbitmq.consumer.prefetch")),
+				scheduler.WithWorkers(viper.GetInt("transport.rabbitmq.consumer.workers")),
+				scheduler.WithRequeueDelay(viper.GetDuration("transport.rabbitmq.consumer.requeueDelay"),
+					viper.GetInt("transport.rabbitmq.consumer.maxRequeues"))))
 		if err != nil {
 			logger.Panicf(fmt.Sprintf("create transport error %s", err))
 		}
@@ -91,22 +171,175 @@ func buildDependencies(ctx context.Context, logger *zap.SugaredLogger) Applicati
 			logger.Panicf(fmt.Sprintf("creating internal exchanges/queues error - %v", err))
 		}
 
-		rabbitMqTransport = rabbitMq
+		asyncTransports[scheduler.Rabbitmq] = rabbitMq
 		supported = append(supported, "rabbitmq")
 	}
 
-	var httpTransport scheduler.HttpTransport
+	if viper.IsSet("transport.kafka") && viper.GetBool("transport.kafka.enabled") {
+		kafka, err := scheduler.NewKafkaTransport(scheduler.KafkaConfig{
+			Brokers:           viper.GetStringSlice("transport.kafka.brokers"),
+			TopicPrefix:       viper.GetString("transport.kafka.topicPrefix"),
+			SharedTopic:       viper.GetString("transport.kafka.sharedTopic"),
+			PartitionBy:       scheduler.PartitionKey(viper.GetString("transport.kafka.partitionBy")),
+			ConsumerGroup:     viper.GetString("transport.kafka.consumerGroup"),
+			Partitions:        viper.GetInt("transport.kafka.partitions"),
+			ReplicationFactor: viper.GetInt("transport.kafka.replicationFactor"),
+			WriteTimeout:      viper.GetDuration("transport.kafka.writeTimeout"),
+		}, logger,
+			scheduler.WithKafkaSubscribeDefaults(
+				scheduler.WithPrefetch(viper.GetInt("transport.kafka.consumer.prefetch")),
+				scheduler.WithWorkers(viper.GetInt("transport.kafka.consumer.workers")),
+				scheduler.WithRequeueDelay(viper.GetDuration("transport.kafka.consumer.requeueDelay"),
+					viper.GetInt("transport.kafka.consumer.maxRequeues"))))
+		if err != nil {
+			logger.Panicf(fmt.Sprintf("create kafka transport error %s", err))
+		}
+
+		asyncTransports[scheduler.Kafka] = kafka
+		supported = append(supported, "kafka")
+	}
+
+	if viper.IsSet("transport.nats") && viper.GetBool("transport.nats.enabled") {
+		nats, err := scheduler.NewNatsTransport(scheduler.NatsConfig{
+			Url:            viper.GetString("transport.nats.url"),
+			Prefix:         viper.GetString("transport.nats.prefix"),
+			JobsStream:     viper.GetString("transport.nats.jobsStream"),
+			JobsSubject:    viper.GetString("transport.nats.jobsSubject"),
+			Durable:        viper.GetString("transport.nats.durable"),
+			Replicas:       viper.GetInt("transport.nats.replicas"),
+			MaxAge:         viper.GetDuration("transport.nats.maxAge"),
+			AckWait:        viper.GetDuration("transport.nats.ackWait"),
+			PublishTimeout: viper.GetDuration("transport.nats.publishTimeout"),
+		}, logger,
+			scheduler.WithNatsSubscribeDefaults(
+				scheduler.WithPrefetch(viper.GetInt("transport.nats.consumer.prefetch")),
+				scheduler.WithWorkers(viper.GetInt("transport.nats.consumer.workers")),
+				scheduler.WithRequeueDelay(viper.GetDuration("transport.nats.consumer.requeueDelay"),
+					viper.GetInt("transport.nats.consumer.maxRequeues"))))
+		if err != nil {
+			logger.Panicf(fmt.Sprintf("create nats transport error %s", err))
+		}
+
+		asyncTransports[scheduler.Nats] = nats
+		supported = append(supported, "nats")
+	}
+
+	if viper.IsSet("transport.redis") && viper.GetBool("transport.redis.enabled") {
+		redis, err := scheduler.NewRedisTransport(scheduler.RedisConfig{
+			Url:           viper.GetString("transport.redis.url"),
+			Prefix:        viper.GetString("transport.redis.prefix"),
+			ConsumerGroup: viper.GetString("transport.redis.consumerGroup"),
+			Consumer:      viper.GetString("transport.redis.consumerName"),
+			MaxLen:        viper.GetInt64("transport.redis.maxLen"),
+			ClaimIdle:     viper.GetDuration("transport.redis.claimIdle"),
+			BlockTimeout:  viper.GetDuration("transport.redis.blockTimeout"),
+		}, logger,
+			scheduler.WithRedisSubscribeDefaults(
+				scheduler.WithPrefetch(viper.GetInt("transport.redis.consumer.prefetch")),
+				scheduler.WithWorkers(viper.GetInt("transport.redis.consumer.workers")),
+				scheduler.WithRequeueDelay(viper.GetDuration("transport.redis.consumer.requeueDelay"),
+					viper.GetInt("transport.redis.consumer.maxRequeues"))))
+		if err != nil {
+			logger.Panicf(fmt.Sprintf("create redis transport error %s", err))
+		}
+
+		asyncTransports[scheduler.Redis] = redis
+		supported = append(supported, "redis")
+	}
+
+	syncTransports := map[scheduler.TransportType]scheduler.SyncTransportDriver{}
 	if viper.IsSet("transport.http") && viper.GetBool("transport.http.enabled") {
-		httpTransport = scheduler.HttpTransport{
+		syncTransports[scheduler.Http] = scheduler.HttpTransport{
 			Logger: logger,
 		}
 		supported = append(supported, "http")
 	}
 
+	if viper.IsSet("transport.grpc") && viper.GetBool("transport.grpc.enabled") {
+		grpcTransport, err := scheduler.NewGrpcTransport(scheduler.GrpcConfig{
+			Timeout:  viper.GetDuration("transport.grpc.timeout"),
+			PoolSize: viper.GetInt("transport.grpc.poolSize"),
+			CAFile:   viper.GetString("transport.grpc.tls.caFile"),
+			CertFile: viper.GetString("transport.grpc.tls.certFile"),
+			KeyFile:  viper.GetString("transport.grpc.tls.keyFile"),
+		})
+		if err != nil {
+			logger.Panicf(fmt.Sprintf("create grpc transport error %s", err))
+		}
+
+		syncTransports[scheduler.Grpc] = grpcTransport
+		supported = append(supported, "grpc")
+	}
+
+	var websocketTransport *scheduler.WebsocketTransport
+	if viper.IsSet("transport.websocket") && viper.GetBool("transport.websocket.enabled") {
+		websocketTransport = scheduler.NewWebsocketTransport(scheduler.WebsocketConfig{
+			HeartbeatTimeout: viper.GetDuration("transport.websocket.heartbeatTimeout"),
+			WriteTimeout:     viper.GetDuration("transport.websocket.writeTimeout"),
+			MaxMessageSize:   viper.GetInt64("transport.websocket.maxMessageSize"),
+		}, logger)
+
+		// workers are known only to instance they are connected to, so other instances could not dispatch to them
+		if pgStorage != nil {
+			if err := pgStorage.LockWebsocketInstance(ctx); err != nil {
+				logger.Panicf("websocket transport error - %v", err)
+			}
+		}
+
+		syncTransports[scheduler.Websocket] = websocketTransport
+		supported = append(supported, "websocket")
+	}
+
+	opts := make([]scheduler.Option, 0)
+	if viper.IsSet("transport.pull") && viper.GetBool("transport.pull.enabled") {
+		opts = append(opts, scheduler.WithPull(scheduler.PullConfig{
+			MaxWait:      viper.GetDuration("transport.pull.maxWait"),
+			DefaultLease: viper.GetDuration("transport.pull.defaultLease"),
+			MaxLease:     viper.GetDuration("transport.pull.maxLease"),
+			PollInterval: viper.GetDuration("transport.pull.pollInterval"),
+		}))
+		supported = append(supported, "pull")
+	}
+
+	if viper.IsSet("rollups") && viper.GetBool("rollups.enabled") {
+		opts = append(opts, scheduler.WithRollups(scheduler.RollupConfig{
+			Interval:        viper.GetDuration("rollups.interval"),
+			Lateness:        viper.GetDuration("rollups.lateness"),
+			HourlyRetention: viper.GetDuration("rollups.retention.hourly"),
+			DailyRetention:  viper.GetDuration("rollups.retention.daily"),
+		}))
+	}
+
+	if viper.IsSet("partitioning") && viper.GetBool("partitioning.enabled") {
+		opts = append(opts, scheduler.WithPartitions(scheduler.PartitionConfig{
+			Interval:  viper.GetDuration("partitioning.interval"),
+			Premake:   viper.GetInt("partitioning.premake"),
+			Retention: viper.GetDuration("partitioning.retention"),
+		}))
+	}
+
+	if viper.IsSet("retention") && viper.GetBool("retention.enabled") {
+		opts = append(opts, scheduler.WithRetention(scheduler.RetentionConfig{
+			Interval:          viper.GetDuration("retention.interval"),
+			BatchSize:         viper.GetInt("retention.batchSize"),
+			Archive:           viper.GetBool("retention.archive"),
+			JobRuns:           viper.GetDuration("retention.jobRuns"),
+			FinishedSchedules: viper.GetDuration("retention.finishedSchedules"),
+		}))
+	}
+
+	if viper.GetBool("transport.rabbitmq.reconciliation.enabled") {
+		opts = append(opts, scheduler.WithQueueReconciliation(scheduler.QueueReconciliationConfig{
+			Interval:      viper.GetDuration("transport.rabbitmq.reconciliation.interval"),
+			DeleteOrphans: viper.GetBool("transport.rabbitmq.reconciliation.deleteOrphans"),
+		}))
+	}
+
 	return Application{
-		Scheduler: scheduler.Start(ctx, pgStorage, rabbitMqTransport, httpTransport,
-			supported, logger),
-		Logger: logger,
+		Scheduler: scheduler.Start(ctx, pgStorage, asyncTransports, syncTransports,
+			supported, logger, opts...),
+		Websocket: websocketTransport,
+		Logger:    logger,
 	}
 }
 
@@ -0,0 +1,124 @@
+package queries
+
+import (
+	"context"
+	"time"
+	"timely/scheduler"
+
+	"github.com/google/uuid"
+)
+
+type GetJobRunStats struct {
+	ScheduleId uuid.UUID // empty for stats of all schedules
+	JobSlug    string    // empty for stats of all job slugs
+	Window     scheduler.StatsWindow
+}
+
+type GetJobRunStatsHandler struct {
+	Storage    scheduler.StorageDriver
+	UseRollups bool // windows longer than a day are computed from rollups
+	Now        func() time.Time
+}
+
+type JobRunStatsDto struct {
+	Window                  scheduler.StatsWindow `json:"window"`
+	Approximate             bool                  `json:"approximate"` // computed from rollups
+	From                    time.Time             `json:"from"`
+	To                      time.Time             `json:"to"`
+	Total                   int                   `json:"total"`
+	Succeed                 int                   `json:"succeed"`
+	Failed                  int                   `json:"failed"`
+	Waiting                 int                   `json:"waiting"`
+	SuccessRate             *float64              `json:"successRate"`
+	RetryRate               *float64              `json:"retryRate"`
+	Duration                *DurationStatsDto     `json:"duration"`
+	MeanTimeBetweenFailures *float64              `json:"meanTimeBetweenFailures"` // seconds
+}
+
+// DurationStatsDto contains job run duration percentiles in seconds
+type DurationStatsDto struct {
+	P50 float64 `json:"p50"`
+	P95 float64 `json:"p95"`
+	P99 float64 `json:"p99"`
+}
+
+func (h GetJobRunStatsHandler) Handle(ctx context.Context, q GetJobRunStats) (JobRunStatsDto, error) {
+	window, err := q.Window.Duration()
+	if err != nil {
+		return JobRunStatsDto{}, err
+	}
+
+	if q.ScheduleId != uuid.Nil {
+		schedule, err := h.Storage.GetScheduleById(ctx, q.ScheduleId)
+		if err != nil {
+			return JobRunStatsDto{}, err
+		}
+
+		if schedule == nil {
+			return JobRunStatsDto{}, ErrScheduleNotFound
+		}
+	}
+
+	now := time.Now
+	if h.Now != nil {
+		now = h.Now
+	}
+
+	to := now().Round(time.Second)
+	from := to.Add(-window)
+
+	filter := scheduler.JobRunStatsFilter{
+		ScheduleId: q.ScheduleId,
+		JobSlug:    q.JobSlug,
+		From:       from,
+		To:         to,
+	}
+
+	approximate := h.UseRollups && window > time.Hour*24
+
+	var stats scheduler.JobRunStats
+	if approximate {
+		granularity := scheduler.Hourly
+		if window > time.Hour*24*7 {
+			granularity = scheduler.Daily
+		}
+
+		stats, err = h.Storage.GetJobRunRollupStats(ctx, granularity, filter)
+	} else {
+		stats, err = h.Storage.GetJobRunStats(ctx, filter)
+	}
+
+	if err != nil {
+		return JobRunStatsDto{}, err
+	}
+
+	var duration *DurationStatsDto
+	if len(stats.Percentiles) == 3 {
+		duration = &DurationStatsDto{
+			P50: stats.Percentiles[0],
+			P95: stats.Percentiles[1],
+			P99: stats.Percentiles[2],
+		}
+	}
+
+	var mtbf *float64
+	if d := stats.MeanTimeBetweenFailures(); d != nil {
+		seconds := d.Seconds()
+		mtbf = &seconds
+	}
+
+	return JobRunStatsDto{
+		Window:                  q.Window,
+		Approximate:             approximate,
+		From:                    from,
+		To:                      to,
+		Total:                   stats.Total,
+		Succeed:                 stats.Succeed,
+		Failed:                  stats.Failed,
+		Waiting:                 stats.Waiting,
+		SuccessRate:             stats.SuccessRate(),
+		RetryRate:               stats.RetryRate(),
+		Duration:                duration,
+		MeanTimeBetweenFailures: mtbf,
+	}, nil
+}
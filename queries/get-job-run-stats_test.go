@@ -0,0 +1,91 @@
+package queries
+
+import (
+	"context"
+	"testing"
+	"time"
+	"timely/scheduler"
+
+	"github.com/google/uuid"
+)
+
+func TestGetJobRunStats(t *testing.T) {
+	tests := map[string]struct {
+		scheduleId uuid.UUID
+		window     scheduler.StatsWindow
+
+		expectedTotal int
+		expectErr     string
+	}{
+		"invalid_window": {
+			window:    "2y",
+			expectErr: "invalid stats window",
+		},
+		"schedule_with_id_does_not_exist": {
+			scheduleId: uuid.MustParse("0f54d8c5-6690-4fa4-9489-f7ec575140bd"),
+			window:     scheduler.LastDay,
+			expectErr:  ErrScheduleNotFound.Error(),
+		},
+		"stats_for_schedule": {
+			scheduleId:    uuid.MustParse("ad39c83f-59b1-4f01-8c6d-0196ce59127f"),
+			window:        scheduler.LastDay,
+			expectedTotal: 1,
+		},
+		"stats_for_all_schedules": {
+			window:        scheduler.LastWeek,
+			expectedTotal: 1,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			now := time.Date(2000, time.January, 8, 0, 0, 0, 0, time.UTC)
+			h := GetJobRunStatsHandler{Storage: getJobRunStatsStorage(), Now: func() time.Time { return now }}
+
+			stats, err := h.Handle(context.Background(), GetJobRunStats{
+				ScheduleId: test.scheduleId,
+				Window:     test.window,
+			})
+
+			if test.expectErr != "" {
+				if err == nil || test.expectErr != err.Error() {
+					t.Errorf("expect error %s, got %v", test.expectErr, err)
+				}
+
+				return
+			}
+
+			if stats.Total != test.expectedTotal {
+				t.Errorf("expect result %+v, got %+v", test.expectedTotal, stats.Total)
+			}
+
+			if stats.SuccessRate == nil || *stats.SuccessRate != 1 {
+				t.Errorf("expect result %+v, got %+v", 1, stats.SuccessRate)
+			}
+
+			if !stats.To.Equal(now) {
+				t.Errorf("expect result %+v, got %+v", now, stats.To)
+			}
+		})
+	}
+}
+
+func getJobRunStatsStorage() *storageDriverFake {
+	return &storageDriverFake{
+		schedules: map[string]*scheduler.Schedule{
+			"ad39c83f-59b1-4f01-8c6d-0196ce59127f": {
+				Id:  uuid.MustParse("ad39c83f-59b1-4f01-8c6d-0196ce59127f"),
+				Job: &scheduler.Job{Id: uuid.New(), Slug: "test-slug"},
+			},
+		},
+		jobRuns: map[string][]*scheduler.JobRun{
+			"ad39c83f-59b1-4f01-8c6d-0196ce59127f": {
+				{
+					Id:         uuid.MustParse("5b0f3e44-8d2a-4c1e-9f6b-2a7d9c1e4b35"),
+					ScheduleId: uuid.MustParse("ad39c83f-59b1-4f01-8c6d-0196ce59127f"),
+					Status:     scheduler.JobSucceed,
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,36 @@
+package queries
+
+import (
+	"context"
+	"timely/scheduler"
+
+	"github.com/google/uuid"
+)
+
+type GetJobRun struct {
+	JobRunId uuid.UUID
+}
+
+type GetJobRunHandler struct {
+	Storage scheduler.StorageDriver
+}
+
+var (
+	ErrJobRunNotFound = scheduler.Error{
+		Code: "JOB_RUN_NOT_FOUND",
+		Msg:  "job run not found",
+	}
+)
+
+func (h GetJobRunHandler) Handle(ctx context.Context, q GetJobRun) (JobRunDetailsDto, error) {
+	jobRun, err := h.Storage.GetJobRun(ctx, q.JobRunId)
+	if err != nil {
+		return JobRunDetailsDto{}, err
+	}
+
+	if jobRun == nil {
+		return JobRunDetailsDto{}, ErrJobRunNotFound
+	}
+
+	return mapJobRun(jobRun), nil
+}
@@ -0,0 +1,72 @@
+package queries
+
+import (
+	"context"
+	"testing"
+	"timely/scheduler"
+
+	"github.com/google/uuid"
+)
+
+func TestGetJobRun(t *testing.T) {
+	tests := map[string]struct {
+		id uuid.UUID
+
+		expected  JobRunDetailsDto
+		expectErr string
+	}{
+		"storage_returns_error": {
+			id:        uuid.Nil,
+			expectErr: "storage error",
+		},
+		"job_run_with_id_does_not_exist": {
+			id:        uuid.MustParse("0f54d8c5-6690-4fa4-9489-f7ec575140bd"),
+			expectErr: ErrJobRunNotFound.Error(),
+		},
+		"job_run_with_id_exists_should_be_returned": {
+			id: uuid.MustParse("5b0f3e44-8d2a-4c1e-9f6b-2a7d9c1e4b35"),
+			expected: JobRunDetailsDto{
+				Id:         uuid.MustParse("5b0f3e44-8d2a-4c1e-9f6b-2a7d9c1e4b35"),
+				ScheduleId: uuid.MustParse("ad39c83f-59b1-4f01-8c6d-0196ce59127f"),
+				Status:     scheduler.JobSucceed,
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			h := GetJobRunHandler{Storage: getJobRunStorage()}
+			jr, err := h.Handle(context.Background(), GetJobRun{JobRunId: test.id})
+
+			if test.expectErr != "" {
+				if err == nil || test.expectErr != err.Error() {
+					t.Errorf("expect error %s, got %v", test.expectErr, err)
+				}
+			} else {
+				if jr != test.expected {
+					t.Errorf("expect result %+v, got %+v", test.expected, jr)
+				}
+			}
+		})
+	}
+}
+
+func getJobRunStorage() *storageDriverFake {
+	return &storageDriverFake{
+		schedules: map[string]*scheduler.Schedule{
+			"ad39c83f-59b1-4f01-8c6d-0196ce59127f": {
+				Id:  uuid.MustParse("ad39c83f-59b1-4f01-8c6d-0196ce59127f"),
+				Job: &scheduler.Job{Id: uuid.New(), Slug: "test-slug"},
+			},
+		},
+		jobRuns: map[string][]*scheduler.JobRun{
+			"ad39c83f-59b1-4f01-8c6d-0196ce59127f": {
+				{
+					Id:         uuid.MustParse("5b0f3e44-8d2a-4c1e-9f6b-2a7d9c1e4b35"),
+					ScheduleId: uuid.MustParse("ad39c83f-59b1-4f01-8c6d-0196ce59127f"),
+					Status:     scheduler.JobSucceed,
+				},
+			},
+		},
+	}
+}
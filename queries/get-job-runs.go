@@ -0,0 +1,115 @@
+package queries
+
+import (
+	"context"
+	"time"
+	"timely/scheduler"
+
+	"github.com/google/uuid"
+)
+
+type GetJobRuns struct {
+	Page     int // offset pagination, when zero keyset pagination starting after Cursor is used
+	PageSize int
+	Cursor   *scheduler.Cursor
+	Filter   scheduler.JobRunFilter
+}
+
+type GetJobRunsHandler struct {
+	Storage scheduler.StorageDriver
+}
+
+type JobRunsPageDto struct {
+	Items      []JobRunDetailsDto `json:"items"`
+	Page       int                `json:"page,omitempty"`
+	PageSize   int                `json:"pageSize"`
+	Total      *int               `json:"total,omitempty"`
+	NextCursor *string            `json:"nextCursor,omitempty"`
+}
+
+type JobRunDetailsDto struct {
+	Id            uuid.UUID              `json:"id"`
+	ScheduleId    uuid.UUID              `json:"scheduleId"`
+	GroupId       uuid.UUID              `json:"groupId"`
+	Status        scheduler.JobRunStatus `json:"status"`
+	Attempt       int                    `json:"attempt"`
+	ScheduledDate time.Time              `json:"scheduledDate"`
+	Reason        *string                `json:"reason"`
+	StartDate     time.Time              `json:"startDate"`
+	EndDate       *time.Time             `json:"endDate"`
+}
+
+func (h GetJobRunsHandler) Handle(ctx context.Context, q GetJobRuns) (JobRunsPageDto, error) {
+	if q.Filter.ScheduleId != uuid.Nil {
+		schedule, err := h.Storage.GetScheduleById(ctx, q.Filter.ScheduleId)
+		if err != nil {
+			return JobRunsPageDto{}, err
+		}
+
+		if schedule == nil {
+			return JobRunsPageDto{}, ErrScheduleNotFound
+		}
+	}
+
+	if q.Page == 0 {
+		return h.handleCursor(ctx, q)
+	}
+
+	jobRuns, total, err := h.Storage.GetJobRunsPaged(ctx, q.Filter, q.Page, q.PageSize)
+	if err != nil {
+		return JobRunsPageDto{}, err
+	}
+
+	return JobRunsPageDto{
+		Items:    mapJobRuns(jobRuns),
+		Page:     q.Page,
+		PageSize: q.PageSize,
+		Total:    &total,
+	}, nil
+}
+
+func (h GetJobRunsHandler) handleCursor(ctx context.Context, q GetJobRuns) (JobRunsPageDto, error) {
+	// one additional item is fetched to find out if there is a next page
+	jobRuns, err := h.Storage.GetJobRunsAfter(ctx, q.Filter, q.Cursor, q.PageSize+1)
+	if err != nil {
+		return JobRunsPageDto{}, err
+	}
+
+	var nextCursor *string
+	if len(jobRuns) > q.PageSize {
+		jobRuns = jobRuns[:q.PageSize]
+		last := jobRuns[len(jobRuns)-1]
+		token := scheduler.Cursor{Date: last.StartDate, Id: last.Id}.Encode()
+		nextCursor = &token
+	}
+
+	return JobRunsPageDto{
+		Items:      mapJobRuns(jobRuns),
+		PageSize:   q.PageSize,
+		NextCursor: nextCursor,
+	}, nil
+}
+
+func mapJobRuns(jobRuns []*scheduler.JobRun) []JobRunDetailsDto {
+	jobRunsDto := make([]JobRunDetailsDto, 0, len(jobRuns))
+
+	for _, jobRun := range jobRuns {
+		jobRunsDto = append(jobRunsDto, mapJobRun(jobRun))
+	}
+
+	return jobRunsDto
+}
+
+func mapJobRun(jobRun *scheduler.JobRun) JobRunDetailsDto {
+	return JobRunDetailsDto{
+		Id:            jobRun.Id,
+		ScheduleId:    jobRun.ScheduleId,
+		GroupId:       jobRun.GroupId,
+		Status:        jobRun.Status,
+		Attempt:       jobRun.Attempt,
+		ScheduledDate: jobRun.ScheduledDate,
+		Reason:        jobRun.Reason,
+		StartDate:     jobRun.StartDate,
+		EndDate:       jobRun.EndDate,
+	}
+}
@@ -19,10 +19,15 @@ type GetScheduleHandler struct {
 type ScheduleDetailsDto struct {
 	Id                uuid.UUID                 `json:"id"`
 	GroupId           uuid.UUID                 `json:"groupId"`
+	Attempt           int                       `json:"attempt"`
+	Namespace         string                    `json:"namespace"`
 	Description       string                    `json:"description"`
+	Labels            map[string]string         `json:"labels"`
 	Frequency         string                    `json:"frequency"`
 	Status            scheduler.ScheduleStatus  `json:"status"`
 	RetryPolicy       *RetryPolicyDto           `json:"retryPolicy"`
+	Jitter            string                    `json:"jitter"`
+	JobRunRetention   string                    `json:"jobRunRetention"`
 	LastExecutionDate *time.Time                `json:"lastExecutionDate"`
 	NextExecutionDate *time.Time                `json:"nextExecutionDate"`
 	Job               ScheduleDetailsJobDto     `json:"job"`
@@ -43,16 +48,20 @@ type ScheduleDetailsJobDto struct {
 }
 
 type JobRunDto struct {
-	Id        uuid.UUID              `json:"id"`
-	Status    scheduler.JobRunStatus `json:"status"`
-	Reason    *string                `json:"reason"`
-	StartDate time.Time              `json:"startDate"`
-	EndDate   *time.Time             `json:"endDate"`
+	Id            uuid.UUID              `json:"id"`
+	Status        scheduler.JobRunStatus `json:"status"`
+	Attempt       int                    `json:"attempt"`
+	ScheduledDate time.Time              `json:"scheduledDate"`
+	Reason        *string                `json:"reason"`
+	StartDate     time.Time              `json:"startDate"`
+	EndDate       *time.Time             `json:"endDate"`
 }
 
 type ScheduleConfigurationDto struct {
 	TransportType scheduler.TransportType `json:"transportType"`
 	Url           string                  `json:"url"`
+	Exchange      string                  `json:"exchange,omitempty"`
+	RoutingKey    string                  `json:"routingKey,omitempty"`
 }
 
 var (
@@ -90,21 +99,28 @@ func (h GetScheduleHandler) Handle(ctx context.Context, q GetSchedule) (Schedule
 	for _, jobRun := range jobRuns {
 		recentJobRunsDto[jobRun.GroupId] = append(recentJobRunsDto[jobRun.GroupId],
 			JobRunDto{
-				Id:        jobRun.Id,
-				Status:    jobRun.Status,
-				Reason:    jobRun.Reason,
-				StartDate: jobRun.StartDate,
-				EndDate:   jobRun.EndDate,
+				Id:            jobRun.Id,
+				Status:        jobRun.Status,
+				Attempt:       jobRun.Attempt,
+				ScheduledDate: jobRun.ScheduledDate,
+				Reason:        jobRun.Reason,
+				StartDate:     jobRun.StartDate,
+				EndDate:       jobRun.EndDate,
 			})
 	}
 
 	return ScheduleDetailsDto{
 		Id:                schedule.Id,
 		GroupId:           schedule.GroupId,
+		Attempt:           schedule.Attempt,
+		Namespace:         schedule.Namespace,
 		Description:       schedule.Description,
+		Labels:            schedule.Labels,
 		Frequency:         schedule.Frequency,
 		Status:            schedule.Status,
 		RetryPolicy:       retry,
+		Jitter:            schedule.Jitter,
+		JobRunRetention:   schedule.JobRunRetention,
 		LastExecutionDate: schedule.LastExecutionDate,
 		NextExecutionDate: schedule.NextExecutionDate,
 		Job: ScheduleDetailsJobDto{
@@ -115,6 +131,8 @@ func (h GetScheduleHandler) Handle(ctx context.Context, q GetSchedule) (Schedule
 		Configuration: ScheduleConfigurationDto{
 			TransportType: schedule.Configuration.TransportType,
 			Url:           schedule.Configuration.Url,
+			Exchange:      schedule.Configuration.Exchange,
+			RoutingKey:    schedule.Configuration.RoutingKey,
 		},
 		RecentJobRuns: recentJobRunsDto,
 	}, nil
@@ -4,7 +4,9 @@ import (
 	"context"
 	"errors"
 	"reflect"
+	"slices"
 	"testing"
+	"time"
 	"timely/scheduler"
 
 	"github.com/google/uuid"
@@ -106,14 +108,32 @@ func (s storageDriverFake) GetScheduleByJobSlug(ctx context.Context, slug string
 	panic("implement me")
 }
 
-func (s storageDriverFake) GetAwaitingSchedules(ctx context.Context) ([]*scheduler.Schedule, error) {
+func (s storageDriverFake) GetAwaitingSchedules(ctx context.Context,
+	transports []scheduler.TransportType) ([]*scheduler.Schedule, error) {
 	panic("implement me")
 }
 
-func (s storageDriverFake) GetSchedulesPaged(ctx context.Context, page int, pageSize int) ([]*scheduler.Schedule, error) {
+func (s storageDriverFake) GetSchedulesPaged(ctx context.Context, filter scheduler.ScheduleFilter, page int,
+	pageSize int) ([]*scheduler.Schedule, int, error) {
 	panic("implement me")
 }
 
+func (s storageDriverFake) GetSchedulesAfter(ctx context.Context, filter scheduler.ScheduleFilter,
+	cursor *scheduler.Cursor, limit int) ([]*scheduler.Schedule, error) {
+	schedules := make([]*scheduler.Schedule, 0)
+	for _, schedule := range s.schedules {
+		if cursor == nil || schedule.CreationDate.Before(cursor.Date) {
+			schedules = append(schedules, schedule)
+		}
+	}
+
+	slices.SortFunc(schedules, func(a, b *scheduler.Schedule) int {
+		return b.CreationDate.Compare(a.CreationDate)
+	})
+
+	return schedules[:min(limit, len(schedules))], nil
+}
+
 func (s storageDriverFake) Add(ctx context.Context, schedule scheduler.Schedule) error {
 	panic("implement me")
 }
@@ -122,27 +142,47 @@ func (s storageDriverFake) DeleteScheduleById(ctx context.Context, id uuid.UUID)
 	panic("implement me")
 }
 
-func (s storageDriverFake) UpdateSchedule(ctx context.Context, schedule scheduler.Schedule) error {
+func (s storageDriverFake) WithTx(ctx context.Context, fn func(tx scheduler.StorageTx) error) error {
 	panic("implement me")
 }
 
-func (s storageDriverFake) AddJobRun(ctx context.Context, jobRun scheduler.JobRun) error {
+func (s storageDriverFake) ClaimOutboxMessages(ctx context.Context, limit int,
+	lease time.Duration) ([]*scheduler.OutboxMessage, error) {
 	panic("implement me")
 }
 
-func (s storageDriverFake) UpdateJobRun(ctx context.Context, jobRun scheduler.JobRun) error {
+func (s storageDriverFake) DeleteSentOutboxMessages(ctx context.Context, before time.Time,
+	limit int) (int64, error) {
 	panic("implement me")
 }
 
 func (s storageDriverFake) GetJobRun(ctx context.Context, id uuid.UUID) (*scheduler.JobRun, error) {
-	panic("implement me")
+	if id == uuid.Nil {
+		return nil, errors.New("storage error")
+	}
+
+	for _, jobRuns := range s.jobRuns {
+		for _, jobRun := range jobRuns {
+			if jobRun.Id == id {
+				return jobRun, nil
+			}
+		}
+	}
+
+	return nil, nil
 }
 
 func (s storageDriverFake) GetJobRunGroup(ctx context.Context, scheduleId uuid.UUID, groupId uuid.UUID) ([]*scheduler.JobRun, error) {
 	panic("implement me")
 }
 
-func (s storageDriverFake) GetJobRuns(ctx context.Context, scheduleId uuid.UUID) ([]*scheduler.JobRun, error) {
+func (s storageDriverFake) GetJobRunsPaged(ctx context.Context, filter scheduler.JobRunFilter, page int,
+	pageSize int) ([]*scheduler.JobRun, int, error) {
+	panic("implement me")
+}
+
+func (s storageDriverFake) GetJobRunsAfter(ctx context.Context, filter scheduler.JobRunFilter,
+	cursor *scheduler.Cursor, limit int) ([]*scheduler.JobRun, error) {
 	panic("implement me")
 }
 
@@ -150,6 +190,92 @@ func (s storageDriverFake) GetStaleJobs(ctx context.Context) ([]scheduler.StaleJ
 	panic("implement me")
 }
 
+func (s storageDriverFake) GetJobRunStats(ctx context.Context,
+	filter scheduler.JobRunStatsFilter) (scheduler.JobRunStats, error) {
+	stats := scheduler.JobRunStats{}
+	for _, jobRuns := range s.jobRuns {
+		for _, jobRun := range jobRuns {
+			if filter.ScheduleId != uuid.Nil && jobRun.ScheduleId != filter.ScheduleId {
+				continue
+			}
+
+			stats.Total++
+			switch jobRun.Status {
+			case scheduler.JobSucceed:
+				stats.Succeed++
+			case scheduler.JobFailed:
+				stats.Failed++
+			case scheduler.JobWaiting:
+				stats.Waiting++
+			}
+		}
+	}
+
+	return stats, nil
+}
+
+func (s storageDriverFake) GetJobRunRollupStats(ctx context.Context, granularity scheduler.RollupGranularity,
+	filter scheduler.JobRunStatsFilter) (scheduler.JobRunStats, error) {
+	panic("implement me")
+}
+
+func (s storageDriverFake) GetRollupWatermark(ctx context.Context) (*time.Time, error) {
+	panic("implement me")
+}
+
+func (s storageDriverFake) GetOldestJobRunDate(ctx context.Context) (*time.Time, error) {
+	panic("implement me")
+}
+
+func (s storageDriverFake) RollupJobRuns(ctx context.Context, from, to, watermark time.Time) (bool, error) {
+	panic("implement me")
+}
+
+func (s storageDriverFake) DeleteRollups(ctx context.Context, granularity scheduler.RollupGranularity,
+	before time.Time) (int64, error) {
+	panic("implement me")
+}
+
+func (s storageDriverFake) GetJobRunRetentions(ctx context.Context) (map[uuid.UUID]string, error) {
+	panic("implement me")
+}
+
+func (s storageDriverFake) DeleteJobRuns(ctx context.Context, scheduleId uuid.UUID, before time.Time, limit int,
+	archive bool) (int64, error) {
+	panic("implement me")
+}
+
+func (s storageDriverFake) GetFinishedSchedules(ctx context.Context, before time.Time,
+	limit int) ([]*scheduler.Schedule, error) {
+	panic("implement me")
+}
+
+func (s storageDriverFake) ArchiveScheduleById(ctx context.Context, id uuid.UUID) error {
+	panic("implement me")
+}
+
+func (s storageDriverFake) GetJobRunPartitions(ctx context.Context) ([]string, error) {
+	panic("implement me")
+}
+
+func (s storageDriverFake) CreateJobRunPartition(ctx context.Context, partition scheduler.JobRunPartition) error {
+	panic("implement me")
+}
+
+func (s storageDriverFake) HasJobRunsOfSchedules(ctx context.Context, partition scheduler.JobRunPartition,
+	scheduleIds []uuid.UUID) (bool, error) {
+	panic("implement me")
+}
+
+func (s storageDriverFake) DropJobRunPartition(ctx context.Context, partition scheduler.JobRunPartition,
+	archive bool) error {
+	panic("implement me")
+}
+
+func (s storageDriverFake) DeleteInboxMessages(ctx context.Context, before time.Time, limit int) (int64, error) {
+	panic("implement me")
+}
+
 func (s storageDriverFake) GetRecentJobRuns(ctx context.Context, scheduleId uuid.UUID) ([]*scheduler.JobRun, error) {
 	v, exists := s.jobRuns[scheduleId.String()]
 	if !exists {
@@ -158,3 +284,12 @@ func (s storageDriverFake) GetRecentJobRuns(ctx context.Context, scheduleId uuid
 
 	return v, nil
 }
+
+func (s storageDriverFake) GetJobQueues(ctx context.Context) ([]scheduler.JobQueue, error) {
+	panic("implement me")
+}
+
+func (s storageDriverFake) ClaimPullJob(ctx context.Context, slugs []string, lease time.Duration,
+	now time.Time) (*scheduler.PullJob, error) {
+	panic("implement me")
+}
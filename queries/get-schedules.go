@@ -9,21 +9,35 @@ import (
 )
 
 type GetSchedules struct {
-	Page     int
+	Page     int // offset pagination, when zero keyset pagination starting after Cursor is used
 	PageSize int
+	Cursor   *scheduler.Cursor
+	Filter   scheduler.ScheduleFilter
 }
 
 type GetSchedulesHandler struct {
 	Storage scheduler.StorageDriver
 }
 
+type SchedulesPageDto struct {
+	Items      []ScheduleDto `json:"items"`
+	Page       int           `json:"page,omitempty"`
+	PageSize   int           `json:"pageSize"`
+	Total      *int          `json:"total,omitempty"`
+	NextCursor *string       `json:"nextCursor,omitempty"`
+}
+
 type ScheduleDto struct {
 	Id                uuid.UUID                `json:"id"`
+	Namespace         string                   `json:"namespace"`
 	Description       string                   `json:"description"`
+	Labels            map[string]string        `json:"labels"`
 	Frequency         string                   `json:"frequency"`
 	Status            scheduler.ScheduleStatus `json:"status"`
+	TransportType     scheduler.TransportType  `json:"transportType"`
 	LastExecutionDate *time.Time               `json:"lastExecutionDate"`
 	NextExecutionDate *time.Time               `json:"nextExecutionDate"`
+	CreationDate      time.Time                `json:"creationDate"`
 	JobSlug           string                   `json:"jobSlug"`
 }
 
@@ -32,26 +46,65 @@ type JobDto struct {
 	Data *map[string]any `json:"data"`
 }
 
-func (h GetSchedulesHandler) Handle(ctx context.Context, q GetSchedules) ([]ScheduleDto, error) {
-	schedules, err := h.Storage.GetSchedulesPaged(ctx, q.Page, q.PageSize)
+func (h GetSchedulesHandler) Handle(ctx context.Context, q GetSchedules) (SchedulesPageDto, error) {
+	if q.Page == 0 {
+		return h.handleCursor(ctx, q)
+	}
+
+	schedules, total, err := h.Storage.GetSchedulesPaged(ctx, q.Filter, q.Page, q.PageSize)
 
 	if err != nil {
-		return []ScheduleDto{}, err
+		return SchedulesPageDto{}, err
 	}
 
+	return SchedulesPageDto{
+		Items:    mapSchedules(schedules),
+		Page:     q.Page,
+		PageSize: q.PageSize,
+		Total:    &total,
+	}, nil
+}
+
+func (h GetSchedulesHandler) handleCursor(ctx context.Context, q GetSchedules) (SchedulesPageDto, error) {
+	// one additional item is fetched to find out if there is a next page
+	schedules, err := h.Storage.GetSchedulesAfter(ctx, q.Filter, q.Cursor, q.PageSize+1)
+	if err != nil {
+		return SchedulesPageDto{}, err
+	}
+
+	var nextCursor *string
+	if len(schedules) > q.PageSize {
+		schedules = schedules[:q.PageSize]
+		last := schedules[len(schedules)-1]
+		token := scheduler.Cursor{Date: last.CreationDate, Id: last.Id}.Encode()
+		nextCursor = &token
+	}
+
+	return SchedulesPageDto{
+		Items:      mapSchedules(schedules),
+		PageSize:   q.PageSize,
+		NextCursor: nextCursor,
+	}, nil
+}
+
+func mapSchedules(schedules []*scheduler.Schedule) []ScheduleDto {
 	schedulesDto := make([]ScheduleDto, 0, len(schedules))
 
 	for _, schedule := range schedules {
 		schedulesDto = append(schedulesDto, ScheduleDto{
 			Id:                schedule.Id,
+			Namespace:         schedule.Namespace,
 			Description:       schedule.Description,
+			Labels:            schedule.Labels,
 			Frequency:         schedule.Frequency,
 			Status:            schedule.Status,
+			TransportType:     schedule.Configuration.TransportType,
 			LastExecutionDate: schedule.LastExecutionDate,
 			NextExecutionDate: schedule.NextExecutionDate,
+			CreationDate:      schedule.CreationDate,
 			JobSlug:           schedule.Job.Slug,
 		})
 	}
 
-	return schedulesDto, nil
+	return schedulesDto
 }
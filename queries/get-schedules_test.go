@@ -0,0 +1,64 @@
+package queries
+
+import (
+	"context"
+	"testing"
+	"time"
+	"timely/scheduler"
+
+	"github.com/google/uuid"
+)
+
+func TestGetSchedulesWithCursor(t *testing.T) {
+	h := GetSchedulesHandler{Storage: getSchedulesStorage()}
+
+	first, err := h.Handle(context.Background(), GetSchedules{PageSize: 1})
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+
+	if len(first.Items) != 1 || first.NextCursor == nil {
+		t.Fatalf("expect single item with next cursor, got %+v", first)
+	}
+
+	expected := uuid.MustParse("ad39c83f-59b1-4f01-8c6d-0196ce59127f")
+	if first.Items[0].Id != expected {
+		t.Errorf("expect result %s, got %s", expected, first.Items[0].Id)
+	}
+
+	cursor, err := scheduler.DecodeCursor(*first.NextCursor)
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+
+	second, err := h.Handle(context.Background(), GetSchedules{PageSize: 1, Cursor: &cursor})
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+
+	if len(second.Items) != 1 || second.NextCursor != nil {
+		t.Fatalf("expect last single item without next cursor, got %+v", second)
+	}
+
+	expected = uuid.MustParse("3c5e4a1e-2f0d-4a57-9b8c-6f1a0b8e7d21")
+	if second.Items[0].Id != expected {
+		t.Errorf("expect result %s, got %s", expected, second.Items[0].Id)
+	}
+}
+
+func getSchedulesStorage() *storageDriverFake {
+	return &storageDriverFake{
+		schedules: map[string]*scheduler.Schedule{
+			"ad39c83f-59b1-4f01-8c6d-0196ce59127f": {
+				Id:           uuid.MustParse("ad39c83f-59b1-4f01-8c6d-0196ce59127f"),
+				Job:          &scheduler.Job{Id: uuid.New(), Slug: "test-slug"},
+				CreationDate: time.Date(2000, time.January, 2, 0, 0, 0, 0, time.UTC),
+			},
+			"3c5e4a1e-2f0d-4a57-9b8c-6f1a0b8e7d21": {
+				Id:           uuid.MustParse("3c5e4a1e-2f0d-4a57-9b8c-6f1a0b8e7d21"),
+				Job:          &scheduler.Job{Id: uuid.New(), Slug: "test-slug"},
+				CreationDate: time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC),
+			},
+		},
+	}
+}
@@ -0,0 +1,41 @@
+package scheduler
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Cursor points at the last returned item of keyset paginated listing. Items are ordered by
+// stable, never updated date and id, so they do not move between pages while being processed
+type Cursor struct {
+	Date time.Time `json:"d"`
+	Id   uuid.UUID `json:"i"`
+}
+
+var ErrInvalidCursor = &Error{
+	Code: "INVALID_CURSOR",
+	Msg:  "invalid cursor"}
+
+// Encode returns opaque continuation token
+func (c Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func DecodeCursor(token string) (Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	var c Cursor
+	if err = json.Unmarshal(data, &c); err != nil || c.Id == uuid.Nil || c.Date.IsZero() {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	return c, nil
+}
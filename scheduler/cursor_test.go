@@ -0,0 +1,43 @@
+package scheduler
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestCursorEncodeDecode(t *testing.T) {
+	expected := Cursor{Date: getStubDate(), Id: uuid.New()}
+
+	c, err := DecodeCursor(expected.Encode())
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+
+	if !c.Date.Equal(expected.Date) || c.Id != expected.Id {
+		t.Errorf("expect result %+v, got %+v", expected, c)
+	}
+}
+
+func TestDecodeInvalidCursor(t *testing.T) {
+	tests := map[string]struct {
+		token string
+	}{
+		"not_base64":    {token: "%%%"},
+		"not_json":      {token: "bm90LWpzb24"},
+		"missing_id":    {token: Cursor{Date: getStubDate()}.Encode()},
+		"missing_date":  {token: Cursor{Id: uuid.New()}.Encode()},
+		"empty_payload": {token: ""},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := DecodeCursor(test.token)
+
+			if !errors.Is(err, ErrInvalidCursor) {
+				t.Errorf("expect error %v, got %v", ErrInvalidCursor, err)
+			}
+		})
+	}
+}
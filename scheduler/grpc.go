@@ -0,0 +1,229 @@
+package scheduler
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"timely/libs/jobspb"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	grpcScheme       = "grpc://"
+	grpcSecureScheme = "grpcs://"
+
+	defaultGrpcTimeout  = time.Second * 10
+	defaultGrpcPoolSize = 1
+)
+
+var (
+	ErrInvalidGrpcEndpoint = &Error{
+		Code: "INVALID_GRPC_ENDPOINT",
+		Msg:  "grpc endpoint has to be grpc://host:port or grpcs://host:port"}
+	ErrInvalidCertificate = &Error{
+		Code: "INVALID_CERTIFICATE",
+		Msg:  "certificate authority could not be parsed"}
+)
+
+// GrpcConfig describes how connections to workers are opened, endpoints with grpcs scheme use TLS
+type GrpcConfig struct {
+	Timeout  time.Duration // deadline of single start call
+	PoolSize int           // connections per endpoint, calls are spread over them
+	CAFile   string        // certificate authority of workers, system pool when empty
+	CertFile string        // client certificate for mutual TLS
+	KeyFile  string
+}
+
+// GrpcTransport starts jobs through JobService of workers, connections are opened lazily and kept per endpoint
+type GrpcTransport struct {
+	config GrpcConfig
+	tls    *tls.Config
+
+	mu    sync.Mutex
+	pools map[string]*grpcPool
+}
+
+type grpcPool struct {
+	connections []*grpc.ClientConn
+	next        atomic.Uint64
+}
+
+func (p *grpcPool) get() *grpc.ClientConn {
+	return p.connections[p.next.Add(1)%uint64(len(p.connections))]
+}
+
+func NewGrpcTransport(config GrpcConfig) (*GrpcTransport, error) {
+	if config.Timeout <= 0 {
+		config.Timeout = defaultGrpcTimeout
+	}
+
+	if config.PoolSize <= 0 {
+		config.PoolSize = defaultGrpcPoolSize
+	}
+
+	tlsConfig, err := newGrpcTLSConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &GrpcTransport{
+		config: config,
+		tls:    tlsConfig,
+		pools:  map[string]*grpcPool{},
+	}, nil
+}
+
+func newGrpcTLSConfig(config GrpcConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if config.CAFile != "" {
+		ca, err := os.ReadFile(config.CAFile)
+		if err != nil {
+			return nil, err
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, ErrInvalidCertificate
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if config.CertFile != "" || config.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(config.CertFile, config.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
+
+// ParseGrpcEndpoint returns address of endpoint and whether it uses TLS
+func ParseGrpcEndpoint(endpoint string) (string, bool, error) {
+	var address string
+	secure := false
+
+	switch {
+	case strings.HasPrefix(endpoint, grpcScheme):
+		address = strings.TrimPrefix(endpoint, grpcScheme)
+	case strings.HasPrefix(endpoint, grpcSecureScheme):
+		address = strings.TrimPrefix(endpoint, grpcSecureScheme)
+		secure = true
+	default:
+		return "", false, ErrInvalidGrpcEndpoint
+	}
+
+	if address == "" || strings.Contains(address, "/") {
+		return "", false, ErrInvalidGrpcEndpoint
+	}
+
+	return address, secure, nil
+}
+
+// Start calls StartJob of worker, job is started when worker accepts the call before deadline
+func (t *GrpcTransport) Start(ctx context.Context, endpoint string, request ScheduleJobRequest) error {
+	conn, err := t.connection(endpoint)
+	if err != nil {
+		return err
+	}
+
+	message, err := newStartJobRequest(request)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, t.config.Timeout)
+	defer cancel()
+
+	_, err = jobspb.NewJobServiceClient(conn).StartJob(ctx, message)
+	if err != nil {
+		return fmt.Errorf("error during starting job at %s - %w", endpoint, err)
+	}
+
+	return nil
+}
+
+func newStartJobRequest(request ScheduleJobRequest) (*jobspb.StartJobRequest, error) {
+	var data *structpb.Struct
+	if request.Data != nil {
+		var err error
+		if data, err = structpb.NewStruct(*request.Data); err != nil {
+			return nil, err
+		}
+	}
+
+	return &jobspb.StartJobRequest{
+		ScheduleId:    request.ScheduleId.String(),
+		GroupId:       request.GroupId.String(),
+		JobRunId:      request.JobRunId.String(),
+		Attempt:       int32(request.Attempt),
+		ScheduledDate: timestamppb.New(request.ScheduledDate),
+		Job:           request.Job,
+		Data:          data,
+	}, nil
+}
+
+// connection returns connection of endpoint pool, pool is created on first use
+func (t *GrpcTransport) connection(endpoint string) (*grpc.ClientConn, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if pool, ok := t.pools[endpoint]; ok {
+		return pool.get(), nil
+	}
+
+	address, secure, err := ParseGrpcEndpoint(endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	creds := insecure.NewCredentials()
+	if secure {
+		creds = credentials.NewTLS(t.tls)
+	}
+
+	pool := &grpcPool{}
+	for i := 0; i < t.config.PoolSize; i++ {
+		// connection is established lazily and re-established by grpc after failures
+		conn, err := grpc.NewClient(address, grpc.WithTransportCredentials(creds))
+		if err != nil {
+			for _, opened := range pool.connections {
+				_ = opened.Close()
+			}
+			return nil, err
+		}
+		pool.connections = append(pool.connections, conn)
+	}
+
+	t.pools[endpoint] = pool
+	return pool.get(), nil
+}
+
+func (t *GrpcTransport) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var err error
+	for endpoint, pool := range t.pools {
+		for _, conn := range pool.connections {
+			err = errors.Join(err, conn.Close())
+		}
+		delete(t.pools, endpoint)
+	}
+
+	return err
+}
@@ -0,0 +1,143 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+	"timely/libs/jobspb"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type jobServiceFake struct {
+	jobspb.UnimplementedJobServiceServer
+	requests chan *jobspb.StartJobRequest
+	delay    time.Duration
+}
+
+func (s *jobServiceFake) StartJob(ctx context.Context, req *jobspb.StartJobRequest) (*jobspb.StartJobResponse, error) {
+	select {
+	case <-time.After(s.delay):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	s.requests <- req
+	return &jobspb.StartJobResponse{}, nil
+}
+
+func startJobServiceFake(t *testing.T, delay time.Duration) (string, *jobServiceFake) {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fake := &jobServiceFake{requests: make(chan *jobspb.StartJobRequest, 1), delay: delay}
+	server := grpc.NewServer()
+	jobspb.RegisterJobServiceServer(server, fake)
+
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(server.Stop)
+
+	return "grpc://" + listener.Addr().String(), fake
+}
+
+func TestParseGrpcEndpoint(t *testing.T) {
+	tests := map[string]struct {
+		endpoint string
+
+		address string
+		secure  bool
+		err     error
+	}{
+		"plaintext": {
+			endpoint: "grpc://worker:50051",
+			address:  "worker:50051",
+		},
+		"tls": {
+			endpoint: "grpcs://worker:50051",
+			address:  "worker:50051",
+			secure:   true,
+		},
+		"http_scheme": {
+			endpoint: "http://worker:50051",
+			err:      ErrInvalidGrpcEndpoint,
+		},
+		"path": {
+			endpoint: "grpc://worker:50051/jobs",
+			err:      ErrInvalidGrpcEndpoint,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			address, secure, err := ParseGrpcEndpoint(test.endpoint)
+
+			if !errors.Is(err, test.err) {
+				t.Errorf("expect error %+v, got %+v", test.err, err)
+			}
+
+			if address != test.address || secure != test.secure {
+				t.Errorf("expect result %s %v, got %s %v", test.address, test.secure, address, secure)
+			}
+		})
+	}
+}
+
+func TestGrpcTransportStart(t *testing.T) {
+	endpoint, fake := startJobServiceFake(t, 0)
+
+	transport, err := NewGrpcTransport(GrpcConfig{PoolSize: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = transport.Close() })
+
+	data := map[string]any{"key": "value"}
+	request := ScheduleJobRequest{
+		ScheduleId:    uuid.New(),
+		GroupId:       uuid.New(),
+		JobRunId:      uuid.New(),
+		Attempt:       2,
+		ScheduledDate: getStubDate(),
+		Job:           "slug",
+		Data:          &data,
+	}
+
+	if err = transport.Start(context.Background(), endpoint, request); err != nil {
+		t.Fatal(err)
+	}
+
+	received := <-fake.requests
+	if received.GetJobRunId() != request.JobRunId.String() || received.GetAttempt() != 2 ||
+		received.GetJob() != "slug" || !received.GetScheduledDate().AsTime().Equal(request.ScheduledDate) ||
+		received.GetData().AsMap()["key"] != "value" {
+		t.Errorf("expect result %+v, got %+v", request, received)
+	}
+
+	if len(transport.pools) != 1 || len(transport.pools[endpoint].connections) != 2 {
+		t.Errorf("expect result %+v, got %+v", 1, len(transport.pools))
+	}
+}
+
+func TestGrpcTransportDeadline(t *testing.T) {
+	endpoint, _ := startJobServiceFake(t, time.Second)
+
+	transport, err := NewGrpcTransport(GrpcConfig{Timeout: time.Millisecond * 50})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = transport.Close() })
+
+	err = transport.Start(context.Background(), endpoint, ScheduleJobRequest{Job: "slug"})
+	if status.Code(errors.Unwrap(err)) != codes.DeadlineExceeded {
+		t.Errorf("expect error %+v, got %+v", codes.DeadlineExceeded, err)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/google/uuid"
 	"go.uber.org/zap"
@@ -20,11 +21,13 @@ type HttpTransport struct {
 }
 
 type ScheduleJobRequest struct {
-	ScheduleId uuid.UUID       `json:"scheduleId"`
-	GroupId    uuid.UUID       `json:"groupId"`
-	JobRunId   uuid.UUID       `json:"jobRunId"`
-	Job        string          `json:"job"`
-	Data       *map[string]any `json:"data"`
+	ScheduleId    uuid.UUID       `json:"scheduleId"`
+	GroupId       uuid.UUID       `json:"groupId"`
+	JobRunId      uuid.UUID       `json:"jobRunId"`
+	Attempt       int             `json:"attempt"`
+	ScheduledDate time.Time       `json:"scheduledDate"`
+	Job           string          `json:"job"`
+	Data          *map[string]any `json:"data"`
 }
 
 var InvalidScheduleStartResponse = Error{
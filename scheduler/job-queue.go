@@ -0,0 +1,160 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+)
+
+// JobQueue is rabbitmq queue named after job slug, shared by all schedules of the job
+type JobQueue struct {
+	Name              string
+	ExternallyManaged bool // queue is never removed by timely
+	References        int  // unfinished schedules dispatching to the queue
+	CreationDate      time.Time
+}
+
+// QueueInspector is implemented by async transports able to list queues existing on broker
+type QueueInspector interface {
+	GetBoundQueues(ctx context.Context, exchange string) ([]string, error)
+}
+
+type QueueReconciliationConfig struct {
+	Interval      time.Duration
+	DeleteOrphans bool // orphaned queues are only reported when disabled
+}
+
+// ReleaseJobQueue removes queue of the job when no other schedule references it, returns whether queue
+// has been removed, queue row stays locked during removal so schedule can't start using it meanwhile
+func ReleaseJobQueue(ctx context.Context, storage StorageDriver, transport AsyncTransportDriver,
+	name string) (bool, error) {
+	released := false
+
+	err := storage.WithTx(ctx, func(tx StorageTx) error {
+		queue, err := tx.GetJobQueueForUpdate(ctx, name)
+		if err != nil || queue == nil || queue.ExternallyManaged {
+			return err
+		}
+
+		references, err := tx.CountJobQueueReferences(ctx, name)
+		if err != nil || references > 0 {
+			return err
+		}
+
+		if err = transport.DeleteQueue(name); err != nil {
+			return err
+		}
+
+		released = true
+		return tx.DeleteJobQueue(ctx, name)
+	})
+
+	return released, err
+}
+
+func (s *Scheduler) releaseJobQueue(ctx context.Context, schedule *Schedule) {
+	transport, ok := s.asyncTransport(Rabbitmq)
+	if !schedule.UsesJobQueue() || !ok {
+		return
+	}
+
+	released, err := ReleaseJobQueue(ctx, s.Storage, transport, schedule.Job.Slug)
+	if err != nil {
+		s.logger.Errorf("error during releasing queue %s - %v", schedule.Job.Slug, err)
+		return
+	}
+
+	if released {
+		s.logger.Infof("removed queue %s of the last schedule %s", schedule.Job.Slug, schedule.Id)
+	}
+}
+
+func (s *Scheduler) reconcileJobQueues(ctx context.Context, config QueueReconciliationConfig) {
+	s.logger.Info("starting job queues reconciliation")
+
+	if config.Interval <= 0 {
+		config.Interval = time.Hour
+	}
+
+	for {
+		if err := s.reconcileJobQueuesOnce(ctx, config); err != nil {
+			s.logger.Errorf("error during job queues reconciliation - %v", err)
+		}
+
+		time.Sleep(config.Interval)
+	}
+}
+
+// reconcileJobQueuesOnce releases known queues without references, which removal failed before,
+// and finds queues bound to job exchange which are not known at all
+func (s *Scheduler) reconcileJobQueuesOnce(ctx context.Context, config QueueReconciliationConfig) error {
+	transport, ok := s.asyncTransport(Rabbitmq)
+	if !ok {
+		return nil
+	}
+
+	// broker is listed first, queue is declared only after its schedule is stored, so queue created
+	// in the meantime is already known
+	var bound []string
+	if inspector, ok := transport.(QueueInspector); ok {
+		var err error
+		bound, err = inspector.GetBoundQueues(ctx, string(JobScheduleExchange))
+
+		// without management api broker can't be listed, so only orphans are not detected
+		if err != nil && !errors.Is(err, ErrManagementNotConfigured) {
+			return err
+		}
+	}
+
+	known, err := s.Storage.GetJobQueues(ctx)
+	if err != nil {
+		return err
+	}
+
+	unreferenced, orphaned := planJobQueueReconciliation(known, bound)
+
+	for _, name := range unreferenced {
+		if _, err = ReleaseJobQueue(ctx, s.Storage, transport, name); err != nil {
+			s.logger.Errorf("error during releasing queue %s - %v", name, err)
+		}
+	}
+
+	for _, name := range orphaned {
+		if !config.DeleteOrphans {
+			s.logger.Warnf("found orphaned queue %s", name)
+			continue
+		}
+
+		if err = transport.DeleteQueue(name); err != nil {
+			s.logger.Errorf("error during deleting orphaned queue %s - %v", name, err)
+			continue
+		}
+
+		s.logger.Infof("removed orphaned queue %s", name)
+	}
+
+	return nil
+}
+
+// planJobQueueReconciliation returns known queues without references and queues on broker which are not known
+func planJobQueueReconciliation(known []JobQueue, bound []string) ([]string, []string) {
+	var unreferenced, orphaned []string
+
+	names := make([]string, 0, len(known))
+	for _, queue := range known {
+		names = append(names, queue.Name)
+
+		if queue.References == 0 && !queue.ExternallyManaged {
+			unreferenced = append(unreferenced, queue.Name)
+		}
+	}
+
+	for _, name := range bound {
+		if !slices.Contains(names, name) && !slices.Contains(orphaned, name) {
+			orphaned = append(orphaned, name)
+		}
+	}
+
+	return unreferenced, orphaned
+}
@@ -0,0 +1,164 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"slices"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestPlanJobQueueReconciliation(t *testing.T) {
+	tests := map[string]struct {
+		known []JobQueue
+		bound []string
+
+		expectUnreferenced []string
+		expectOrphaned     []string
+	}{
+		"referenced_queues": {
+			known: []JobQueue{{Name: "a", References: 1}, {Name: "b", References: 2}},
+			bound: []string{"a", "b"},
+		},
+		"unreferenced_queue": {
+			known:              []JobQueue{{Name: "a", References: 0}, {Name: "b", References: 1}},
+			bound:              []string{"a", "b"},
+			expectUnreferenced: []string{"a"},
+		},
+		"unreferenced_external_queue_is_kept": {
+			known: []JobQueue{{Name: "a", ExternallyManaged: true}},
+			bound: []string{"a"},
+		},
+		"unknown_bound_queue": {
+			known:          []JobQueue{{Name: "a", References: 1}},
+			bound:          []string{"a", "c", "c"},
+			expectOrphaned: []string{"c"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			unreferenced, orphaned := planJobQueueReconciliation(test.known, test.bound)
+
+			if !reflect.DeepEqual(unreferenced, test.expectUnreferenced) {
+				t.Errorf("expect unreferenced %+v, got %+v", test.expectUnreferenced, unreferenced)
+			}
+
+			if !reflect.DeepEqual(orphaned, test.expectOrphaned) {
+				t.Errorf("expect orphaned %+v, got %+v", test.expectOrphaned, orphaned)
+			}
+		})
+	}
+}
+
+// jobQueueStorageFake keeps job queues in memory, remaining storage methods are not used
+type jobQueueStorageFake struct {
+	StorageDriver
+	queues map[string]JobQueue
+}
+
+type jobQueueTxFake struct {
+	StorageTx
+	storage *jobQueueStorageFake
+}
+
+func (s *jobQueueStorageFake) GetJobQueues(ctx context.Context) ([]JobQueue, error) {
+	queues := make([]JobQueue, 0, len(s.queues))
+	for _, queue := range s.queues {
+		queues = append(queues, queue)
+	}
+
+	return queues, nil
+}
+
+func (s *jobQueueStorageFake) WithTx(ctx context.Context, fn func(tx StorageTx) error) error {
+	return fn(jobQueueTxFake{storage: s})
+}
+
+func (t jobQueueTxFake) GetJobQueueForUpdate(ctx context.Context, name string) (*JobQueue, error) {
+	if queue, ok := t.storage.queues[name]; ok {
+		return &queue, nil
+	}
+
+	return nil, nil
+}
+
+func (t jobQueueTxFake) CountJobQueueReferences(ctx context.Context, name string) (int, error) {
+	return t.storage.queues[name].References, nil
+}
+
+func (t jobQueueTxFake) DeleteJobQueue(ctx context.Context, name string) error {
+	delete(t.storage.queues, name)
+	return nil
+}
+
+// queueInspectorFake lists bound queues of broker and records deleted ones
+type queueInspectorFake struct {
+	AsyncTransportDriver
+	bound   []string
+	err     error
+	deleted []string
+}
+
+func (i *queueInspectorFake) GetBoundQueues(ctx context.Context, exchange string) ([]string, error) {
+	return i.bound, i.err
+}
+
+func (i *queueInspectorFake) DeleteQueue(queue string) error {
+	i.deleted = append(i.deleted, queue)
+	return nil
+}
+
+func TestReconcileJobQueues(t *testing.T) {
+	listErr := errors.New("connection refused")
+
+	tests := map[string]struct {
+		bound []string
+		err   error
+
+		expectErr     error
+		expectDeleted []string
+	}{
+		"orphaned_queue": {
+			bound:         []string{"referenced", "unreferenced", "orphaned"},
+			expectDeleted: []string{"orphaned", "unreferenced"},
+		},
+		"management_not_configured": {
+			err:           ErrManagementNotConfigured,
+			expectDeleted: []string{"unreferenced"},
+		},
+		"listing_failed": {
+			err:       listErr,
+			expectErr: listErr,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			Supports = []string{string(Rabbitmq)}
+
+			storage := &jobQueueStorageFake{queues: map[string]JobQueue{
+				"referenced":   {Name: "referenced", References: 1},
+				"unreferenced": {Name: "unreferenced"},
+			}}
+			transport := &queueInspectorFake{bound: test.bound, err: test.err}
+			s := &Scheduler{
+				Storage:         storage,
+				AsyncTransports: map[TransportType]AsyncTransportDriver{Rabbitmq: transport},
+				logger:          zap.NewNop().Sugar(),
+			}
+
+			err := s.reconcileJobQueuesOnce(context.Background(), QueueReconciliationConfig{DeleteOrphans: true})
+			if !errors.Is(err, test.expectErr) {
+				t.Errorf("expect error %+v, got %+v", test.expectErr, err)
+			}
+
+			slices.Sort(transport.deleted)
+			if !slices.Equal(transport.deleted, test.expectDeleted) {
+				t.Errorf("expect result %+v, got %+v", test.expectDeleted, transport.deleted)
+			}
+		})
+	}
+}
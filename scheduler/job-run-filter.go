@@ -0,0 +1,16 @@
+package scheduler
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type JobRunFilter struct {
+	ScheduleId uuid.UUID
+	GroupId    uuid.UUID
+	Status     JobRunStatus
+	From       *time.Time // inclusive start date lower bound
+	To         *time.Time // exclusive start date upper bound
+	Reason     string     // free text search on reason
+}
@@ -0,0 +1,86 @@
+package scheduler
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type StatsWindow string
+
+const (
+	LastHour  StatsWindow = "1h"
+	LastDay   StatsWindow = "24h"
+	LastWeek  StatsWindow = "7d"
+	LastMonth StatsWindow = "30d"
+)
+
+var statsWindows = map[StatsWindow]time.Duration{
+	LastHour:  time.Hour,
+	LastDay:   time.Hour * 24,
+	LastWeek:  time.Hour * 24 * 7,
+	LastMonth: time.Hour * 24 * 30,
+}
+
+func (w StatsWindow) Duration() (time.Duration, error) {
+	d, exists := statsWindows[w]
+	if !exists {
+		return 0, errors.New("invalid stats window")
+	}
+
+	return d, nil
+}
+
+type JobRunStatsFilter struct {
+	ScheduleId uuid.UUID // empty for stats of all schedules
+	JobSlug    string    // empty for stats of all job slugs
+	From       time.Time
+	To         time.Time
+}
+
+type JobRunStats struct {
+	Total       int
+	Succeed     int
+	Failed      int
+	Waiting     int
+	Retried     int        // job runs with attempt greater than first one
+	Percentiles []float64  // p50, p95, p99 of finished job runs duration in seconds, empty without finished runs
+	FirstFail   *time.Time // start date of first failed job run in window
+	LastFail    *time.Time // start date of last failed job run in window
+}
+
+// SuccessRate returns ratio of succeeded to finished job runs, nil without finished job runs
+func (s JobRunStats) SuccessRate() *float64 {
+	finished := s.Succeed + s.Failed
+	if finished == 0 {
+		return nil
+	}
+
+	rate := float64(s.Succeed) / float64(finished)
+
+	return &rate
+}
+
+// RetryRate returns ratio of retries to all job runs, nil without job runs
+func (s JobRunStats) RetryRate() *float64 {
+	if s.Total == 0 {
+		return nil
+	}
+
+	rate := float64(s.Retried) / float64(s.Total)
+
+	return &rate
+}
+
+// MeanTimeBetweenFailures returns mean interval between consecutive failures,
+// nil when there are less than two failures
+func (s JobRunStats) MeanTimeBetweenFailures() *time.Duration {
+	if s.Failed < 2 || s.FirstFail == nil || s.LastFail == nil {
+		return nil
+	}
+
+	mtbf := s.LastFail.Sub(*s.FirstFail) / time.Duration(s.Failed-1)
+
+	return &mtbf
+}
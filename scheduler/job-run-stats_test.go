@@ -0,0 +1,71 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStatsWindowDuration(t *testing.T) {
+	tests := map[string]struct {
+		window StatsWindow
+
+		expected  time.Duration
+		expectErr bool
+	}{
+		"last_hour":      {window: LastHour, expected: time.Hour},
+		"last_week":      {window: LastWeek, expected: time.Hour * 24 * 7},
+		"invalid_window": {window: "2y", expectErr: true},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			d, err := test.window.Duration()
+
+			if test.expectErr != (err != nil) {
+				t.Fatalf("expect error %v, got %v", test.expectErr, err)
+			}
+
+			if d != test.expected {
+				t.Errorf("expect result %+v, got %+v", test.expected, d)
+			}
+		})
+	}
+}
+
+func TestJobRunStatsRates(t *testing.T) {
+	stats := JobRunStats{Total: 10, Succeed: 6, Failed: 2, Waiting: 2, Retried: 1}
+
+	if *stats.SuccessRate() != 0.75 {
+		t.Errorf("expect result %+v, got %+v", 0.75, *stats.SuccessRate())
+	}
+
+	if *stats.RetryRate() != 0.1 {
+		t.Errorf("expect result %+v, got %+v", 0.1, *stats.RetryRate())
+	}
+}
+
+func TestJobRunStatsRatesWithoutJobRuns(t *testing.T) {
+	stats := JobRunStats{}
+
+	if stats.SuccessRate() != nil {
+		t.Errorf("expect result %+v, got %+v", nil, *stats.SuccessRate())
+	}
+
+	if stats.RetryRate() != nil {
+		t.Errorf("expect result %+v, got %+v", nil, *stats.RetryRate())
+	}
+
+	if stats.MeanTimeBetweenFailures() != nil {
+		t.Errorf("expect result %+v, got %+v", nil, *stats.MeanTimeBetweenFailures())
+	}
+}
+
+func TestJobRunStatsMeanTimeBetweenFailures(t *testing.T) {
+	first, last := getStubDate(), getStubDate().Add(time.Hour)
+	stats := JobRunStats{Total: 3, Failed: 3, FirstFail: &first, LastFail: &last}
+
+	expected := time.Minute * 30
+	if *stats.MeanTimeBetweenFailures() != expected {
+		t.Errorf("expect result %+v, got %+v", expected, *stats.MeanTimeBetweenFailures())
+	}
+}
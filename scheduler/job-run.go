@@ -20,13 +20,15 @@ const (
 )
 
 type JobRun struct {
-	Id         uuid.UUID
-	GroupId    uuid.UUID
-	ScheduleId uuid.UUID
-	Status     JobRunStatus
-	Reason     *string
-	StartDate  time.Time
-	EndDate    *time.Time
+	Id            uuid.UUID
+	GroupId       uuid.UUID
+	ScheduleId    uuid.UUID
+	Status        JobRunStatus
+	Attempt       int       // attempt within occurrence (group), starting from 1
+	ScheduledDate time.Time // date when occurrence or retry was intended to start
+	Reason        *string
+	StartDate     time.Time
+	EndDate       *time.Time
 }
 
 type StaleJobRun struct {
@@ -36,27 +38,48 @@ type StaleJobRun struct {
 	JobStartDate      time.Time
 }
 
-func NewJobRun(scheduleId uuid.UUID, groupId uuid.UUID, now func() time.Time) JobRun {
+func NewJobRun(scheduleId uuid.UUID, groupId uuid.UUID, attempt int, scheduledDate time.Time,
+	now func() time.Time) JobRun {
 	return JobRun{
-		Id:         uuid.New(),
-		ScheduleId: scheduleId,
-		GroupId:    groupId,
-		Status:     JobWaiting,
-		Reason:     nil,
-		StartDate:  now().Round(time.Second),
-		EndDate:    nil,
+		Id:            uuid.New(),
+		ScheduleId:    scheduleId,
+		GroupId:       groupId,
+		Status:        JobWaiting,
+		Attempt:       attempt,
+		ScheduledDate: scheduledDate,
+		Reason:        nil,
+		StartDate:     now().Round(time.Second),
+		EndDate:       nil,
 	}
 }
 
-func (jr *JobRun) Succeed(now func() time.Time) {
+var ErrIllegalJobRunTransition = &Error{
+	Code: "ILLEGAL_JOB_RUN_TRANSITION",
+	Msg:  "job run status can be changed only once from waiting"}
+
+// Succeed finishes waiting job run, finished job runs are final
+func (jr *JobRun) Succeed(now func() time.Time) error {
+	if jr.Status != JobWaiting {
+		return ErrIllegalJobRunTransition
+	}
+
 	jr.Status = JobSucceed
 	end := now().Round(time.Second)
 	jr.EndDate = &end
+
+	return nil
 }
 
-func (jr *JobRun) Failed(reason string, now func() time.Time) {
+// Failed finishes waiting job run, finished job runs are final
+func (jr *JobRun) Failed(reason string, now func() time.Time) error {
+	if jr.Status != JobWaiting {
+		return ErrIllegalJobRunTransition
+	}
+
 	jr.Status = JobFailed
 	jr.Reason = &reason
 	end := now().Round(time.Second)
 	jr.EndDate = &end
+
+	return nil
 }
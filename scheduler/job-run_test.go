@@ -1,6 +1,7 @@
 package scheduler
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/google/uuid"
@@ -10,16 +11,18 @@ func TestNewRun(t *testing.T) {
 	groupId, scheduleId := uuid.New(), uuid.New()
 
 	expected := JobRun{
-		Id:         [16]byte{},
-		GroupId:    groupId,
-		ScheduleId: scheduleId,
-		Status:     JobWaiting,
-		Reason:     nil,
-		StartDate:  getStubDate(),
-		EndDate:    nil,
+		Id:            [16]byte{},
+		GroupId:       groupId,
+		ScheduleId:    scheduleId,
+		Status:        JobWaiting,
+		Attempt:       2,
+		ScheduledDate: getStubDate(),
+		Reason:        nil,
+		StartDate:     getStubDate(),
+		EndDate:       nil,
 	}
 
-	jr := NewJobRun(scheduleId, groupId, getStubDate)
+	jr := NewJobRun(scheduleId, groupId, 2, getStubDate(), getStubDate)
 
 	expected.Id = jr.Id
 
@@ -29,7 +32,7 @@ func TestNewRun(t *testing.T) {
 }
 
 func TestSucceed(t *testing.T) {
-	jr := NewJobRun(uuid.New(), uuid.New(), getStubDate)
+	jr := NewJobRun(uuid.New(), uuid.New(), 1, getStubDate(), getStubDate)
 
 	jr.Succeed(getStubDate)
 
@@ -44,7 +47,7 @@ func TestSucceed(t *testing.T) {
 }
 
 func TestFailed(t *testing.T) {
-	jr := NewJobRun(uuid.New(), uuid.New(), getStubDate)
+	jr := NewJobRun(uuid.New(), uuid.New(), 1, getStubDate(), getStubDate)
 
 	jr.Failed("test fail reason", getStubDate)
 
@@ -61,3 +64,31 @@ func TestFailed(t *testing.T) {
 		t.Errorf("expect result %+v, got %+v", "test fail reason", jr.Status)
 	}
 }
+
+func TestFinishedJobRunTransitions(t *testing.T) {
+	tests := map[string]struct {
+		status JobRunStatus
+	}{
+		"succeed": {status: JobSucceed},
+		"failed":  {status: JobFailed},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			jr := NewJobRun(uuid.New(), uuid.New(), 1, getStubDate(), getStubDate)
+			jr.Status = test.status
+
+			if err := jr.Succeed(getStubDate); !errors.Is(err, ErrIllegalJobRunTransition) {
+				t.Errorf("expect error %+v, got %+v", ErrIllegalJobRunTransition, err)
+			}
+
+			if err := jr.Failed("test fail reason", getStubDate); !errors.Is(err, ErrIllegalJobRunTransition) {
+				t.Errorf("expect error %+v, got %+v", ErrIllegalJobRunTransition, err)
+			}
+
+			if jr.Status != test.status || jr.EndDate != nil {
+				t.Errorf("expect unchanged job run, got %+v", jr)
+			}
+		})
+	}
+}
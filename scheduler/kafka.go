@@ -0,0 +1,403 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"go.uber.org/zap"
+)
+
+type PartitionKey string
+
+const (
+	PartitionByJob      PartitionKey = "job"      // runs of the same job are ordered
+	PartitionBySchedule PartitionKey = "schedule" // runs of the same schedule are ordered, jobs spread over partitions
+)
+
+const (
+	defaultKafkaConsumerGroup = "timely"
+	defaultKafkaWriteTimeout  = time.Second * 10
+	kafkaBatchTimeout         = time.Millisecond * 10
+)
+
+var (
+	ErrMissingBrokers = &Error{
+		Code: "MISSING_BROKERS",
+		Msg:  "at least one kafka broker is required"}
+	ErrInvalidPartitionKey = &Error{
+		Code: "INVALID_PARTITION_KEY",
+		Msg:  "partition key has to be job or schedule"}
+	ErrTopicNotFound = &Error{
+		Code: "TOPIC_NOT_FOUND",
+		Msg:  "kafka topic does not exist"}
+)
+
+// KafkaConfig describes brokers and topics, schedule events are produced to topic named after job slug
+// unless SharedTopic is set, all topic names are prefixed with TopicPrefix
+type KafkaConfig struct {
+	Brokers           []string
+	TopicPrefix       string
+	SharedTopic       string       // single topic for all jobs, message key tells jobs apart
+	PartitionBy       PartitionKey // message key of schedule events
+	ConsumerGroup     string       // consumer group of job status subscription
+	Partitions        int          // partitions of topics created by timely
+	ReplicationFactor int          // replication factor of topics created by timely
+	WriteTimeout      time.Duration
+}
+
+func (c KafkaConfig) Validate() error {
+	var err error
+
+	if len(c.Brokers) == 0 {
+		err = errors.Join(err, ErrMissingBrokers)
+	}
+
+	if !slices.Contains([]PartitionKey{PartitionByJob, PartitionBySchedule}, c.PartitionBy) {
+		err = errors.Join(err, ErrInvalidPartitionKey)
+	}
+
+	return err
+}
+
+func (c KafkaConfig) topic(name string) string {
+	return c.TopicPrefix + name
+}
+
+type KafkaTransport struct {
+	config        KafkaConfig
+	client        *kafka.Client
+	writer        *kafka.Writer
+	subscribeOpts []SubscribeOption
+
+	// partition counts of topics known to exist
+	topics *sync.Map
+
+	logger *zap.SugaredLogger
+}
+
+type KafkaOption func(*KafkaTransport)
+
+// WithKafkaSubscribeDefaults sets options used by every subscription, prefetch is queue capacity of reader
+// and workers are consumer group members, at most one per partition
+func WithKafkaSubscribeDefaults(opts ...SubscribeOption) KafkaOption {
+	return func(t *KafkaTransport) {
+		t.subscribeOpts = append(t.subscribeOpts, opts...)
+	}
+}
+
+func NewKafkaTransport(config KafkaConfig, logger *zap.SugaredLogger, opts ...KafkaOption) (*KafkaTransport, error) {
+	if config.PartitionBy == "" {
+		config.PartitionBy = PartitionByJob
+	}
+
+	if config.ConsumerGroup == "" {
+		config.ConsumerGroup = defaultKafkaConsumerGroup
+	}
+
+	if config.WriteTimeout <= 0 {
+		config.WriteTimeout = defaultKafkaWriteTimeout
+	}
+
+	config.Partitions = max(config.Partitions, 1)
+	config.ReplicationFactor = max(config.ReplicationFactor, 1)
+
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	addr := kafka.TCP(config.Brokers...)
+	transport := &KafkaTransport{
+		config: config,
+		client: &kafka.Client{Addr: addr, Timeout: config.WriteTimeout},
+		writer: &kafka.Writer{
+			Addr:         addr,
+			Balancer:     &kafka.Hash{},
+			RequiredAcks: kafka.RequireAll,
+			BatchTimeout: kafkaBatchTimeout,
+			WriteTimeout: config.WriteTimeout,
+		},
+		topics: &sync.Map{},
+		logger: logger,
+	}
+
+	for _, opt := range opts {
+		opt(transport)
+	}
+
+	if err := transport.Health(context.Background()); err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+	logger.Info("connected to kafka")
+
+	return transport, nil
+}
+
+// Publish produces message acknowledged by all in-sync replicas, messages with the same key
+// end up in the same partition
+func (t *KafkaTransport) Publish(ctx context.Context, topic, key string, message any) error {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return errors.New("invalid message format")
+	}
+
+	topic = t.config.topic(topic)
+	err = t.writer.WriteMessages(ctx, kafka.Message{
+		Topic: topic,
+		Key:   []byte(key),
+		Value: data,
+	})
+
+	err = kafkaPublishError(err)
+	if errors.Is(err, ErrMessageUnroutable) {
+		// topic is most likely gone, it is created again by the next job run
+		t.topics.Delete(topic)
+	}
+
+	return err
+}
+
+// kafkaPublishError maps errors of rejected messages to dispatch failures
+func kafkaPublishError(err error) error {
+	var writeErrs kafka.WriteErrors
+	if errors.As(err, &writeErrs) {
+		for _, writeErr := range writeErrs {
+			if writeErr != nil {
+				err = writeErr
+				break
+			}
+		}
+	}
+
+	switch {
+	case errors.Is(err, kafka.UnknownTopicOrPartition), errors.Is(err, kafka.InvalidTopic):
+		return errors.Join(ErrMessageUnroutable, err)
+	case errors.Is(err, kafka.MessageSizeTooLarge):
+		return errors.Join(ErrPublishNacked, err)
+	}
+
+	return err
+}
+
+// Subscribe consumes topic in consumer group until context is cancelled, offset of message is committed
+// after it is processed, so messages are redelivered when subscriber crashes
+func (t *KafkaTransport) Subscribe(ctx context.Context, topic string, handle func(message []byte) error,
+	opts ...SubscribeOption) error {
+	options := newSubscribeOptions(append(slices.Clone(t.subscribeOpts), opts...)...)
+	topic = t.config.topic(topic)
+
+	for attempt := 1; ; attempt++ {
+		partitions, err := t.ensureTopic(ctx, topic)
+		if err == nil {
+			err = t.consume(ctx, topic, handle, options, min(options.Workers, partitions))
+		}
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		backoff := reconnectBackoff(attempt)
+		t.logger.Warnf("consumer of %s interrupted, resubscribing in %s - %v", topic, backoff, err)
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// consume runs group members until one of them fails, in-flight messages are finished before return
+func (t *KafkaTransport) consume(ctx context.Context, topic string, handle func(message []byte) error,
+	options SubscribeOptions, members int) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	errs := make(chan error, members)
+	var wg sync.WaitGroup
+	for i := 0; i < members; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer cancel()
+
+			errs <- t.member(ctx, topic, handle, options)
+		}()
+	}
+	wg.Wait()
+
+	return <-errs
+}
+
+func (t *KafkaTransport) member(ctx context.Context, topic string, handle func(message []byte) error,
+	options SubscribeOptions) error {
+	reader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:       t.config.Brokers,
+		GroupID:       t.config.ConsumerGroup,
+		Topic:         topic,
+		QueueCapacity: options.Prefetch,
+		StartOffset:   kafka.FirstOffset,
+	})
+	defer reader.Close()
+
+	for {
+		message, err := reader.FetchMessage(ctx)
+		if err != nil {
+			return err
+		}
+
+		t.process(ctx, message, handle, options)
+
+		// message is redelivered to the next member of group when commit fails
+		if err = reader.CommitMessages(ctx, message); err != nil {
+			return err
+		}
+	}
+}
+
+// process retries failed message in place, so messages of partition stay ordered, message is dropped
+// once retries are exhausted
+func (t *KafkaTransport) process(ctx context.Context, message kafka.Message, handle func(message []byte) error,
+	options SubscribeOptions) {
+	for count := 0; ; {
+		err := handle(message.Value)
+		if err == nil {
+			return
+		}
+
+		t.logger.Errorf("error during consumer action processing - %v", err)
+
+		var retry bool
+		if count, retry = retryAttempt(count, options); !retry {
+			t.logger.Warnf("dropping message %s/%d/%d after %d retries", message.Topic, message.Partition,
+				message.Offset, count)
+			return
+		}
+
+		select {
+		case <-time.After(options.RequeueDelay):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Prepare creates topic of the job, custom topic is managed outside of timely so only its existence is checked
+func (t *KafkaTransport) Prepare(ctx context.Context, schedule *Schedule) error {
+	topic, _ := t.Route(schedule)
+	topic = t.config.topic(topic)
+
+	if schedule.Configuration.Exchange != "" {
+		_, err := t.checkTopic(ctx, topic)
+		return err
+	}
+
+	_, err := t.ensureTopic(ctx, topic)
+	return err
+}
+
+// Route returns topic and message key of schedule events, custom topic and key take precedence
+func (t *KafkaTransport) Route(schedule *Schedule) (string, string) {
+	topic := schedule.Job.Slug
+	switch {
+	case schedule.Configuration.Exchange != "":
+		topic = schedule.Configuration.Exchange
+	case t.config.SharedTopic != "":
+		topic = t.config.SharedTopic
+	}
+
+	key := schedule.Job.Slug
+	switch {
+	case schedule.Configuration.RoutingKey != "":
+		key = schedule.Configuration.RoutingKey
+	case t.config.PartitionBy == PartitionBySchedule:
+		key = schedule.Id.String()
+	}
+
+	return topic, key
+}
+
+// ensureTopic creates topic unless it exists and returns its partition count
+func (t *KafkaTransport) ensureTopic(ctx context.Context, topic string) (int, error) {
+	if partitions, ok := t.topics.Load(topic); ok {
+		return partitions.(int), nil
+	}
+
+	resp, err := t.client.CreateTopics(ctx, &kafka.CreateTopicsRequest{
+		Topics: []kafka.TopicConfig{{
+			Topic:             topic,
+			NumPartitions:     t.config.Partitions,
+			ReplicationFactor: t.config.ReplicationFactor,
+		}},
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if err = resp.Errors[topic]; err != nil && !errors.Is(err, kafka.TopicAlreadyExists) {
+		return 0, err
+	}
+
+	return t.checkTopic(ctx, topic)
+}
+
+// checkTopic verifies that topic exists and returns its partition count
+func (t *KafkaTransport) checkTopic(ctx context.Context, topic string) (int, error) {
+	resp, err := t.client.Metadata(ctx, &kafka.MetadataRequest{Topics: []string{topic}})
+	if err != nil {
+		return 0, err
+	}
+
+	for _, metadata := range resp.Topics {
+		if metadata.Name != topic {
+			continue
+		}
+
+		if errors.Is(metadata.Error, kafka.UnknownTopicOrPartition) {
+			return 0, errors.Join(ErrTopicNotFound, fmt.Errorf("topic %s", topic))
+		}
+
+		if metadata.Error != nil {
+			return 0, metadata.Error
+		}
+
+		partitions := max(len(metadata.Partitions), 1)
+		t.topics.Store(topic, partitions)
+		return partitions, nil
+	}
+
+	return 0, errors.Join(ErrTopicNotFound, fmt.Errorf("topic %s", topic))
+}
+
+// DeleteQueue deletes topic, topic which does not exist is not an error
+func (t *KafkaTransport) DeleteQueue(topic string) error {
+	topic = t.config.topic(topic)
+	t.topics.Delete(topic)
+
+	resp, err := t.client.DeleteTopics(context.Background(), &kafka.DeleteTopicsRequest{Topics: []string{topic}})
+	if err != nil {
+		return err
+	}
+
+	if err = resp.Errors[topic]; err != nil && !errors.Is(err, kafka.UnknownTopicOrPartition) {
+		return err
+	}
+
+	return nil
+}
+
+// Health checks that cluster metadata can be fetched
+func (t *KafkaTransport) Health(ctx context.Context) error {
+	_, err := t.client.Metadata(ctx, &kafka.MetadataRequest{Topics: []string{}})
+	return err
+}
+
+func (t *KafkaTransport) Close() error {
+	return t.writer.Close()
+}
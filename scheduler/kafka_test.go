@@ -0,0 +1,124 @@
+package scheduler
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/segmentio/kafka-go"
+)
+
+func TestKafkaConfigValidate(t *testing.T) {
+	tests := map[string]struct {
+		config KafkaConfig
+		err    error
+	}{
+		"valid_config": {
+			config: KafkaConfig{Brokers: []string{"localhost:9092"}, PartitionBy: PartitionBySchedule},
+		},
+		"missing_brokers": {
+			config: KafkaConfig{PartitionBy: PartitionByJob},
+			err:    ErrMissingBrokers,
+		},
+		"unknown_partition_key": {
+			config: KafkaConfig{Brokers: []string{"localhost:9092"}, PartitionBy: "namespace"},
+			err:    ErrInvalidPartitionKey,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := test.config.Validate()
+
+			if !errors.Is(err, test.err) {
+				t.Errorf("expect error %+v, got %+v", test.err, err)
+			}
+		})
+	}
+}
+
+func TestKafkaRoute(t *testing.T) {
+	scheduleId := uuid.MustParse("5f0c8a3e-7d2b-4c1a-9e6f-3b8d2a1c4e70")
+
+	tests := map[string]struct {
+		config     KafkaConfig
+		exchange   string
+		routingKey string
+
+		expectTopic string
+		expectKey   string
+	}{
+		"topic_per_job": {
+			config:      KafkaConfig{PartitionBy: PartitionByJob},
+			expectTopic: "slug",
+			expectKey:   "slug",
+		},
+		"shared_topic": {
+			config:      KafkaConfig{SharedTopic: "jobs", PartitionBy: PartitionByJob},
+			expectTopic: "jobs",
+			expectKey:   "slug",
+		},
+		"partitioned_by_schedule": {
+			config:      KafkaConfig{SharedTopic: "jobs", PartitionBy: PartitionBySchedule},
+			expectTopic: "jobs",
+			expectKey:   scheduleId.String(),
+		},
+		"custom_topic_and_key": {
+			config:      KafkaConfig{SharedTopic: "jobs", PartitionBy: PartitionBySchedule},
+			exchange:    "orders",
+			routingKey:  "orders.created",
+			expectTopic: "orders",
+			expectKey:   "orders.created",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			s := NewSchedule("description", "once", getStubDate,
+				WithConfiguration(Kafka, ""),
+				WithRouting(test.exchange, test.routingKey),
+				WithJob("slug", nil))
+			s.Id = scheduleId
+
+			transport := &KafkaTransport{config: test.config}
+			topic, key := transport.Route(&s)
+			if topic != test.expectTopic || key != test.expectKey {
+				t.Errorf("expect result %s %s, got %s %s", test.expectTopic, test.expectKey, topic, key)
+			}
+		})
+	}
+}
+
+func TestKafkaPublishError(t *testing.T) {
+	tests := map[string]struct {
+		err      error
+		expected error
+	}{
+		"unknown_topic": {
+			err:      kafka.UnknownTopicOrPartition,
+			expected: ErrMessageUnroutable,
+		},
+		"unknown_topic_of_batch": {
+			err:      kafka.WriteErrors{nil, kafka.UnknownTopicOrPartition},
+			expected: ErrMessageUnroutable,
+		},
+		"message_too_large": {
+			err:      kafka.MessageSizeTooLarge,
+			expected: ErrPublishNacked,
+		},
+		"leader_not_available": {
+			err:      kafka.LeaderNotAvailable,
+			expected: kafka.LeaderNotAvailable,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := kafkaPublishError(test.err)
+
+			if !errors.Is(err, test.expected) {
+				t.Errorf("expect error %+v, got %+v", test.expected, err)
+			}
+		})
+	}
+}
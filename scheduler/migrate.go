@@ -0,0 +1,298 @@
+package scheduler
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/jackc/pgx/v5"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+var (
+	ErrUnknownSchemaVersion = &Error{
+		Code: "UNKNOWN_SCHEMA_VERSION",
+		Msg:  "database schema is newer than known migrations"}
+	ErrPendingMigrations = &Error{
+		Code: "PENDING_MIGRATIONS",
+		Msg:  "database schema is not up to date, run migrations"}
+	ErrMigrationMismatch = &Error{
+		Code: "MIGRATION_MISMATCH",
+		Msg:  "applied migration does not match known migration of the same version"}
+)
+
+// migrationLockKey is advisory lock key which makes only one instance migrate schema at a time
+const migrationLockKey = 7468_003
+
+var migrationFileRegex = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// LoadMigrations reads migrations named <version>_<name>.(up|down).sql ordered by version
+func LoadMigrations(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		match := migrationFileRegex.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("invalid migration file name %s", entry.Name())
+		}
+
+		version, _ := strconv.Atoi(match[1])
+		content, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		migration, exists := byVersion[version]
+		if !exists {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		}
+
+		if migration.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has conflicting names %s and %s",
+				version, migration.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" {
+			return nil, fmt.Errorf("migration %d is missing up script", migration.Version)
+		}
+
+		migrations = append(migrations, *migration)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+func embeddedMigrations() ([]Migration, error) {
+	return LoadMigrations(migrationFiles, "migrations")
+}
+
+// MigrateUp applies all pending migrations, each one in own transaction
+func (pg Pgsql) MigrateUp(ctx context.Context) error {
+	migrations, err := embeddedMigrations()
+	if err != nil {
+		return err
+	}
+
+	return pg.withMigrationLock(ctx, func(conn *pgx.Conn, current int) error {
+		if len(migrations) > 0 && current > migrations[len(migrations)-1].Version {
+			return ErrUnknownSchemaVersion
+		}
+
+		if err := checkAppliedMigrations(ctx, conn, migrations); err != nil {
+			return err
+		}
+
+		for _, migration := range migrations {
+			if migration.Version <= current {
+				continue
+			}
+
+			err := pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+				if _, err := tx.Exec(ctx, migration.Up); err != nil {
+					return err
+				}
+
+				_, err := tx.Exec(ctx, `INSERT INTO schema_migrations (version, name, applied_at)
+						VALUES ($1, $2, now())`, migration.Version, migration.Name)
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("migration %d_%s failed - %w", migration.Version, migration.Name, err)
+			}
+		}
+
+		return nil
+	})
+}
+
+// querier is implemented by both single connection and pool
+type querier interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+}
+
+// checkAppliedMigrations refuses database whose recorded migrations differ from embedded ones, later
+// migrations would be applied on top of schema they were not written for
+func checkAppliedMigrations(ctx context.Context, conn querier, migrations []Migration) error {
+	rows, err := conn.Query(ctx, `SELECT version, name FROM schema_migrations ORDER BY version`)
+	if err != nil {
+		return err
+	}
+
+	applied, err := pgx.CollectRows(rows, pgx.RowToStructByPos[struct {
+		Version int
+		Name    string
+	}])
+	if err != nil {
+		return err
+	}
+
+	names := make(map[int]string, len(migrations))
+	for _, migration := range migrations {
+		names[migration.Version] = migration.Name
+	}
+
+	for _, migration := range applied {
+		if names[migration.Version] != migration.Name {
+			return errors.Join(ErrMigrationMismatch, fmt.Errorf("migration %d_%s applied, known %d_%s",
+				migration.Version, migration.Name, migration.Version, names[migration.Version]))
+		}
+	}
+
+	return nil
+}
+
+// MigrateDown reverts given amount of the most recent migrations
+func (pg Pgsql) MigrateDown(ctx context.Context, steps int) error {
+	migrations, err := embeddedMigrations()
+	if err != nil {
+		return err
+	}
+
+	return pg.withMigrationLock(ctx, func(conn *pgx.Conn, current int) error {
+		for i := len(migrations) - 1; i >= 0 && steps > 0; i-- {
+			migration := migrations[i]
+			if migration.Version > current {
+				continue
+			}
+
+			if migration.Down == "" {
+				return fmt.Errorf("migration %d_%s can't be reverted", migration.Version, migration.Name)
+			}
+
+			err := pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+				if _, err := tx.Exec(ctx, migration.Down); err != nil {
+					return err
+				}
+
+				_, err := tx.Exec(ctx, `DELETE FROM schema_migrations WHERE version = $1`, migration.Version)
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("migration %d_%s revert failed - %w", migration.Version, migration.Name, err)
+			}
+
+			steps--
+		}
+
+		return nil
+	})
+}
+
+// SchemaVersion returns version of the latest applied migration, zero for empty database
+func (pg Pgsql) SchemaVersion(ctx context.Context) (int, error) {
+	var exists bool
+	err := pg.pool.QueryRow(ctx, `SELECT to_regclass('schema_migrations') IS NOT NULL`).Scan(&exists)
+	if err != nil || !exists {
+		return 0, err
+	}
+
+	var version int
+	err = pg.pool.QueryRow(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&version)
+	if err != nil {
+		return 0, err
+	}
+
+	return version, nil
+}
+
+// CheckSchemaVersion verifies that database schema matches embedded migrations, both its version and each
+// applied migration
+func (pg Pgsql) CheckSchemaVersion(ctx context.Context) error {
+	migrations, err := embeddedMigrations()
+	if err != nil {
+		return err
+	}
+
+	current, err := pg.SchemaVersion(ctx)
+	if err != nil {
+		return err
+	}
+
+	if current > 0 {
+		if err = checkAppliedMigrations(ctx, pg.pool, migrations); err != nil {
+			return err
+		}
+	}
+
+	latest := 0
+	if len(migrations) > 0 {
+		latest = migrations[len(migrations)-1].Version
+	}
+
+	switch {
+	case current > latest:
+		return errors.Join(ErrUnknownSchemaVersion,
+			fmt.Errorf("database version %d, latest known %d", current, latest))
+	case current < latest:
+		return errors.Join(ErrPendingMigrations,
+			fmt.Errorf("database version %d, latest known %d", current, latest))
+	}
+
+	return nil
+}
+
+// withMigrationLock runs migration step on single connection holding session advisory lock,
+// so concurrently starting instances wait for each other instead of racing
+func (pg Pgsql) withMigrationLock(ctx context.Context, step func(conn *pgx.Conn, current int) error) error {
+	conn, err := pg.pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	if _, err = conn.Exec(ctx, `SELECT pg_advisory_lock($1)`, migrationLockKey); err != nil {
+		return err
+	}
+	defer conn.Exec(context.Background(), `SELECT pg_advisory_unlock($1)`, migrationLockKey)
+
+	_, err = conn.Exec(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations
+		(
+			version INT NOT NULL PRIMARY KEY,
+			name CHARACTER VARYING(256) NOT NULL,
+			applied_at TIMESTAMP WITH TIME ZONE NOT NULL
+		)`)
+	if err != nil {
+		return err
+	}
+
+	var current int
+	err = conn.QueryRow(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&current)
+	if err != nil {
+		return err
+	}
+
+	return step(conn.Conn(), current)
+}
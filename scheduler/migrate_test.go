@@ -0,0 +1,83 @@
+package scheduler
+
+import (
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	tests := map[string]struct {
+		files fstest.MapFS
+
+		expected  []Migration
+		expectErr bool
+	}{
+		"ordered_by_version": {
+			files: fstest.MapFS{
+				"migrations/0002_labels.up.sql":    {Data: []byte("up 2")},
+				"migrations/0001_initial.up.sql":   {Data: []byte("up 1")},
+				"migrations/0001_initial.down.sql": {Data: []byte("down 1")},
+			},
+			expected: []Migration{
+				{Version: 1, Name: "initial", Up: "up 1", Down: "down 1"},
+				{Version: 2, Name: "labels", Up: "up 2"},
+			},
+		},
+		"missing_up_script": {
+			files: fstest.MapFS{
+				"migrations/0001_initial.down.sql": {Data: []byte("down 1")},
+			},
+			expectErr: true,
+		},
+		"invalid_file_name": {
+			files: fstest.MapFS{
+				"migrations/initial.sql": {Data: []byte("up 1")},
+			},
+			expectErr: true,
+		},
+		"conflicting_names": {
+			files: fstest.MapFS{
+				"migrations/0001_initial.up.sql": {Data: []byte("up 1")},
+				"migrations/0001_other.down.sql": {Data: []byte("down 1")},
+			},
+			expectErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			result, err := LoadMigrations(test.files, "migrations")
+
+			if test.expectErr != (err != nil) {
+				t.Fatalf("expect error %v, got %v", test.expectErr, err)
+			}
+
+			if len(result) != len(test.expected) {
+				t.Fatalf("expect result %+v, got %+v", test.expected, result)
+			}
+
+			for i := range result {
+				if result[i] != test.expected[i] {
+					t.Errorf("expect result %+v, got %+v", test.expected[i], result[i])
+				}
+			}
+		})
+	}
+}
+
+func TestEmbeddedMigrations(t *testing.T) {
+	migrations, err := embeddedMigrations()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, migration := range migrations {
+		if migration.Version != i+1 {
+			t.Errorf("expect version %d, got %d", i+1, migration.Version)
+		}
+
+		if migration.Down == "" {
+			t.Errorf("expect down script for migration %d", migration.Version)
+		}
+	}
+}
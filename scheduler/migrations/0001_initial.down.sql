@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS job_runs;
+DROP TABLE IF EXISTS jobs;
+DROP TABLE IF EXISTS schedules;
@@ -1,15 +1,5 @@
-﻿CREATE DATABASE "Timely"
-    WITH
-    OWNER = postgres
-    ENCODING = 'UTF8'
-    LOCALE_PROVIDER = 'libc'
-    CONNECTION LIMIT = -1;
-    
---
-DROP TABLE IF EXISTS job_runs;
-DROP TABLE IF EXISTS jobs;
-DROP TABLE IF EXISTS schedules;
-
+-- baseline schema, the same as former database_schema.sql, so databases created from it are adopted
+-- as version 1 and receive later changes through following migrations
 CREATE TABLE IF NOT EXISTS schedules
 (
     id UUID NOT NULL PRIMARY KEY,
@@ -50,4 +40,4 @@ CREATE INDEX IF NOT EXISTS schedules_status_next_execution_date_idx
     ON schedules(status, next_execution_date ASC);
 
 CREATE INDEX IF NOT EXISTS job_runs_status_start_date_idx 
-    ON job_runs(status, start_date ASC);
\ No newline at end of file
+    ON job_runs(status, start_date ASC);
@@ -0,0 +1 @@
+ALTER TABLE schedules DROP COLUMN IF EXISTS jitter;
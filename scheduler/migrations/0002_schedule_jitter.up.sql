@@ -0,0 +1 @@
+ALTER TABLE schedules ADD COLUMN IF NOT EXISTS jitter CHARACTER VARYING(32);
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS job_runs_schedule_id_start_date_idx;
+DROP INDEX IF EXISTS schedules_labels_idx;
+DROP INDEX IF EXISTS schedules_namespace_idx;
+
+ALTER TABLE schedules DROP COLUMN IF EXISTS labels;
+ALTER TABLE schedules DROP COLUMN IF EXISTS namespace;
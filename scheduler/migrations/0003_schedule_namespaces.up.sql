@@ -0,0 +1,11 @@
+ALTER TABLE schedules ADD COLUMN IF NOT EXISTS namespace CHARACTER VARYING(64) NOT NULL DEFAULT 'default';
+ALTER TABLE schedules ADD COLUMN IF NOT EXISTS labels JSONB NOT NULL DEFAULT '{}';
+
+CREATE INDEX IF NOT EXISTS schedules_namespace_idx 
+    ON schedules(namespace);
+
+CREATE INDEX IF NOT EXISTS schedules_labels_idx 
+    ON schedules USING GIN (labels);
+
+CREATE INDEX IF NOT EXISTS job_runs_schedule_id_start_date_idx 
+    ON job_runs(schedule_id, start_date DESC);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS schedules_creation_date_id_idx;
+
+ALTER TABLE schedules DROP COLUMN IF EXISTS creation_date;
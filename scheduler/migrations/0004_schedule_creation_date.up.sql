@@ -0,0 +1,5 @@
+-- existing schedules get migration date, their order in cursor pagination is kept by id
+ALTER TABLE schedules ADD COLUMN IF NOT EXISTS creation_date TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now();
+
+CREATE INDEX IF NOT EXISTS schedules_creation_date_id_idx 
+    ON schedules(creation_date DESC, id DESC);
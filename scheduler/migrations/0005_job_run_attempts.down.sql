@@ -0,0 +1,4 @@
+ALTER TABLE job_runs DROP COLUMN IF EXISTS scheduled_date;
+ALTER TABLE job_runs DROP COLUMN IF EXISTS attempt;
+
+ALTER TABLE schedules DROP COLUMN IF EXISTS attempt;
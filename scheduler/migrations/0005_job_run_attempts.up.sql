@@ -0,0 +1,9 @@
+ALTER TABLE schedules ADD COLUMN IF NOT EXISTS attempt INT NOT NULL DEFAULT 0;
+
+ALTER TABLE job_runs ADD COLUMN IF NOT EXISTS attempt INT NOT NULL DEFAULT 1;
+ALTER TABLE job_runs ADD COLUMN IF NOT EXISTS scheduled_date TIMESTAMP WITH TIME ZONE;
+
+-- scheduled date of existing job runs is unknown, start date is the closest one
+UPDATE job_runs SET scheduled_date = start_date WHERE scheduled_date IS NULL;
+
+ALTER TABLE job_runs ALTER COLUMN scheduled_date SET NOT NULL;
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS job_runs_start_date_idx;
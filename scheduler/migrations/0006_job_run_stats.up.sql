@@ -0,0 +1,2 @@
+CREATE INDEX IF NOT EXISTS job_runs_start_date_idx 
+    ON job_runs(start_date);
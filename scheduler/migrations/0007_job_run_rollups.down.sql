@@ -0,0 +1,4 @@
+DROP AGGREGATE IF EXISTS array_sum(INT[]);
+DROP FUNCTION IF EXISTS array_add(INT[], INT[]);
+DROP TABLE IF EXISTS job_run_rollup_watermark;
+DROP TABLE IF EXISTS job_run_rollups;
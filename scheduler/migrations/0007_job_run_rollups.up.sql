@@ -0,0 +1,41 @@
+-- rollups are not referencing schedules, so stats survive schedule removal
+CREATE TABLE IF NOT EXISTS job_run_rollups
+(
+    granularity CHARACTER VARYING(16) NOT NULL,
+    bucket_start TIMESTAMP WITH TIME ZONE NOT NULL,
+    schedule_id UUID NOT NULL,
+    job_slug CHARACTER VARYING(256) NOT NULL,
+    total INT NOT NULL,
+    succeed INT NOT NULL,
+    failed INT NOT NULL,
+    waiting INT NOT NULL,
+    retried INT NOT NULL,
+    duration_histogram INT[] NOT NULL,
+    PRIMARY KEY (granularity, bucket_start, schedule_id)
+);
+
+CREATE TABLE IF NOT EXISTS job_run_rollup_watermark
+(
+    id INT NOT NULL PRIMARY KEY,
+    watermark TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+-- element-wise sum of histograms
+CREATE OR REPLACE FUNCTION array_add(a INT[], b INT[]) RETURNS INT[] AS $$
+    SELECT ARRAY(
+        SELECT COALESCE(x, 0) + COALESCE(y, 0)
+        FROM unnest(a, b) WITH ORDINALITY AS t(x, y, i)
+        ORDER BY i)
+$$ LANGUAGE SQL IMMUTABLE;
+
+CREATE OR REPLACE AGGREGATE array_sum(INT[]) (
+    SFUNC = array_add,
+    STYPE = INT[],
+    INITCOND = '{}'
+);
+
+CREATE INDEX IF NOT EXISTS job_run_rollups_job_slug_idx 
+    ON job_run_rollups(granularity, job_slug, bucket_start);
+
+CREATE INDEX IF NOT EXISTS job_run_rollups_schedule_id_idx 
+    ON job_run_rollups(granularity, schedule_id, bucket_start);
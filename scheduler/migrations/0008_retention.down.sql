@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS schedules_frequency_status_last_execution_date_idx;
+
+DROP TABLE IF EXISTS job_runs_archive;
+DROP TABLE IF EXISTS jobs_archive;
+DROP TABLE IF EXISTS schedules_archive;
+
+ALTER TABLE schedules DROP COLUMN IF EXISTS job_run_retention;
@@ -0,0 +1,23 @@
+ALTER TABLE schedules ADD COLUMN IF NOT EXISTS job_run_retention CHARACTER VARYING(32);
+
+-- archive tables mirror columns of source tables, retention moves rows there when archiving is enabled
+CREATE TABLE IF NOT EXISTS schedules_archive
+(
+    LIKE schedules,
+    archive_date TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS jobs_archive
+(
+    LIKE jobs,
+    archive_date TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS job_runs_archive
+(
+    LIKE job_runs,
+    archive_date TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS schedules_frequency_status_last_execution_date_idx 
+    ON schedules(frequency, status, last_execution_date ASC);
@@ -0,0 +1,31 @@
+-- job runs of all partitions are moved back to single table
+ALTER TABLE job_runs RENAME TO job_runs_partitioned;
+ALTER TABLE job_runs_partitioned RENAME CONSTRAINT job_runs_pkey TO job_runs_partitioned_pkey;
+
+CREATE TABLE job_runs
+(
+    id UUID NOT NULL PRIMARY KEY,
+    group_id UUID NOT NULL,
+    schedule_id UUID NOT NULL REFERENCES schedules(id) ON DELETE CASCADE,
+    status CHARACTER VARYING(128) NOT NULL,
+    reason CHARACTER VARYING(1024),
+    start_date TIMESTAMP WITH TIME ZONE NOT NULL,
+    end_date TIMESTAMP WITH TIME ZONE,
+    attempt INT NOT NULL DEFAULT 1,
+    scheduled_date TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+INSERT INTO job_runs (id, group_id, schedule_id, status, reason, start_date, end_date, attempt, scheduled_date)
+    SELECT id, group_id, schedule_id, status, reason, start_date, end_date, attempt, scheduled_date
+    FROM job_runs_partitioned;
+
+DROP TABLE job_runs_partitioned CASCADE;
+
+CREATE INDEX IF NOT EXISTS job_runs_schedule_id_start_date_idx 
+    ON job_runs(schedule_id, start_date DESC);
+
+CREATE INDEX IF NOT EXISTS job_runs_start_date_idx 
+    ON job_runs(start_date);
+
+CREATE INDEX IF NOT EXISTS job_runs_status_start_date_idx 
+    ON job_runs(status, start_date ASC);
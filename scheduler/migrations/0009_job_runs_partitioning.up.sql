@@ -0,0 +1,43 @@
+-- job_runs are partitioned monthly by start_date, partitions are created and dropped by scheduler,
+-- default partition keeps job runs which don't fit into any of them. existing job runs are copied
+-- to default partition and moved to monthly partitions once scheduler creates them
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM pg_partitioned_table WHERE partrelid = 'job_runs'::regclass) THEN
+        RETURN;
+    END IF;
+
+    ALTER TABLE job_runs RENAME TO job_runs_unpartitioned;
+    ALTER TABLE job_runs_unpartitioned RENAME CONSTRAINT job_runs_pkey TO job_runs_unpartitioned_pkey;
+
+    CREATE TABLE job_runs
+    (
+        id UUID NOT NULL,
+        group_id UUID NOT NULL,
+        schedule_id UUID NOT NULL REFERENCES schedules(id) ON DELETE CASCADE,
+        status CHARACTER VARYING(128) NOT NULL,
+        reason CHARACTER VARYING(1024),
+        start_date TIMESTAMP WITH TIME ZONE NOT NULL,
+        end_date TIMESTAMP WITH TIME ZONE,
+        attempt INT NOT NULL DEFAULT 1,
+        scheduled_date TIMESTAMP WITH TIME ZONE NOT NULL,
+        PRIMARY KEY (id, start_date)
+    ) PARTITION BY RANGE (start_date);
+
+    CREATE TABLE job_runs_default PARTITION OF job_runs DEFAULT;
+
+    INSERT INTO job_runs (id, group_id, schedule_id, status, reason, start_date, end_date, attempt, scheduled_date)
+        SELECT id, group_id, schedule_id, status, reason, start_date, end_date, attempt, scheduled_date
+        FROM job_runs_unpartitioned;
+
+    DROP TABLE job_runs_unpartitioned;
+END $$;
+
+CREATE INDEX IF NOT EXISTS job_runs_schedule_id_start_date_idx 
+    ON job_runs(schedule_id, start_date DESC);
+
+CREATE INDEX IF NOT EXISTS job_runs_start_date_idx 
+    ON job_runs(start_date);
+
+CREATE INDEX IF NOT EXISTS job_runs_status_start_date_idx 
+    ON job_runs(status, start_date ASC);
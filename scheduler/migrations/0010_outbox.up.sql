@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS outbox
+(
+    id UUID NOT NULL PRIMARY KEY,
+    job_run_id UUID NOT NULL,
+    exchange CHARACTER VARYING(256) NOT NULL,
+    routing_key CHARACTER VARYING(256) NOT NULL,
+    payload JSONB NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    last_error CHARACTER VARYING(1024),
+    next_attempt_date TIMESTAMP WITH TIME ZONE NOT NULL,
+    creation_date TIMESTAMP WITH TIME ZONE NOT NULL,
+    sent_date TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS outbox_pending_idx 
+    ON outbox(creation_date ASC) WHERE sent_date IS NULL;
+
+CREATE INDEX IF NOT EXISTS outbox_sent_date_idx 
+    ON outbox(sent_date) WHERE sent_date IS NOT NULL;
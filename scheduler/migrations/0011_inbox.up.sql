@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS inbox
+(
+    event_id UUID NOT NULL PRIMARY KEY,
+    job_run_id UUID NOT NULL,
+    processed_date TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS inbox_processed_date_idx 
+    ON inbox(processed_date);
@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS outbox_discard_date_idx;
+
+DROP INDEX IF EXISTS outbox_pending_idx;
+
+CREATE INDEX IF NOT EXISTS outbox_pending_idx 
+    ON outbox(creation_date ASC) WHERE sent_date IS NULL;
+
+ALTER TABLE outbox DROP COLUMN IF EXISTS discard_date;
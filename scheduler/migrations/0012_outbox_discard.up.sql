@@ -0,0 +1,9 @@
+ALTER TABLE outbox ADD COLUMN IF NOT EXISTS discard_date TIMESTAMP WITH TIME ZONE;
+
+DROP INDEX IF EXISTS outbox_pending_idx;
+
+CREATE INDEX IF NOT EXISTS outbox_pending_idx 
+    ON outbox(creation_date ASC) WHERE sent_date IS NULL AND discard_date IS NULL;
+
+CREATE INDEX IF NOT EXISTS outbox_discard_date_idx 
+    ON outbox(discard_date) WHERE discard_date IS NOT NULL;
@@ -0,0 +1,5 @@
+ALTER TABLE schedules_archive DROP COLUMN IF EXISTS routing_key;
+ALTER TABLE schedules_archive DROP COLUMN IF EXISTS exchange;
+
+ALTER TABLE schedules DROP COLUMN IF EXISTS routing_key;
+ALTER TABLE schedules DROP COLUMN IF EXISTS exchange;
@@ -0,0 +1,6 @@
+-- rabbitmq schedules can be dispatched to custom exchange instead of queue named after job slug
+ALTER TABLE schedules ADD COLUMN IF NOT EXISTS exchange CHARACTER VARYING(256) NOT NULL DEFAULT '';
+ALTER TABLE schedules ADD COLUMN IF NOT EXISTS routing_key CHARACTER VARYING(256) NOT NULL DEFAULT '';
+
+ALTER TABLE schedules_archive ADD COLUMN IF NOT EXISTS exchange CHARACTER VARYING(256) NOT NULL DEFAULT '';
+ALTER TABLE schedules_archive ADD COLUMN IF NOT EXISTS routing_key CHARACTER VARYING(256) NOT NULL DEFAULT '';
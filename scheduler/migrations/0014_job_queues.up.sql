@@ -0,0 +1,15 @@
+-- queues named after job slug are shared by schedules of the same job, queue is removed
+-- only when its last schedule goes away, externally managed queues are never removed
+CREATE TABLE IF NOT EXISTS job_queues
+(
+    name CHARACTER VARYING(256) NOT NULL PRIMARY KEY,
+    externally_managed BOOLEAN NOT NULL DEFAULT false,
+    creation_date TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+);
+
+INSERT INTO job_queues (name)
+    SELECT DISTINCT j.slug
+    FROM jobs AS j
+    JOIN schedules AS s ON s.id = j.schedule_id
+    WHERE s.transport_type = 'rabbitmq' AND s.exchange = ''
+ON CONFLICT (name) DO NOTHING;
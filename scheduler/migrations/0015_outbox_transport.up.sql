@@ -0,0 +1,2 @@
+-- outbox messages are published with async transport of their schedule
+ALTER TABLE outbox ADD COLUMN IF NOT EXISTS transport_type CHARACTER VARYING(32) NOT NULL DEFAULT 'rabbitmq';
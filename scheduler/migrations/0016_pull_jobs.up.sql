@@ -0,0 +1,18 @@
+-- jobs of pull transport wait here until worker claims them, lease of claimed job expires
+-- when worker does not report status in time, so job is available to other workers again
+CREATE TABLE IF NOT EXISTS pull_jobs
+(
+    job_run_id UUID NOT NULL PRIMARY KEY,
+    schedule_id UUID NOT NULL,
+    slug CHARACTER VARYING(256) NOT NULL,
+    payload JSONB NOT NULL,
+    deliveries INT NOT NULL DEFAULT 0,
+    lease_expiry TIMESTAMP WITH TIME ZONE,
+    creation_date TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS pull_jobs_slug_idx
+    ON pull_jobs(slug, creation_date ASC);
+
+CREATE INDEX IF NOT EXISTS pull_jobs_schedule_id_idx
+    ON pull_jobs(schedule_id);
@@ -0,0 +1,372 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultNatsJobsStream     = "timely-jobs"
+	defaultNatsJobsSubject    = "timely.jobs"
+	defaultNatsDurable        = "timely"
+	defaultNatsAckWait        = time.Second * 30
+	defaultNatsPublishTimeout = time.Second * 5
+)
+
+var (
+	ErrNatsDisconnected = &Error{
+		Code: "NATS_DISCONNECTED",
+		Msg:  "nats connection is not available"}
+	ErrStreamNotFound = &Error{
+		Code: "STREAM_NOT_FOUND",
+		Msg:  "stream does not exist"}
+)
+
+// NatsConfig describes streams of jetstream, schedule events are published to subject `<JobsSubject>.<job slug>`
+// captured by JobsStream, all stream names and subjects are prefixed with Prefix
+type NatsConfig struct {
+	Url            string
+	Prefix         string
+	JobsStream     string
+	JobsSubject    string
+	Durable        string        // durable consumer of job status stream
+	Replicas       int           // replicas of streams created by timely
+	MaxAge         time.Duration // schedule events expire when not consumed in time, zero keeps them
+	AckWait        time.Duration // unacked message is redelivered after ack wait
+	PublishTimeout time.Duration // how long publish waits for stream acknowledgement
+}
+
+type NatsTransport struct {
+	config        NatsConfig
+	connection    *nats.Conn
+	js            jetstream.JetStream
+	subscribeOpts []SubscribeOption
+
+	// streams known to exist
+	streams *sync.Map
+
+	logger *zap.SugaredLogger
+}
+
+type NatsOption func(*NatsTransport)
+
+// WithNatsSubscribeDefaults sets options used by every subscription, prefetch is max ack pending
+// of consumer and workers limit concurrently processed messages
+func WithNatsSubscribeDefaults(opts ...SubscribeOption) NatsOption {
+	return func(t *NatsTransport) {
+		t.subscribeOpts = append(t.subscribeOpts, opts...)
+	}
+}
+
+func NewNatsTransport(config NatsConfig, logger *zap.SugaredLogger, opts ...NatsOption) (*NatsTransport, error) {
+	if config.JobsStream == "" {
+		config.JobsStream = defaultNatsJobsStream
+	}
+
+	if config.JobsSubject == "" {
+		config.JobsSubject = defaultNatsJobsSubject
+	}
+
+	if config.Durable == "" {
+		config.Durable = defaultNatsDurable
+	}
+
+	if config.AckWait <= 0 {
+		config.AckWait = defaultNatsAckWait
+	}
+
+	if config.PublishTimeout <= 0 {
+		config.PublishTimeout = defaultNatsPublishTimeout
+	}
+
+	config.Replicas = max(config.Replicas, 1)
+
+	// connection is re-dialed by client for as long as it takes, subscriptions are restored afterwards
+	conn, err := nats.Connect(config.Url,
+		nats.Name("timely"),
+		nats.MaxReconnects(-1),
+		nats.DisconnectErrHandler(func(_ *nats.Conn, err error) {
+			logger.Errorf("nats disconnected - %v", err)
+		}),
+		nats.ReconnectHandler(func(conn *nats.Conn) {
+			logger.Infof("reconnected to nats %s", conn.ConnectedUrlRedacted())
+		}))
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+	logger.Info("connected to nats")
+
+	js, err := jetstream.New(conn)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	transport := &NatsTransport{
+		config:     config,
+		connection: conn,
+		js:         js,
+		streams:    &sync.Map{},
+		logger:     logger,
+	}
+
+	for _, opt := range opts {
+		opt(transport)
+	}
+
+	return transport, nil
+}
+
+func (c NatsConfig) name(name string) string {
+	return c.Prefix + name
+}
+
+func (c NatsConfig) jobSubject(slug string) string {
+	return c.name(c.JobsSubject) + "." + slug
+}
+
+// Publish sends message to stream and waits for its acknowledgement, routing key is appended to subject
+// as the last token, message which is not captured by any stream is reported as unroutable
+func (t *NatsTransport) Publish(ctx context.Context, subject, routingKey string, message any) error {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return errors.New("invalid message format")
+	}
+
+	if routingKey != "" {
+		subject += "." + routingKey
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, t.config.PublishTimeout)
+	defer cancel()
+
+	_, err = t.js.Publish(ctx, t.config.name(subject), data)
+	if err != nil {
+		t.logger.Errorf("error during nats publish - %v", err)
+	}
+
+	err = natsPublishError(err)
+	if errors.Is(err, ErrMessageUnroutable) {
+		// stream is most likely gone, it is created again by the next job run
+		t.streams.Delete(t.config.name(t.config.JobsStream))
+	}
+
+	return err
+}
+
+// natsPublishError maps errors of messages which did not reach stream to dispatch failures
+func natsPublishError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, jetstream.ErrNoStreamResponse), errors.Is(err, nats.ErrNoResponders):
+		return errors.Join(ErrMessageUnroutable, err)
+	case errors.Is(err, context.DeadlineExceeded):
+		return errors.Join(ErrPublishConfirmTimeout, err)
+	}
+
+	return err
+}
+
+// Subscribe consumes stream named after queue with durable consumer until context is cancelled, processed message
+// is acked, failed message is naked with delay or terminated once requeues are exhausted
+func (t *NatsTransport) Subscribe(ctx context.Context, queue string, handle func(message []byte) error,
+	opts ...SubscribeOption) error {
+	options := newSubscribeOptions(append(slices.Clone(t.subscribeOpts), opts...)...)
+	stream := t.config.name(queue)
+
+	for attempt := 1; ; attempt++ {
+		err := t.ensureStream(ctx, jetstream.StreamConfig{
+			Name:      stream,
+			Subjects:  []string{stream},
+			Retention: jetstream.WorkQueuePolicy,
+			Storage:   jetstream.FileStorage,
+			Replicas:  t.config.Replicas,
+		})
+		if err == nil {
+			err = t.consume(ctx, stream, handle, options)
+		}
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		backoff := reconnectBackoff(attempt)
+		t.logger.Warnf("consumer of %s interrupted, resubscribing in %s - %v", stream, backoff, err)
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// consume processes messages until iterator fails, in-flight messages are finished before return
+func (t *NatsTransport) consume(ctx context.Context, stream string, handle func(message []byte) error,
+	options SubscribeOptions) error {
+	consumer, err := t.js.CreateOrUpdateConsumer(ctx, stream, jetstream.ConsumerConfig{
+		Durable:       t.config.Durable,
+		AckPolicy:     jetstream.AckExplicitPolicy,
+		AckWait:       t.config.AckWait,
+		MaxAckPending: options.Prefetch,
+	})
+	if err != nil {
+		return err
+	}
+
+	iter, err := consumer.Messages(jetstream.PullMaxMessages(options.Prefetch))
+	if err != nil {
+		return err
+	}
+
+	stop := context.AfterFunc(ctx, iter.Stop)
+	defer stop()
+
+	messages := make(chan jetstream.Msg)
+	var wg sync.WaitGroup
+	for i := 0; i < options.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for message := range messages {
+				t.process(message, handle, options)
+			}
+		}()
+	}
+
+	for {
+		var message jetstream.Msg
+		message, err = iter.Next()
+		if err != nil {
+			break
+		}
+
+		messages <- message
+	}
+
+	iter.Stop()
+	close(messages)
+	wg.Wait()
+
+	return err
+}
+
+func (t *NatsTransport) process(message jetstream.Msg, handle func(message []byte) error, options SubscribeOptions) {
+	err := handle(message.Data())
+	if err == nil {
+		if err = message.Ack(); err != nil {
+			t.logger.Errorf("error during ack - %v", err)
+		}
+		return
+	}
+
+	t.logger.Errorf("error during consumer action processing - %v", err)
+
+	retries := 0
+	if metadata, metadataErr := message.Metadata(); metadataErr == nil {
+		retries = int(metadata.NumDelivered) - 1
+	}
+
+	if _, retry := retryAttempt(retries, options); retry {
+		err = message.NakWithDelay(options.RequeueDelay)
+	} else {
+		err = message.Term()
+	}
+
+	if err != nil {
+		t.logger.Errorf("error during nak - %v", err)
+	}
+}
+
+// Prepare creates stream of job subjects, custom subject is captured by stream managed outside of timely
+// so only existence of such stream is checked
+func (t *NatsTransport) Prepare(ctx context.Context, schedule *Schedule) error {
+	if schedule.Configuration.Exchange != "" {
+		subject, routingKey := t.Route(schedule)
+		if routingKey != "" {
+			subject += "." + routingKey
+		}
+
+		_, err := t.js.StreamNameBySubject(ctx, t.config.name(subject))
+		if errors.Is(err, jetstream.ErrStreamNotFound) {
+			return errors.Join(ErrStreamNotFound, fmt.Errorf("subject %s", subject))
+		}
+
+		return err
+	}
+
+	return t.ensureStream(ctx, jetstream.StreamConfig{
+		Name:      t.config.name(t.config.JobsStream),
+		Subjects:  []string{t.config.name(t.config.JobsSubject) + ".>"},
+		Retention: jetstream.LimitsPolicy,
+		Storage:   jetstream.FileStorage,
+		Replicas:  t.config.Replicas,
+		MaxAge:    t.config.MaxAge,
+	})
+}
+
+// Route returns subject and its last token of schedule events, custom subject takes precedence
+func (t *NatsTransport) Route(schedule *Schedule) (string, string) {
+	if schedule.Configuration.Exchange != "" {
+		return schedule.Configuration.Exchange, schedule.Configuration.RoutingKey
+	}
+
+	return t.config.JobsSubject + "." + schedule.Job.Slug, ""
+}
+
+// ensureStream creates stream unless it is known to exist
+func (t *NatsTransport) ensureStream(ctx context.Context, config jetstream.StreamConfig) error {
+	if _, ok := t.streams.Load(config.Name); ok {
+		return nil
+	}
+
+	_, err := t.js.CreateOrUpdateStream(ctx, config)
+	if err != nil {
+		return err
+	}
+
+	t.streams.Store(config.Name, struct{}{})
+	return nil
+}
+
+// DeleteQueue purges pending schedule events of the job, stream is shared by all jobs so it is kept
+func (t *NatsTransport) DeleteQueue(slug string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), t.config.PublishTimeout)
+	defer cancel()
+
+	stream, err := t.js.Stream(ctx, t.config.name(t.config.JobsStream))
+	if errors.Is(err, jetstream.ErrStreamNotFound) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return stream.Purge(ctx, jetstream.WithPurgeSubject(t.config.jobSubject(slug)))
+}
+
+func (t *NatsTransport) Health(ctx context.Context) error {
+	if t.connection.Status() != nats.CONNECTED {
+		return ErrNatsDisconnected
+	}
+
+	return nil
+}
+
+// Close waits until published messages are flushed
+func (t *NatsTransport) Close() error {
+	return t.connection.Drain()
+}
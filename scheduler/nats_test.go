@@ -0,0 +1,182 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+	"go.uber.org/zap"
+)
+
+func startNatsTransport(t *testing.T) *NatsTransport {
+	t.Helper()
+
+	ns, err := server.NewServer(&server.Options{Port: -1, JetStream: true, StoreDir: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	go ns.Start()
+	if !ns.ReadyForConnections(time.Second * 5) {
+		t.Fatal("nats server is not ready")
+	}
+	t.Cleanup(ns.Shutdown)
+
+	transport, err := NewNatsTransport(NatsConfig{Url: ns.ClientURL(), Prefix: "test-"}, zap.NewNop().Sugar())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = transport.Close() })
+
+	return transport
+}
+
+func TestNatsRoute(t *testing.T) {
+	tests := map[string]struct {
+		exchange   string
+		routingKey string
+
+		expectSubject    string
+		expectRoutingKey string
+	}{
+		"subject_per_job": {
+			expectSubject: "timely.jobs.slug",
+		},
+		"custom_subject": {
+			exchange:      "orders",
+			expectSubject: "orders",
+		},
+		"custom_subject_with_routing_key": {
+			exchange:         "orders",
+			routingKey:       "created",
+			expectSubject:    "orders",
+			expectRoutingKey: "created",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			s := NewSchedule("description", "once", getStubDate,
+				WithConfiguration(Nats, ""),
+				WithRouting(test.exchange, test.routingKey),
+				WithJob("slug", nil))
+
+			transport := &NatsTransport{config: NatsConfig{JobsSubject: defaultNatsJobsSubject}}
+			subject, routingKey := transport.Route(&s)
+			if subject != test.expectSubject || routingKey != test.expectRoutingKey {
+				t.Errorf("expect result %s %s, got %s %s", test.expectSubject, test.expectRoutingKey,
+					subject, routingKey)
+			}
+		})
+	}
+}
+
+func TestNatsPublish(t *testing.T) {
+	transport := startNatsTransport(t)
+	ctx := context.Background()
+
+	s := NewSchedule("description", "once", getStubDate,
+		WithConfiguration(Nats, ""),
+		WithJob("slug", nil))
+
+	if err := transport.Prepare(ctx, &s); err != nil {
+		t.Fatal(err)
+	}
+
+	subject, routingKey := transport.Route(&s)
+	if err := transport.Publish(ctx, subject, routingKey, ScheduleJobEvent{Job: "slug"}); err != nil {
+		t.Fatal(err)
+	}
+
+	stream, err := transport.js.Stream(ctx, "test-"+defaultNatsJobsStream)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := stream.Info(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if info.State.Msgs != 1 {
+		t.Errorf("expect result %+v, got %+v", 1, info.State.Msgs)
+	}
+
+	if err = transport.DeleteQueue("slug"); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err = stream.Info(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if info.State.Msgs != 0 {
+		t.Errorf("expect result %+v, got %+v", 0, info.State.Msgs)
+	}
+}
+
+func TestNatsPublishUnroutable(t *testing.T) {
+	transport := startNatsTransport(t)
+	ctx := context.Background()
+
+	s := NewSchedule("description", "once", getStubDate,
+		WithConfiguration(Nats, ""),
+		WithRouting("orders", "created"),
+		WithJob("slug", nil))
+
+	if err := transport.Prepare(ctx, &s); !errors.Is(err, ErrStreamNotFound) {
+		t.Errorf("expect error %+v, got %+v", ErrStreamNotFound, err)
+	}
+
+	subject, routingKey := transport.Route(&s)
+	err := transport.Publish(ctx, subject, routingKey, ScheduleJobEvent{Job: "slug"})
+	if !IsDispatchFailure(err) {
+		t.Errorf("expect error %+v, got %+v", ErrMessageUnroutable, err)
+	}
+}
+
+func TestNatsSubscribe(t *testing.T) {
+	transport := startNatsTransport(t)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	var deliveries atomic.Int32
+	done := make(chan error, 1)
+	go func() {
+		done <- transport.Subscribe(ctx, string(JobStatusQueue), func(message []byte) error {
+			// first delivery fails, so message is redelivered after requeue delay
+			if deliveries.Add(1) == 1 {
+				return errors.New("processing error")
+			}
+
+			cancel()
+			return nil
+		}, WithRequeueDelay(time.Millisecond*10, 1), WithWorkers(1))
+	}()
+
+	// stream is created by subscription, messages published before are not captured
+	for {
+		err := transport.Publish(ctx, string(JobStatusQueue), "", JobStatusEvent{Status: "succeed"})
+		if err == nil {
+			break
+		}
+
+		select {
+		case <-time.After(time.Millisecond * 50):
+		case <-ctx.Done():
+			t.Fatalf("stream was not created - %v", err)
+		}
+	}
+
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("expect error %+v, got %+v", context.Canceled, err)
+	}
+
+	if deliveries.Load() != 2 {
+		t.Errorf("expect result %+v, got %+v", 2, deliveries.Load())
+	}
+}
@@ -0,0 +1,210 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OutboxMessage is message stored in the same transaction as state change which caused it,
+// relay publishes it afterwards so message is never lost nor sent for rolled back change
+type OutboxMessage struct {
+	Id              uuid.UUID
+	JobRunId        uuid.UUID
+	Transport       TransportType // async transport message is published with
+	Exchange        string        // exchange or topic, depending on transport
+	RoutingKey      string        // routing key or message key, depending on transport
+	Payload         json.RawMessage
+	Attempts        int
+	LastError       *string
+	NextAttemptDate time.Time
+	CreationDate    time.Time
+	SentDate        *time.Time
+	DiscardDate     *time.Time // message won't be published, its job run failed instead
+}
+
+const (
+	outboxRelayDelay       = time.Millisecond * 500
+	outboxBatchSize        = 100
+	outboxClaimLease       = time.Minute * 2
+	outboxLeaseMargin      = time.Second * 10
+	outboxMaxRetryBackoff  = time.Minute
+	outboxSentRetention    = time.Hour * 24
+	inboxRetention         = time.Hour * 24 * 7
+	messagesPurgeDelay     = time.Hour
+	messagesPurgeBatchSize = 1000
+)
+
+func NewOutboxMessage(jobRunId uuid.UUID, transport TransportType, exchange, routingKey string, message any,
+	now func() time.Time) (OutboxMessage, error) {
+	payload, err := json.Marshal(message)
+	if err != nil {
+		return OutboxMessage{}, err
+	}
+
+	return OutboxMessage{
+		Id:              uuid.New(),
+		JobRunId:        jobRunId,
+		Transport:       transport,
+		Exchange:        exchange,
+		RoutingKey:      routingKey,
+		Payload:         payload,
+		NextAttemptDate: now().Round(time.Second),
+		CreationDate:    now().Round(time.Second),
+	}, nil
+}
+
+func (m *OutboxMessage) Sent(now func() time.Time) {
+	date := now().Round(time.Second)
+	m.SentDate = &date
+}
+
+// Discard stops publishing of message which broker refused to deliver
+func (m *OutboxMessage) Discard(reason string, now func() time.Time) {
+	m.Attempts++
+	m.LastError = &reason
+	date := now().Round(time.Second)
+	m.DiscardDate = &date
+}
+
+// Failed schedules next publish attempt with exponential backoff
+func (m *OutboxMessage) Failed(reason string, now func() time.Time) {
+	m.Attempts++
+	m.LastError = &reason
+
+	backoff := outboxMaxRetryBackoff
+	if m.Attempts < 7 {
+		backoff = min(time.Second*time.Duration(1<<(m.Attempts-1)), outboxMaxRetryBackoff)
+	}
+
+	m.NextAttemptDate = now().Add(backoff).Round(time.Second)
+}
+
+func (s *Scheduler) relayOutbox(ctx context.Context) {
+	s.logger.Info("starting outbox relay")
+
+	for {
+		processed, err := s.relayOutboxBatch(ctx)
+		if err != nil {
+			s.logger.Errorf("error during outbox relay - %v", err)
+		}
+
+		// full batch means there are most likely more pending messages
+		if processed < outboxBatchSize || err != nil {
+			time.Sleep(outboxRelayDelay)
+		}
+	}
+}
+
+// relayOutboxBatch publishes claimed messages without holding database transaction open, result of each
+// publish is stored in its own short transaction
+func (s *Scheduler) relayOutboxBatch(ctx context.Context) (int, error) {
+	claimed := time.Now()
+	messages, err := s.Storage.ClaimOutboxMessages(ctx, outboxBatchSize, outboxClaimLease)
+	if err != nil {
+		return 0, err
+	}
+
+	for i, message := range messages {
+		// lease of remaining messages is about to expire, other relay may claim them, so they are left to it
+		if time.Since(claimed) > outboxClaimLease-outboxLeaseMargin {
+			return i, nil
+		}
+
+		dispatchErr := s.publishOutboxMessage(ctx, message)
+
+		// job run is read without lock only to find its schedule, schedule has to be locked first
+		var jobRun *JobRun
+		if dispatchErr != nil {
+			if jobRun, err = s.Storage.GetJobRun(ctx, message.JobRunId); err != nil {
+				return i, err
+			}
+		}
+
+		// run is failed together with discarding of its message, so neither is stored without the other
+		err = s.Storage.WithTx(ctx, func(tx StorageTx) error {
+			if jobRun != nil {
+				if err := failJobRunTx(ctx, tx, jobRun, dispatchErr); err != nil && !isRejectedJobStatus(err) {
+					return err
+				}
+			}
+
+			return tx.UpdateOutboxMessage(ctx, *message)
+		})
+		if err != nil {
+			return i, err
+		}
+	}
+
+	return len(messages), nil
+}
+
+// publishOutboxMessage publishes message and records the attempt on it, returned error means that broker
+// refused the message and its job run has to be failed
+func (s *Scheduler) publishOutboxMessage(ctx context.Context, message *OutboxMessage) error {
+	transport, ok := s.asyncTransport(message.Transport)
+	if !ok {
+		// transport was disabled after message was stored, message waits until it is enabled again
+		message.Failed(fmt.Sprintf("unsupported transport type - %s", message.Transport), time.Now)
+		return nil
+	}
+
+	err := transport.Publish(ctx, message.Exchange, message.RoutingKey, message.Payload)
+	if err != nil && IsDispatchFailure(err) {
+		// job was not dispatched, run is failed so retry policy of schedule decides about next attempt
+		s.logger.Errorf("failed to dispatch outbox message %s for job run %s - %v", message.Id, message.JobRunId, err)
+		message.Discard(err.Error(), time.Now)
+		return err
+	}
+
+	if err != nil {
+		message.Failed(err.Error(), time.Now)
+		s.logger.Errorf("failed to publish outbox message %s for job run %s, attempt %d - %v",
+			message.Id, message.JobRunId, message.Attempts, err)
+		return nil
+	}
+
+	message.Sent(time.Now)
+	return nil
+}
+
+// purgeProcessedMessages removes sent outbox messages and inbox entries, inbox entries are kept
+// long enough to deduplicate redelivered job statuses
+func (s *Scheduler) purgeProcessedMessages(ctx context.Context) {
+	for {
+		purges := map[string]func() (int64, error){
+			"sent outbox messages": func() (int64, error) {
+				return s.Storage.DeleteSentOutboxMessages(ctx, time.Now().Add(-outboxSentRetention),
+					messagesPurgeBatchSize)
+			},
+			"inbox messages": func() (int64, error) {
+				return s.Storage.DeleteInboxMessages(ctx, time.Now().Add(-inboxRetention), messagesPurgeBatchSize)
+			},
+		}
+
+		for name, purge := range purges {
+			var deleted int64
+			for {
+				count, err := purge()
+				if err != nil {
+					s.logger.Errorf("error during %s purge - %v", name, err)
+					break
+				}
+
+				deleted += count
+				if count < messagesPurgeBatchSize {
+					break
+				}
+			}
+
+			if deleted > 0 {
+				s.logger.Infof("purged %d %s", deleted, name)
+			}
+		}
+
+		time.Sleep(messagesPurgeDelay)
+	}
+}
@@ -0,0 +1,249 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// publishFake fails publishes to exchanges listed in errors, remaining transport methods are not used
+type publishFake struct {
+	AsyncTransportDriver
+	errors    map[string]error
+	published []string
+}
+
+func (p *publishFake) Publish(ctx context.Context, exchange, routingKey string, message any) error {
+	if err, ok := p.errors[exchange]; ok {
+		return err
+	}
+
+	p.published = append(p.published, exchange)
+	return nil
+}
+
+func (s *txStorageFake) ClaimOutboxMessages(ctx context.Context, limit int,
+	lease time.Duration) ([]*OutboxMessage, error) {
+	messages := make([]*OutboxMessage, 0, limit)
+	for _, message := range s.outbox {
+		if len(messages) < limit && message.SentDate == nil && message.DiscardDate == nil {
+			message.NextAttemptDate = time.Now().Add(lease)
+			s.outbox[message.Id] = message
+			messages = append(messages, &message)
+		}
+	}
+
+	return messages, nil
+}
+
+func (s *txStorageFake) GetJobRun(ctx context.Context, id uuid.UUID) (*JobRun, error) {
+	if jobRun, ok := s.jobRuns[id]; ok {
+		return &jobRun, nil
+	}
+
+	return nil, nil
+}
+
+func (t storageTxFake) UpdateOutboxMessage(ctx context.Context, message OutboxMessage) error {
+	t.storage.outbox[message.Id] = message
+	return nil
+}
+
+func newOutboxScheduler(storage StorageDriver, transport AsyncTransportDriver) *Scheduler {
+	Supports = []string{string(Kafka)}
+
+	return &Scheduler{
+		Storage:         storage,
+		AsyncTransports: map[TransportType]AsyncTransportDriver{Kafka: transport},
+		logger:          zap.NewNop().Sugar(),
+	}
+}
+
+func TestNewOutboxMessage(t *testing.T) {
+	jobRunId := uuid.New()
+
+	message, err := NewOutboxMessage(jobRunId, Kafka, "exchange", "routing-key", map[string]string{"key": "value"},
+		getStubDate)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(message.Payload) != `{"key":"value"}` {
+		t.Errorf("expect result %+v, got %+v", `{"key":"value"}`, string(message.Payload))
+	}
+
+	if message.JobRunId != jobRunId || message.Transport != Kafka || message.NextAttemptDate != getStubDate() || message.SentDate != nil {
+		t.Errorf("unexpected message %+v", message)
+	}
+}
+
+func TestOutboxMessageFailed(t *testing.T) {
+	tests := map[string]struct {
+		attempts int
+
+		expected time.Time
+	}{
+		"first_failure": {
+			attempts: 0,
+			expected: getStubDate().Add(time.Second),
+		},
+		"exponential_backoff": {
+			attempts: 3,
+			expected: getStubDate().Add(time.Second * 8),
+		},
+		"capped_backoff": {
+			attempts: 20,
+			expected: getStubDate().Add(time.Minute),
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			message := OutboxMessage{Attempts: test.attempts}
+
+			message.Failed("publish error", getStubDate)
+
+			if message.NextAttemptDate != test.expected {
+				t.Errorf("expect result %+v, got %+v", test.expected, message.NextAttemptDate)
+			}
+
+			if message.Attempts != test.attempts+1 || *message.LastError != "publish error" {
+				t.Errorf("unexpected message %+v", message)
+			}
+		})
+	}
+}
+
+func TestOutboxMessageSent(t *testing.T) {
+	message := OutboxMessage{}
+
+	message.Sent(getStubDate)
+
+	if *message.SentDate != getStubDate() {
+		t.Errorf("expect result %+v, got %+v", getStubDate(), *message.SentDate)
+	}
+}
+
+func TestOutboxMessageDiscard(t *testing.T) {
+	message := OutboxMessage{}
+
+	message.Discard("message unroutable", getStubDate)
+
+	if *message.DiscardDate != getStubDate() || message.SentDate != nil {
+		t.Errorf("expect discarded message, got %+v", message)
+	}
+
+	if message.Attempts != 1 || *message.LastError != "message unroutable" {
+		t.Errorf("unexpected message %+v", message)
+	}
+}
+
+func TestIsDispatchFailure(t *testing.T) {
+	tests := map[string]struct {
+		err error
+
+		expected bool
+	}{
+		"unroutable":      {err: errors.Join(ErrMessageUnroutable, errors.New("NO_ROUTE")), expected: true},
+		"nacked":          {err: ErrPublishNacked, expected: true},
+		"confirm_timeout": {err: errors.Join(ErrPublishConfirmTimeout, context.DeadlineExceeded), expected: false},
+		"connection":      {err: errors.New("channel/connection is not open"), expected: false},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if result := IsDispatchFailure(test.err); result != test.expected {
+				t.Errorf("expect result %+v, got %+v", test.expected, result)
+			}
+		})
+	}
+}
+
+func TestRelayOutboxBatch(t *testing.T) {
+	storage := newTxStorageFake()
+	transport := &publishFake{errors: map[string]error{"broken": errors.New("channel/connection is not open")}}
+	scheduler := newOutboxScheduler(storage, transport)
+
+	sent, err := NewOutboxMessage(uuid.New(), Kafka, "exchange", "key", "payload", getStubDate)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	failed, err := NewOutboxMessage(uuid.New(), Kafka, "broken", "key", "payload", getStubDate)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	storage.outbox[sent.Id], storage.outbox[failed.Id] = sent, failed
+
+	processed, err := scheduler.relayOutboxBatch(context.Background())
+	if err != nil || processed != 2 {
+		t.Fatalf("expect result %+v, got %+v %v", 2, processed, err)
+	}
+
+	if result := storage.outbox[sent.Id]; result.SentDate == nil {
+		t.Errorf("expect sent message, got %+v", result)
+	}
+
+	if result := storage.outbox[failed.Id]; result.SentDate != nil || result.Attempts != 1 || result.LastError == nil {
+		t.Errorf("expect failed message, got %+v", result)
+	}
+
+	if len(transport.published) != 1 || transport.published[0] != "exchange" {
+		t.Errorf("expect result %+v, got %+v", []string{"exchange"}, transport.published)
+	}
+}
+
+func TestRelayOutboxDispatchFailure(t *testing.T) {
+	schedule := NewSchedule("description", "once", getStubDate, WithJob("slug", nil))
+	schedule.Start(time.Now)
+	jobRun := NewJobRun(schedule.Id, schedule.GroupId, schedule.Attempt, getStubDate(), getStubDate)
+
+	tests := map[string]struct {
+		err error
+
+		expectStatus    JobRunStatus
+		expectDiscarded bool
+	}{
+		"unroutable": {
+			err:             errors.Join(ErrMessageUnroutable, errors.New("NO_ROUTE")),
+			expectStatus:    JobFailed,
+			expectDiscarded: true,
+		},
+		"confirm_timeout": {
+			err:          errors.Join(ErrPublishConfirmTimeout, context.DeadlineExceeded),
+			expectStatus: JobWaiting,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			storage := newTxStorageFake()
+			storage.schedules[schedule.Id] = schedule
+			storage.jobRuns[jobRun.Id] = jobRun
+
+			message, err := NewOutboxMessage(jobRun.Id, Kafka, "exchange", "key", "payload", getStubDate)
+			if err != nil {
+				t.Fatal(err)
+			}
+			storage.outbox[message.Id] = message
+
+			scheduler := newOutboxScheduler(storage, &publishFake{errors: map[string]error{"exchange": test.err}})
+			if _, err = scheduler.relayOutboxBatch(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+
+			if status := storage.jobRuns[jobRun.Id].Status; status != test.expectStatus {
+				t.Errorf("expect result %+v, got %+v", test.expectStatus, status)
+			}
+
+			if discarded := storage.outbox[message.Id].DiscardDate != nil; discarded != test.expectDiscarded {
+				t.Errorf("expect result %+v, got %+v", test.expectDiscarded, discarded)
+			}
+		})
+	}
+}
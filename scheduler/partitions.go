@@ -0,0 +1,195 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type PartitionConfig struct {
+	Interval  time.Duration // delay between partitions maintenance
+	Premake   int           // count of future monthly partitions created ahead
+	Retention time.Duration // partitions with all job runs older than retention are dropped, zero keeps them
+}
+
+// retention returns age after which partition can be dropped, job runs kept longer by global retention
+// are not dropped with partition
+func (c PartitionConfig) retention(retention *RetentionConfig) time.Duration {
+	if c.Retention > 0 && retention != nil {
+		return max(c.Retention, retention.JobRuns)
+	}
+
+	return c.Retention
+}
+
+// JobRunPartition is monthly partition of job_runs table, covers job runs started in [From, To)
+type JobRunPartition struct {
+	Name string
+	From time.Time
+	To   time.Time
+}
+
+const (
+	jobRunPartitionPrefix    = "job_runs_"
+	jobRunPartitionLayout    = "2006_01"
+	defaultPartitionInterval = time.Hour
+	defaultPartitionPremake  = 3
+)
+
+func NewJobRunPartition(date time.Time) JobRunPartition {
+	from := time.Date(date.UTC().Year(), date.UTC().Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	return JobRunPartition{
+		Name: jobRunPartitionPrefix + from.Format(jobRunPartitionLayout),
+		From: from,
+		To:   from.AddDate(0, 1, 0),
+	}
+}
+
+// ParseJobRunPartition parses partition from table name, tables not created by scheduler
+// (eg. default partition) are rejected
+func ParseJobRunPartition(name string) (JobRunPartition, error) {
+	suffix, found := strings.CutPrefix(name, jobRunPartitionPrefix)
+	if !found {
+		return JobRunPartition{}, fmt.Errorf("invalid job runs partition %s", name)
+	}
+
+	from, err := time.Parse(jobRunPartitionLayout, suffix)
+	if err != nil {
+		return JobRunPartition{}, fmt.Errorf("invalid job runs partition %s", name)
+	}
+
+	return NewJobRunPartition(from), nil
+}
+
+func (s *Scheduler) maintainPartitions(ctx context.Context, config PartitionConfig) {
+	s.logger.Info("starting job runs partitions maintenance")
+
+	if config.Interval <= 0 {
+		config.Interval = defaultPartitionInterval
+	}
+
+	if config.Premake <= 0 {
+		config.Premake = defaultPartitionPremake
+	}
+
+	for {
+		if err := s.maintainPartitionsOnce(ctx, config, time.Now); err != nil {
+			s.logger.Errorf("error during job runs partitions maintenance - %v", err)
+		}
+
+		time.Sleep(config.Interval)
+	}
+}
+
+func (s *Scheduler) maintainPartitionsOnce(ctx context.Context, config PartitionConfig,
+	now func() time.Time) error {
+	names, err := s.Storage.GetJobRunPartitions(ctx)
+	if err != nil {
+		return err
+	}
+
+	existing := make([]JobRunPartition, 0, len(names))
+	for _, name := range names {
+		partition, err := ParseJobRunPartition(name)
+		if err != nil {
+			continue
+		}
+
+		existing = append(existing, partition)
+	}
+
+	config.Retention = config.retention(s.retention)
+	create, drop := getPartitionsPlan(existing, config, now())
+
+	for _, partition := range create {
+		if err := s.Storage.CreateJobRunPartition(ctx, partition); err != nil {
+			return err
+		}
+
+		s.logger.Infof("created job runs partition %s", partition.Name)
+	}
+
+	if len(drop) == 0 {
+		return nil
+	}
+
+	overrides, err := s.Storage.GetJobRunRetentions(ctx)
+	if err != nil {
+		return err
+	}
+
+	archive := s.retention != nil && s.retention.Archive
+	for _, partition := range drop {
+		// schedules with longer own retention keep partition until their job runs in it expire too
+		if kept := getPartitionKeptSchedules(partition, overrides, now()); len(kept) > 0 {
+			found, err := s.Storage.HasJobRunsOfSchedules(ctx, partition, kept)
+			if err != nil {
+				return err
+			}
+
+			if found {
+				s.logger.Infof("kept expired job runs partition %s, it contains job runs of schedules with "+
+					"longer retention", partition.Name)
+				continue
+			}
+		}
+
+		if err := s.Storage.DropJobRunPartition(ctx, partition, archive); err != nil {
+			return err
+		}
+
+		s.logger.Infof("dropped expired job runs partition %s", partition.Name)
+	}
+
+	return nil
+}
+
+// getPartitionKeptSchedules returns schedules whose own retention keeps some job runs of partition,
+// invalid overrides keep job runs forever same as in job runs purge
+func getPartitionKeptSchedules(partition JobRunPartition, overrides map[uuid.UUID]string,
+	now time.Time) []uuid.UUID {
+	kept := make([]uuid.UUID, 0)
+	for id, override := range overrides {
+		retention, err := time.ParseDuration(override)
+		if err != nil || retention <= 0 || partition.To.After(now.Add(-retention)) {
+			kept = append(kept, id)
+		}
+	}
+
+	return kept
+}
+
+// getPartitionsPlan returns missing partitions from current month up to premake months ahead
+// and partitions which contain only job runs older than retention
+func getPartitionsPlan(existing []JobRunPartition, config PartitionConfig,
+	now time.Time) ([]JobRunPartition, []JobRunPartition) {
+	exists := make(map[string]bool, len(existing))
+	for _, partition := range existing {
+		exists[partition.Name] = true
+	}
+
+	create := make([]JobRunPartition, 0)
+	current := NewJobRunPartition(now)
+	for i := 0; i <= config.Premake; i++ {
+		partition := NewJobRunPartition(current.From.AddDate(0, i, 0))
+		if !exists[partition.Name] {
+			create = append(create, partition)
+		}
+	}
+
+	drop := make([]JobRunPartition, 0)
+	if config.Retention > 0 {
+		cutoff := now.Add(-config.Retention)
+		for _, partition := range existing {
+			if !partition.To.After(cutoff) {
+				drop = append(drop, partition)
+			}
+		}
+	}
+
+	return create, drop
+}
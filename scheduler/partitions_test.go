@@ -0,0 +1,226 @@
+package scheduler
+
+import (
+	"context"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+func TestParseJobRunPartition(t *testing.T) {
+	tests := map[string]struct {
+		name string
+
+		expected  JobRunPartition
+		expectErr bool
+	}{
+		"monthly_partition": {
+			name: "job_runs_2000_12",
+			expected: JobRunPartition{
+				Name: "job_runs_2000_12",
+				From: time.Date(2000, time.December, 1, 0, 0, 0, 0, time.UTC),
+				To:   time.Date(2001, time.January, 1, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		"default_partition": {
+			name:      "job_runs_default",
+			expectErr: true,
+		},
+		"other_table": {
+			name:      "job_run_rollups",
+			expectErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			result, err := ParseJobRunPartition(test.name)
+
+			if test.expectErr != (err != nil) {
+				t.Errorf("expect error %v, got %v", test.expectErr, err)
+			}
+
+			if result != test.expected {
+				t.Errorf("expect result %+v, got %+v", test.expected, result)
+			}
+		})
+	}
+}
+
+func TestGetPartitionsPlan(t *testing.T) {
+	now := time.Date(2000, time.March, 15, 12, 0, 0, 0, time.UTC)
+
+	tests := map[string]struct {
+		existing []string
+		config   PartitionConfig
+
+		expectedCreate []string
+		expectedDrop   []string
+	}{
+		"creates_current_and_future_partitions": {
+			existing:       []string{},
+			config:         PartitionConfig{Premake: 2},
+			expectedCreate: []string{"job_runs_2000_03", "job_runs_2000_04", "job_runs_2000_05"},
+			expectedDrop:   []string{},
+		},
+		"creates_only_missing_partitions": {
+			existing:       []string{"job_runs_2000_03", "job_runs_2000_04"},
+			config:         PartitionConfig{Premake: 2},
+			expectedCreate: []string{"job_runs_2000_05"},
+			expectedDrop:   []string{},
+		},
+		"drops_partitions_older_than_retention": {
+			existing:       []string{"job_runs_1999_12", "job_runs_2000_01", "job_runs_2000_02", "job_runs_2000_03"},
+			config:         PartitionConfig{Retention: time.Hour * 24 * 40},
+			expectedCreate: []string{},
+			expectedDrop:   []string{"job_runs_1999_12", "job_runs_2000_01"},
+		},
+		"keeps_partitions_without_retention": {
+			existing:       []string{"job_runs_1999_12", "job_runs_2000_03"},
+			config:         PartitionConfig{},
+			expectedCreate: []string{},
+			expectedDrop:   []string{},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			existing := make([]JobRunPartition, 0, len(test.existing))
+			for _, name := range test.existing {
+				partition, _ := ParseJobRunPartition(name)
+				existing = append(existing, partition)
+			}
+
+			create, drop := getPartitionsPlan(existing, test.config, now)
+
+			if names := partitionNames(create); !reflect.DeepEqual(names, test.expectedCreate) {
+				t.Errorf("expect create %+v, got %+v", test.expectedCreate, names)
+			}
+
+			if names := partitionNames(drop); !reflect.DeepEqual(names, test.expectedDrop) {
+				t.Errorf("expect drop %+v, got %+v", test.expectedDrop, names)
+			}
+		})
+	}
+}
+
+func partitionNames(partitions []JobRunPartition) []string {
+	names := make([]string, 0, len(partitions))
+	for _, partition := range partitions {
+		names = append(names, partition.Name)
+	}
+
+	return names
+}
+
+// partitionStorageFake keeps partitions with schedules of their job runs, remaining storage methods are not used
+type partitionStorageFake struct {
+	StorageDriver
+	partitions map[string][]uuid.UUID
+	overrides  map[uuid.UUID]string
+	archived   []string
+}
+
+func (s *partitionStorageFake) GetJobRunPartitions(ctx context.Context) ([]string, error) {
+	names := make([]string, 0, len(s.partitions))
+	for name := range s.partitions {
+		names = append(names, name)
+	}
+
+	return names, nil
+}
+
+func (s *partitionStorageFake) CreateJobRunPartition(ctx context.Context, partition JobRunPartition) error {
+	s.partitions[partition.Name] = nil
+	return nil
+}
+
+func (s *partitionStorageFake) GetJobRunRetentions(ctx context.Context) (map[uuid.UUID]string, error) {
+	return s.overrides, nil
+}
+
+func (s *partitionStorageFake) HasJobRunsOfSchedules(ctx context.Context, partition JobRunPartition,
+	scheduleIds []uuid.UUID) (bool, error) {
+	for _, id := range s.partitions[partition.Name] {
+		if slices.Contains(scheduleIds, id) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (s *partitionStorageFake) DropJobRunPartition(ctx context.Context, partition JobRunPartition,
+	archive bool) error {
+	if archive {
+		s.archived = append(s.archived, partition.Name)
+	}
+
+	delete(s.partitions, partition.Name)
+	return nil
+}
+
+func TestMaintainPartitionsRetention(t *testing.T) {
+	now := func() time.Time { return time.Date(2000, time.March, 15, 12, 0, 0, 0, time.UTC) }
+	config := PartitionConfig{Premake: 1, Retention: time.Hour * 24 * 40}
+	kept, forever, expired := uuid.New(), uuid.New(), uuid.New()
+
+	tests := map[string]struct {
+		jobRuns   []uuid.UUID
+		retention *RetentionConfig
+
+		expectDropped  bool
+		expectArchived []string
+	}{
+		"drops_partition_without_overrides": {
+			jobRuns:       []uuid.UUID{uuid.New()},
+			expectDropped: true,
+		},
+		"drops_partition_with_expired_override": {
+			jobRuns:       []uuid.UUID{expired},
+			expectDropped: true,
+		},
+		"keeps_partition_with_longer_override": {
+			jobRuns: []uuid.UUID{kept},
+		},
+		"keeps_partition_with_invalid_override": {
+			jobRuns: []uuid.UUID{forever},
+		},
+		"keeps_partition_with_longer_global_retention": {
+			jobRuns:   []uuid.UUID{uuid.New()},
+			retention: &RetentionConfig{JobRuns: time.Hour * 24 * 90},
+		},
+		"archives_dropped_partition": {
+			jobRuns:        []uuid.UUID{uuid.New()},
+			retention:      &RetentionConfig{Archive: true},
+			expectDropped:  true,
+			expectArchived: []string{"job_runs_2000_01"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			storage := &partitionStorageFake{
+				partitions: map[string][]uuid.UUID{"job_runs_2000_01": test.jobRuns},
+				overrides:  map[uuid.UUID]string{kept: "2160h", forever: "forever", expired: "24h"},
+			}
+			s := &Scheduler{Storage: storage, retention: test.retention, logger: zap.NewNop().Sugar()}
+
+			if err := s.maintainPartitionsOnce(context.Background(), config, now); err != nil {
+				t.Fatal(err)
+			}
+
+			if _, exists := storage.partitions["job_runs_2000_01"]; exists == test.expectDropped {
+				t.Errorf("expect result %+v, got %+v", test.expectDropped, !exists)
+			}
+
+			if !slices.Equal(storage.archived, test.expectArchived) {
+				t.Errorf("expect result %+v, got %+v", test.expectArchived, storage.archived)
+			}
+		})
+	}
+}
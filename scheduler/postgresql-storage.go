@@ -4,6 +4,9 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -13,18 +16,38 @@ import (
 
 type StorageDriver interface {
 	GetScheduleById(ctx context.Context, id uuid.UUID) (*Schedule, error)
-	GetAwaitingSchedules(ctx context.Context) ([]*Schedule, error)
-	GetSchedulesPaged(ctx context.Context, page int, pageSize int) ([]*Schedule, error)
+	GetAwaitingSchedules(ctx context.Context, transports []TransportType) ([]*Schedule, error)
+	GetSchedulesPaged(ctx context.Context, filter ScheduleFilter, page int, pageSize int) ([]*Schedule, int, error)
+	GetSchedulesAfter(ctx context.Context, filter ScheduleFilter, cursor *Cursor, limit int) ([]*Schedule, error)
 	Add(ctx context.Context, schedule Schedule) error
 	DeleteScheduleById(ctx context.Context, id uuid.UUID) error
-	UpdateSchedule(ctx context.Context, schedule Schedule) error
-	AddJobRun(ctx context.Context, jobRun JobRun) error
+	WithTx(ctx context.Context, fn func(tx StorageTx) error) error
+	ClaimOutboxMessages(ctx context.Context, limit int, lease time.Duration) ([]*OutboxMessage, error)
+	DeleteSentOutboxMessages(ctx context.Context, before time.Time, limit int) (int64, error)
+	DeleteInboxMessages(ctx context.Context, before time.Time, limit int) (int64, error)
 	GetJobRun(ctx context.Context, id uuid.UUID) (*JobRun, error)
 	GetJobRunGroup(ctx context.Context, scheduleId uuid.UUID, groupId uuid.UUID) ([]*JobRun, error)
-	GetJobRuns(ctx context.Context, scheduleId uuid.UUID) ([]*JobRun, error)
+	GetJobRunsPaged(ctx context.Context, filter JobRunFilter, page int, pageSize int) ([]*JobRun, int, error)
+	GetJobRunsAfter(ctx context.Context, filter JobRunFilter, cursor *Cursor, limit int) ([]*JobRun, error)
 	GetRecentJobRuns(ctx context.Context, scheduleId uuid.UUID) ([]*JobRun, error)
 	GetStaleJobs(ctx context.Context) ([]StaleJobRun, error)
-	UpdateJobRun(ctx context.Context, jobRun JobRun) error
+	GetJobRunStats(ctx context.Context, filter JobRunStatsFilter) (JobRunStats, error)
+	GetJobRunRollupStats(ctx context.Context, granularity RollupGranularity,
+		filter JobRunStatsFilter) (JobRunStats, error)
+	GetRollupWatermark(ctx context.Context) (*time.Time, error)
+	GetOldestJobRunDate(ctx context.Context) (*time.Time, error)
+	RollupJobRuns(ctx context.Context, from, to, watermark time.Time) (bool, error)
+	DeleteRollups(ctx context.Context, granularity RollupGranularity, before time.Time) (int64, error)
+	GetJobRunRetentions(ctx context.Context) (map[uuid.UUID]string, error)
+	DeleteJobRuns(ctx context.Context, scheduleId uuid.UUID, before time.Time, limit int, archive bool) (int64, error)
+	GetFinishedSchedules(ctx context.Context, before time.Time, limit int) ([]*Schedule, error)
+	ArchiveScheduleById(ctx context.Context, id uuid.UUID) error
+	GetJobRunPartitions(ctx context.Context) ([]string, error)
+	CreateJobRunPartition(ctx context.Context, partition JobRunPartition) error
+	HasJobRunsOfSchedules(ctx context.Context, partition JobRunPartition, scheduleIds []uuid.UUID) (bool, error)
+	DropJobRunPartition(ctx context.Context, partition JobRunPartition, archive bool) error
+	GetJobQueues(ctx context.Context) ([]JobQueue, error)
+	ClaimPullJob(ctx context.Context, slugs []string, lease time.Duration, now time.Time) (*PullJob, error)
 }
 
 type Pgsql struct {
@@ -40,33 +63,32 @@ func NewPgsqlConnection(ctx context.Context, connectionString string) (*Pgsql, e
 	return &Pgsql{pool: dbPool}, nil
 }
 
-func (pg Pgsql) GetScheduleById(ctx context.Context, id uuid.UUID) (*Schedule, error) {
+// Health checks that database is reachable
+func (pg Pgsql) Health(ctx context.Context) error {
+	return pg.pool.Ping(ctx)
+}
+
+const scheduleColumns = `s.id, s.group_id, s.attempt, s.namespace, s.description, s.labels, s.status, s.frequency,
+	s.schedule_start, s.retry_policy_strategy, s.retry_policy_count, s.retry_policy_interval, s.jitter,
+	s.job_run_retention, s.transport_type, s.url, s.exchange, s.routing_key, s.last_execution_date,
+	s.next_execution_date, s.creation_date, j.id, j.slug, j.data`
+
+func scanSchedule(row pgx.Row) (*Schedule, error) {
 	var schedule = Schedule{
 		RetryPolicy: RetryPolicy{},
 		Job:         &Job{},
 	}
 
-	sql := `SELECT s.id, s.group_id, s.description, s.status, s.frequency, s.schedule_start, 
-				s.retry_policy_strategy, s.retry_policy_count, s.retry_policy_interval, 
-				s.transport_type, s.url, s.last_execution_date, s.next_execution_date, j.id, 
-				j.slug, j.data
-			FROM jobs AS j 
-			JOIN schedules AS s ON s.id = j.schedule_id
-			WHERE s.id = $1`
-
 	var jobData string
 
-	err := pg.pool.QueryRow(ctx, sql, id).
-		Scan(&schedule.Id, &schedule.GroupId, &schedule.Description, &schedule.Status, &schedule.Frequency,
-			&schedule.ScheduleStart, &schedule.RetryPolicy.Strategy, &schedule.RetryPolicy.Count,
-			&schedule.RetryPolicy.Interval, &schedule.Configuration.TransportType, &schedule.Configuration.Url,
-			&schedule.LastExecutionDate, &schedule.NextExecutionDate, &schedule.Job.Id, &schedule.Job.Slug, &jobData)
+	err := row.Scan(&schedule.Id, &schedule.GroupId, &schedule.Attempt, &schedule.Namespace, &schedule.Description,
+		&schedule.Labels, &schedule.Status, &schedule.Frequency, &schedule.ScheduleStart,
+		&schedule.RetryPolicy.Strategy, &schedule.RetryPolicy.Count, &schedule.RetryPolicy.Interval,
+		&schedule.Jitter, &schedule.JobRunRetention, &schedule.Configuration.TransportType, &schedule.Configuration.Url,
+		&schedule.Configuration.Exchange, &schedule.Configuration.RoutingKey, &schedule.LastExecutionDate,
+		&schedule.NextExecutionDate, &schedule.CreationDate, &schedule.Job.Id, &schedule.Job.Slug, &jobData)
 
 	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, nil
-		}
-
 		return nil, err
 	}
 
@@ -78,107 +100,225 @@ func (pg Pgsql) GetScheduleById(ctx context.Context, id uuid.UUID) (*Schedule, e
 	return &schedule, nil
 }
 
-func (pg Pgsql) GetAwaitingSchedules(ctx context.Context) ([]*Schedule, error) {
-	sql := `SELECT s.id, s.group_id, s.description, s.status, s.frequency, s.schedule_start,
-				s.retry_policy_strategy, s.retry_policy_count, s.retry_policy_interval, s.transport_type, 
-				s.url, s.last_execution_date, s.next_execution_date, j.id, j.slug, j.data
+func (pg Pgsql) GetScheduleById(ctx context.Context, id uuid.UUID) (*Schedule, error) {
+	sql := `SELECT ` + scheduleColumns + `
+			FROM jobs AS j 
+			JOIN schedules AS s ON s.id = j.schedule_id
+			WHERE s.id = $1`
+
+	schedule, err := scanSchedule(pg.pool.QueryRow(ctx, sql, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return schedule, nil
+}
+
+// GetAwaitingSchedules returns due schedules of given transports, schedules of other transports are left
+// to instances which support them
+func (pg Pgsql) GetAwaitingSchedules(ctx context.Context, transports []TransportType) ([]*Schedule, error) {
+	sql := `SELECT ` + scheduleColumns + `
 			FROM jobs AS j 
 			JOIN schedules AS s ON s.id = j.schedule_id
-			WHERE status IN ($1) AND next_execution_date <= $2
+			WHERE status IN ($1) AND next_execution_date <= $2 AND s.transport_type = ANY($3)
 			ORDER BY next_execution_date ASC`
 
-	rows, err := pg.pool.Query(ctx, sql, Waiting, time.Now())
+	types := make([]string, 0, len(transports))
+	for _, transport := range transports {
+		types = append(types, string(transport))
+	}
 
+	rows, err := pg.pool.Query(ctx, sql, Waiting, time.Now(), types)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
-	var jobData string
 	schedules := make([]*Schedule, 0)
 	for rows.Next() {
-		var schedule = Schedule{
-			RetryPolicy: RetryPolicy{},
-			Job:         &Job{},
-		}
-		err = rows.Scan(&schedule.Id, &schedule.GroupId, &schedule.Description, &schedule.Status,
-			&schedule.Frequency, &schedule.ScheduleStart, &schedule.RetryPolicy.Strategy,
-			&schedule.RetryPolicy.Count, &schedule.RetryPolicy.Interval, &schedule.Configuration.TransportType,
-			&schedule.Configuration.Url, &schedule.LastExecutionDate, &schedule.NextExecutionDate,
-			&schedule.Job.Id, &schedule.Job.Slug, &jobData)
-
+		schedule, err := scanSchedule(rows)
 		if err != nil {
 			return nil, err
 		}
 
-		err = json.Unmarshal([]byte(jobData), &schedule.Job.Data)
-		if err != nil {
-			return nil, err
-		}
-
-		schedules = append(schedules, &schedule)
+		schedules = append(schedules, schedule)
 	}
 
-	return schedules, nil
+	return schedules, rows.Err()
 }
 
-func (pg Pgsql) GetSchedulesPaged(ctx context.Context, page int, pageSize int) ([]*Schedule, error) {
-	sql := `SELECT s.id, s.group_id, s.description, s.status, s.frequency, s.schedule_start, 
-				s.retry_policy_strategy, s.retry_policy_count, s.retry_policy_interval, s.transport_type, 
-				s.url, s.last_execution_date, s.next_execution_date, j.id, j.slug, j.data
+func (pg Pgsql) GetSchedulesPaged(ctx context.Context, filter ScheduleFilter, page int,
+	pageSize int) ([]*Schedule, int, error) {
+	where, args := buildScheduleFilter(filter)
+
+	var total int
+	err := pg.pool.QueryRow(ctx, `SELECT COUNT(*) 
 			FROM jobs AS j 
-			JOIN schedules AS s ON s.id = j.schedule_id
-			ORDER BY last_execution_date DESC
-			OFFSET $1
-			LIMIT $2`
+			JOIN schedules AS s ON s.id = j.schedule_id `+where, args...).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
 
-	rows, err := pg.pool.Query(ctx, sql, (page-1)*pageSize, pageSize)
+	sql := fmt.Sprintf(`SELECT `+scheduleColumns+`
+			FROM jobs AS j 
+			JOIN schedules AS s ON s.id = j.schedule_id
+			%s
+			ORDER BY %s
+			OFFSET $%d
+			LIMIT $%d`, where, buildScheduleOrder(filter), len(args)+1, len(args)+2)
 
+	rows, err := pg.pool.Query(ctx, sql, append(args, (page-1)*pageSize, pageSize)...)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
+	defer rows.Close()
 
 	schedules := make([]*Schedule, 0, pageSize)
 	for rows.Next() {
-		var schedule = Schedule{
-			RetryPolicy: RetryPolicy{},
-			Job:         &Job{},
+		schedule, err := scanSchedule(rows)
+		if err != nil {
+			return nil, 0, err
 		}
 
-		var jobData string
+		schedules = append(schedules, schedule)
+	}
+
+	return schedules, total, rows.Err()
+}
 
-		err = rows.Scan(&schedule.Id, &schedule.GroupId, &schedule.Description, &schedule.Status,
-			&schedule.Frequency, &schedule.ScheduleStart, &schedule.RetryPolicy.Strategy,
-			&schedule.RetryPolicy.Count, &schedule.RetryPolicy.Interval, &schedule.Configuration.TransportType,
-			&schedule.Configuration.Url, &schedule.LastExecutionDate, &schedule.NextExecutionDate,
-			&schedule.Job.Id, &schedule.Job.Slug, &jobData)
+// GetSchedulesAfter returns schedules created before cursor, newest first
+func (pg Pgsql) GetSchedulesAfter(ctx context.Context, filter ScheduleFilter, cursor *Cursor,
+	limit int) ([]*Schedule, error) {
+	where, args := buildScheduleFilter(filter)
 
-		if err != nil {
-			return nil, err
+	if cursor != nil {
+		args = append(args, cursor.Date, cursor.Id)
+		condition := fmt.Sprintf("(s.creation_date, s.id) < ($%d, $%d)", len(args)-1, len(args))
+
+		if where == "" {
+			where = "WHERE " + condition
+		} else {
+			where += " AND " + condition
 		}
+	}
 
-		err = json.Unmarshal([]byte(jobData), &schedule.Job.Data)
+	sql := fmt.Sprintf(`SELECT `+scheduleColumns+`
+			FROM jobs AS j 
+			JOIN schedules AS s ON s.id = j.schedule_id
+			%s
+			ORDER BY s.creation_date DESC, s.id DESC
+			LIMIT $%d`, where, len(args)+1)
+
+	rows, err := pg.pool.Query(ctx, sql, append(args, limit)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	schedules := make([]*Schedule, 0, limit)
+	for rows.Next() {
+		schedule, err := scanSchedule(rows)
 		if err != nil {
 			return nil, err
 		}
 
-		schedules = append(schedules, &schedule)
+		schedules = append(schedules, schedule)
+	}
+
+	return schedules, rows.Err()
+}
+
+func buildScheduleFilter(filter ScheduleFilter) (string, []any) {
+	conditions := make([]string, 0)
+	args := make([]any, 0)
+
+	add := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.Namespace != "" {
+		add("s.namespace = $%d", filter.Namespace)
+	}
+
+	if filter.Status != "" {
+		add("s.status = $%d", filter.Status)
+	}
+
+	if filter.TransportType != "" {
+		add("s.transport_type = $%d", filter.TransportType)
+	}
+
+	if filter.JobSlug != "" {
+		add("j.slug = $%d", filter.JobSlug)
+	}
+
+	if len(filter.Labels) > 0 {
+		add("s.labels @> $%d", filter.Labels)
+	}
+
+	if filter.Search != "" {
+		add(`s.description ILIKE '%%' || $%d || '%%'`, escapeLike(filter.Search))
 	}
 
-	return schedules, nil
+	if filter.LastJobRunStatus != "" {
+		add(`(SELECT jr.status FROM job_runs AS jr WHERE jr.schedule_id = s.id 
+			ORDER BY jr.start_date DESC LIMIT 1) = $%d`, filter.LastJobRunStatus)
+	}
+
+	if len(conditions) == 0 {
+		return "", args
+	}
+
+	return "WHERE " + strings.Join(conditions, " AND "), args
+}
+
+func buildScheduleOrder(filter ScheduleFilter) string {
+	column := "s.last_execution_date"
+	switch filter.SortBy {
+	case SortByNextExecutionDate:
+		column = "s.next_execution_date"
+	case SortByDescription:
+		column = "s.description"
+	}
+
+	order := "DESC"
+	if filter.SortOrder == Ascending {
+		order = "ASC"
+	}
+
+	return fmt.Sprintf("%s %s NULLS LAST, s.id ASC", column, order)
+}
+
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
 }
 
 func (pg Pgsql) Add(ctx context.Context, schedule Schedule) error {
+	labels := schedule.Labels
+	if labels == nil {
+		labels = map[string]string{}
+	}
+
 	tx, err := pg.pool.Begin(ctx)
 	if err != nil {
 		return err
 	}
 
 	_, err = tx.Exec(ctx,
-		"INSERT INTO schedules VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)",
-		schedule.Id, schedule.GroupId, schedule.Description, schedule.Status, schedule.Frequency,
-		schedule.ScheduleStart, schedule.RetryPolicy.Strategy, schedule.RetryPolicy.Count,
-		schedule.RetryPolicy.Interval, schedule.Configuration.TransportType, schedule.Configuration.Url,
-		schedule.LastExecutionDate, schedule.NextExecutionDate)
+		`INSERT INTO schedules (id, group_id, attempt, namespace, description, labels, status, frequency,
+			schedule_start, retry_policy_strategy, retry_policy_count, retry_policy_interval, jitter, job_run_retention,
+			transport_type, url, exchange, routing_key, last_execution_date, next_execution_date, creation_date)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21)`,
+		schedule.Id, schedule.GroupId, schedule.Attempt, schedule.Namespace, schedule.Description, labels, schedule.Status,
+		schedule.Frequency, schedule.ScheduleStart, schedule.RetryPolicy.Strategy, schedule.RetryPolicy.Count,
+		schedule.RetryPolicy.Interval, schedule.Jitter, schedule.JobRunRetention, schedule.Configuration.TransportType,
+		schedule.Configuration.Url, schedule.Configuration.Exchange, schedule.Configuration.RoutingKey,
+		schedule.LastExecutionDate, schedule.NextExecutionDate, schedule.CreationDate)
 
 	if err != nil {
 		if txErr := tx.Rollback(ctx); txErr != nil {
@@ -204,6 +344,23 @@ func (pg Pgsql) Add(ctx context.Context, schedule Schedule) error {
 		return err
 	}
 
+	// queue is registered with schedule, once marked as externally managed it stays so
+	if schedule.UsesJobQueue() {
+		_, err = tx.Exec(ctx, `INSERT INTO job_queues (name, externally_managed, creation_date)
+				VALUES ($1, $2, $3)
+				ON CONFLICT (name) DO UPDATE
+					SET externally_managed = job_queues.externally_managed OR EXCLUDED.externally_managed`,
+			schedule.Job.Slug, schedule.Configuration.ExternalQueue, schedule.CreationDate)
+
+		if err != nil {
+			if txErr := tx.Rollback(ctx); txErr != nil {
+				return txErr
+			}
+
+			return err
+		}
+	}
+
 	if err = tx.Commit(ctx); err != nil {
 		return err
 	}
@@ -226,6 +383,15 @@ func (pg Pgsql) DeleteScheduleById(ctx context.Context, id uuid.UUID) error {
 		return err
 	}
 
+	_, err = tx.Exec(ctx, `DELETE FROM pull_jobs WHERE schedule_id = $1`, id)
+	if err != nil {
+		if txErr := tx.Rollback(ctx); txErr != nil {
+			return txErr
+		}
+
+		return err
+	}
+
 	_, err = tx.Exec(ctx, `DELETE FROM schedules WHERE id = $1`, id)
 	if err != nil {
 		if txErr := tx.Rollback(ctx); txErr != nil {
@@ -242,40 +408,107 @@ func (pg Pgsql) DeleteScheduleById(ctx context.Context, id uuid.UUID) error {
 	return nil
 }
 
-func (pg Pgsql) UpdateSchedule(ctx context.Context, schedule Schedule) error {
-	sql := `UPDATE schedules SET last_execution_date = $1, next_execution_date = $2, status = $3, group_id = $4 WHERE id = $5`
+// ClaimOutboxMessages leases batch of pending outbox messages in creation order, lease postpones their next
+// attempt, so concurrent relays skip them while they are published outside of transaction
+func (pg Pgsql) ClaimOutboxMessages(ctx context.Context, limit int, lease time.Duration) ([]*OutboxMessage, error) {
+	now := time.Now()
+	rows, err := pg.pool.Query(ctx, `UPDATE outbox SET next_attempt_date = $3
+			WHERE id IN (
+				SELECT id FROM outbox
+				WHERE sent_date IS NULL AND discard_date IS NULL AND next_attempt_date <= $1
+				ORDER BY creation_date ASC
+				LIMIT $2
+				FOR UPDATE SKIP LOCKED)
+			RETURNING id, job_run_id, transport_type, exchange, routing_key, payload, attempts, last_error, 
+				next_attempt_date, creation_date, sent_date, discard_date`, now, limit, now.Add(lease))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
 
-	_, err := pg.pool.Exec(ctx, sql,
-		schedule.LastExecutionDate, schedule.NextExecutionDate, schedule.Status, schedule.GroupId, schedule.Id)
+	messages := make([]*OutboxMessage, 0, limit)
+	for rows.Next() {
+		var message OutboxMessage
+		var payload string
 
-	if err != nil {
-		return err
+		err = rows.Scan(&message.Id, &message.JobRunId, &message.Transport, &message.Exchange, &message.RoutingKey,
+			&payload, &message.Attempts, &message.LastError, &message.NextAttemptDate, &message.CreationDate,
+			&message.SentDate, &message.DiscardDate)
+		if err != nil {
+			return nil, err
+		}
+
+		message.Payload = []byte(payload)
+		messages = append(messages, &message)
 	}
 
-	return nil
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// UPDATE ... RETURNING does not keep order of subquery
+	slices.SortFunc(messages, func(a, b *OutboxMessage) int {
+		return a.CreationDate.Compare(b.CreationDate)
+	})
+
+	return messages, nil
 }
 
-func (pg Pgsql) AddJobRun(ctx context.Context, jobRun JobRun) error {
-	sql := `INSERT INTO job_runs VALUES ($1, $2, $3, $4, $5, $6, $7)`
+func (pg Pgsql) DeleteSentOutboxMessages(ctx context.Context, before time.Time, limit int) (int64, error) {
+	tag, err := pg.pool.Exec(ctx, `DELETE FROM outbox WHERE id IN (
+			SELECT id FROM outbox WHERE sent_date < $1 OR discard_date < $1 LIMIT $2)`, before, limit)
+	if err != nil {
+		return 0, err
+	}
+
+	return tag.RowsAffected(), nil
+}
 
-	_, err := pg.pool.Exec(ctx, sql, jobRun.Id, jobRun.GroupId, jobRun.ScheduleId, jobRun.Status, jobRun.Reason,
-		jobRun.StartDate, jobRun.EndDate)
+func (pg Pgsql) DeleteInboxMessages(ctx context.Context, before time.Time, limit int) (int64, error) {
+	tag, err := pg.pool.Exec(ctx, `DELETE FROM inbox WHERE event_id IN (
+			SELECT event_id FROM inbox WHERE processed_date < $1 LIMIT $2)`, before, limit)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
-	return nil
+	return tag.RowsAffected(), nil
 }
 
-func (pg Pgsql) GetJobRun(ctx context.Context, id uuid.UUID) (*JobRun, error) {
+const jobRunColumns = `jr.id, jr.group_id, jr.schedule_id, jr.status, jr.attempt, jr.scheduled_date, jr.reason,
+	jr.start_date, jr.end_date`
+
+func scanJobRun(row pgx.Row) (*JobRun, error) {
 	var jobRun = JobRun{}
 
-	sql := `SELECT id, group_id, schedule_id, status, attempt, reason, start_date, end_date FROM job_runs WHERE id = $1`
+	err := row.Scan(&jobRun.Id, &jobRun.GroupId, &jobRun.ScheduleId, &jobRun.Status, &jobRun.Attempt,
+		&jobRun.ScheduledDate, &jobRun.Reason, &jobRun.StartDate, &jobRun.EndDate)
+	if err != nil {
+		return nil, err
+	}
+
+	return &jobRun, nil
+}
 
-	err := pg.pool.QueryRow(ctx, sql, id).
-		Scan(&jobRun.Id, &jobRun.GroupId, &jobRun.ScheduleId, &jobRun.Status, &jobRun.Reason,
-			&jobRun.StartDate, &jobRun.EndDate)
+func scanJobRuns(rows pgx.Rows) ([]*JobRun, error) {
+	defer rows.Close()
+
+	jobRuns := make([]*JobRun, 0)
+	for rows.Next() {
+		jobRun, err := scanJobRun(rows)
+		if err != nil {
+			return nil, err
+		}
 
+		jobRuns = append(jobRuns, jobRun)
+	}
+
+	return jobRuns, rows.Err()
+}
+
+func (pg Pgsql) GetJobRun(ctx context.Context, id uuid.UUID) (*JobRun, error) {
+	sql := `SELECT ` + jobRunColumns + ` FROM job_runs AS jr WHERE jr.id = $1`
+
+	jobRun, err := scanJobRun(pg.pool.QueryRow(ctx, sql, id))
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, nil
@@ -284,63 +517,123 @@ func (pg Pgsql) GetJobRun(ctx context.Context, id uuid.UUID) (*JobRun, error) {
 		return nil, err
 	}
 
-	return &jobRun, nil
+	return jobRun, nil
 }
 
 func (pg Pgsql) GetJobRunGroup(ctx context.Context, scheduleId uuid.UUID, groupId uuid.UUID) ([]*JobRun, error) {
-	sql := `SELECT id, group_id, schedule_id, status, reason, start_date, end_date FROM job_runs 
-			WHERE schedule_id = $1 AND group_id = $2`
+	sql := `SELECT ` + jobRunColumns + ` FROM job_runs AS jr 
+			WHERE jr.schedule_id = $1 AND jr.group_id = $2`
 
 	rows, err := pg.pool.Query(ctx, sql, scheduleId, groupId)
 	if err != nil {
 		return nil, err
 	}
 
-	jobRuns := make([]*JobRun, 0)
-	for rows.Next() {
-		var jobRun = JobRun{}
+	return scanJobRuns(rows)
+}
 
-		err = rows.Scan(&jobRun.Id, &jobRun.GroupId, &jobRun.ScheduleId, &jobRun.Status,
-			&jobRun.Reason, &jobRun.StartDate, &jobRun.EndDate)
-		if err != nil {
-			return nil, err
-		}
+func (pg Pgsql) GetJobRunsPaged(ctx context.Context, filter JobRunFilter, page int,
+	pageSize int) ([]*JobRun, int, error) {
+	where, args := buildJobRunFilter(filter)
 
-		jobRuns = append(jobRuns, &jobRun)
+	var total int
+	err := pg.pool.QueryRow(ctx, `SELECT COUNT(*) FROM job_runs AS jr `+where, args...).Scan(&total)
+	if err != nil {
+		return nil, 0, err
 	}
 
-	return jobRuns, nil
+	sql := fmt.Sprintf(`SELECT `+jobRunColumns+` FROM job_runs AS jr
+			%s
+			ORDER BY jr.start_date DESC, jr.id DESC
+			OFFSET $%d
+			LIMIT $%d`, where, len(args)+1, len(args)+2)
+
+	rows, err := pg.pool.Query(ctx, sql, append(args, (page-1)*pageSize, pageSize)...)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	jobRuns, err := scanJobRuns(rows)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return jobRuns, total, nil
 }
 
-func (pg Pgsql) GetJobRuns(ctx context.Context, scheduleId uuid.UUID) ([]*JobRun, error) {
-	sql := `SELECT id, group_id, schedule_id, status, reason, start_date, end_date FROM job_runs 
-			WHERE schedule_id = $1`
+// GetJobRunsAfter returns job runs started before cursor, newest first
+func (pg Pgsql) GetJobRunsAfter(ctx context.Context, filter JobRunFilter, cursor *Cursor,
+	limit int) ([]*JobRun, error) {
+	where, args := buildJobRunFilter(filter)
 
-	rows, err := pg.pool.Query(ctx, sql, scheduleId)
+	if cursor != nil {
+		args = append(args, cursor.Date, cursor.Id)
+		condition := fmt.Sprintf("(jr.start_date, jr.id) < ($%d, $%d)", len(args)-1, len(args))
+
+		if where == "" {
+			where = "WHERE " + condition
+		} else {
+			where += " AND " + condition
+		}
+	}
+
+	sql := fmt.Sprintf(`SELECT `+jobRunColumns+` FROM job_runs AS jr
+			%s
+			ORDER BY jr.start_date DESC, jr.id DESC
+			LIMIT $%d`, where, len(args)+1)
+
+	rows, err := pg.pool.Query(ctx, sql, append(args, limit)...)
 	if err != nil {
 		return nil, err
 	}
 
-	jobRuns := make([]*JobRun, 0)
-	for rows.Next() {
-		var jobRun = JobRun{}
+	return scanJobRuns(rows)
+}
 
-		err = rows.Scan(&jobRun.Id, &jobRun.GroupId, &jobRun.ScheduleId, &jobRun.Status,
-			&jobRun.Reason, &jobRun.StartDate, &jobRun.EndDate)
-		if err != nil {
-			return nil, err
-		}
+func buildJobRunFilter(filter JobRunFilter) (string, []any) {
+	conditions := make([]string, 0)
+	args := make([]any, 0)
+
+	add := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.ScheduleId != uuid.Nil {
+		add("jr.schedule_id = $%d", filter.ScheduleId)
+	}
 
-		jobRuns = append(jobRuns, &jobRun)
+	if filter.GroupId != uuid.Nil {
+		add("jr.group_id = $%d", filter.GroupId)
 	}
 
-	return jobRuns, nil
+	if filter.Status != "" {
+		add("jr.status = $%d", filter.Status)
+	}
+
+	if filter.From != nil {
+		add("jr.start_date >= $%d", *filter.From)
+	}
+
+	if filter.To != nil {
+		add("jr.start_date < $%d", *filter.To)
+	}
+
+	if filter.Reason != "" {
+		add(`jr.reason ILIKE '%%' || $%d || '%%'`, escapeLike(filter.Reason))
+	}
+
+	if len(conditions) == 0 {
+		return "", args
+	}
+
+	return "WHERE " + strings.Join(conditions, " AND "), args
 }
 
 func (pg Pgsql) GetRecentJobRuns(ctx context.Context, scheduleId uuid.UUID) ([]*JobRun, error) {
 	sql := `SELECT * FROM (
-				SELECT id, group_id, schedule_id, status, reason, start_date, end_date FROM job_runs 
-				WHERE schedule_id = $1 ORDER BY end_date DESC LIMIT 5
+				SELECT ` + jobRunColumns + ` FROM job_runs AS jr 
+				WHERE jr.schedule_id = $1 ORDER BY jr.end_date DESC LIMIT 5
 			) ORDER BY end_date ASC`
 
 	rows, err := pg.pool.Query(ctx, sql, scheduleId)
@@ -348,19 +641,7 @@ func (pg Pgsql) GetRecentJobRuns(ctx context.Context, scheduleId uuid.UUID) ([]*
 		return nil, err
 	}
 
-	jobRuns := make([]*JobRun, 0)
-	for rows.Next() {
-		var jobRun = JobRun{}
-
-		err = rows.Scan(&jobRun.Id, &jobRun.GroupId, &jobRun.ScheduleId, &jobRun.Status,
-			&jobRun.Reason, &jobRun.StartDate, &jobRun.EndDate)
-		if err != nil {
-			return nil, err
-		}
-
-		jobRuns = append(jobRuns, &jobRun)
-	}
-	return jobRuns, nil
+	return scanJobRuns(rows)
 }
 
 func (pg Pgsql) GetStaleJobs(ctx context.Context) ([]StaleJobRun, error) {
@@ -389,13 +670,501 @@ func (pg Pgsql) GetStaleJobs(ctx context.Context) ([]StaleJobRun, error) {
 	return staleJobRuns, nil
 }
 
-func (pg Pgsql) UpdateJobRun(ctx context.Context, jobRun JobRun) error {
-	sql := `UPDATE job_runs SET status = $1, reason = $2, end_date = $3 WHERE id = $4`
+func (pg Pgsql) GetJobRunStats(ctx context.Context, filter JobRunStatsFilter) (JobRunStats, error) {
+	sql := `SELECT COUNT(*),
+				COUNT(*) FILTER (WHERE jr.status = $1),
+				COUNT(*) FILTER (WHERE jr.status = $2),
+				COUNT(*) FILTER (WHERE jr.status = $3),
+				COUNT(*) FILTER (WHERE jr.attempt > 1),
+				percentile_cont(ARRAY[0.5, 0.95, 0.99]) 
+					WITHIN GROUP (ORDER BY EXTRACT(EPOCH FROM jr.end_date - jr.start_date))
+					FILTER (WHERE jr.end_date IS NOT NULL),
+				MIN(jr.start_date) FILTER (WHERE jr.status = $2),
+				MAX(jr.start_date) FILTER (WHERE jr.status = $2)
+			FROM job_runs AS jr
+			WHERE jr.start_date >= $4 AND jr.start_date < $5`
+
+	args := []any{JobSucceed, JobFailed, JobWaiting, filter.From, filter.To}
+	if filter.ScheduleId != uuid.Nil {
+		args = append(args, filter.ScheduleId)
+		sql += fmt.Sprintf(` AND jr.schedule_id = $%d`, len(args))
+	}
+
+	if filter.JobSlug != "" {
+		args = append(args, filter.JobSlug)
+		sql += fmt.Sprintf(` AND EXISTS (SELECT 1 FROM jobs AS j WHERE j.schedule_id = jr.schedule_id 
+			AND j.slug = $%d)`, len(args))
+	}
+
+	var stats JobRunStats
+	err := pg.pool.QueryRow(ctx, sql, args...).
+		Scan(&stats.Total, &stats.Succeed, &stats.Failed, &stats.Waiting, &stats.Retried, &stats.Percentiles,
+			&stats.FirstFail, &stats.LastFail)
+	if err != nil {
+		return JobRunStats{}, err
+	}
+
+	return stats, nil
+}
+
+// GetJobRunRollupStats returns stats approximated from rollups, percentiles are estimated from
+// duration histogram and failures dates are start dates of buckets
+func (pg Pgsql) GetJobRunRollupStats(ctx context.Context, granularity RollupGranularity,
+	filter JobRunStatsFilter) (JobRunStats, error) {
+	sql := `SELECT COALESCE(SUM(r.total), 0), COALESCE(SUM(r.succeed), 0), COALESCE(SUM(r.failed), 0),
+				COALESCE(SUM(r.waiting), 0), COALESCE(SUM(r.retried), 0), array_sum(r.duration_histogram),
+				MIN(r.bucket_start) FILTER (WHERE r.failed > 0), MAX(r.bucket_start) FILTER (WHERE r.failed > 0)
+			FROM job_run_rollups AS r
+			WHERE r.granularity = $1 AND r.bucket_start >= $2 AND r.bucket_start < $3`
+
+	args := []any{granularity, truncateToBucket(filter.From, granularity), filter.To}
+	if filter.ScheduleId != uuid.Nil {
+		args = append(args, filter.ScheduleId)
+		sql += fmt.Sprintf(` AND r.schedule_id = $%d`, len(args))
+	}
+
+	if filter.JobSlug != "" {
+		args = append(args, filter.JobSlug)
+		sql += fmt.Sprintf(` AND r.job_slug = $%d`, len(args))
+	}
+
+	var stats JobRunStats
+	var histogram []int
+	err := pg.pool.QueryRow(ctx, sql, args...).
+		Scan(&stats.Total, &stats.Succeed, &stats.Failed, &stats.Waiting, &stats.Retried, &histogram,
+			&stats.FirstFail, &stats.LastFail)
+	if err != nil {
+		return JobRunStats{}, err
+	}
+
+	stats.Percentiles = HistogramPercentiles(DurationHistogramBounds, histogram, 0.5, 0.95, 0.99)
+
+	return stats, nil
+}
+
+func (pg Pgsql) GetRollupWatermark(ctx context.Context) (*time.Time, error) {
+	var watermark *time.Time
+
+	err := pg.pool.QueryRow(ctx, `SELECT watermark FROM job_run_rollup_watermark WHERE id = 1`).Scan(&watermark)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return watermark, nil
+}
+
+func (pg Pgsql) GetOldestJobRunDate(ctx context.Context) (*time.Time, error) {
+	var oldest *time.Time
+
+	err := pg.pool.QueryRow(ctx, `SELECT MIN(start_date) FROM job_runs`).Scan(&oldest)
+	if err != nil {
+		return nil, err
+	}
+
+	return oldest, nil
+}
+
+// rollupLockKey is advisory lock key which makes only one instance aggregate rollups at a time
+const rollupLockKey = 7468_001
+
+// RollupJobRuns recomputes hourly rollups of job runs started in range, daily rollups of days touched
+// by the range and stores watermark, returns false when other instance holds the aggregation lock
+func (pg Pgsql) RollupJobRuns(ctx context.Context, from, to, watermark time.Time) (bool, error) {
+	tx, err := pg.pool.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+
+	var acquired bool
+	err = tx.QueryRow(ctx, `SELECT pg_try_advisory_xact_lock($1)`, rollupLockKey).Scan(&acquired)
+	if err != nil || !acquired {
+		return false, err
+	}
+
+	histogram := make([]string, 0, len(DurationHistogramBounds)+1)
+	duration := `EXTRACT(EPOCH FROM jr.end_date - jr.start_date)`
+	lower := "0"
+	for _, bound := range DurationHistogramBounds {
+		histogram = append(histogram, fmt.Sprintf(
+			`COUNT(*) FILTER (WHERE jr.end_date IS NOT NULL AND %s >= %s AND %s < %g)`,
+			duration, lower, duration, bound))
+		lower = fmt.Sprintf("%g", bound)
+	}
+	histogram = append(histogram, fmt.Sprintf(
+		`COUNT(*) FILTER (WHERE jr.end_date IS NOT NULL AND %s >= %s)`, duration, lower))
+
+	hourly := fmt.Sprintf(`INSERT INTO job_run_rollups (granularity, bucket_start, schedule_id, job_slug, 
+				total, succeed, failed, waiting, retried, duration_histogram)
+			SELECT $1, date_trunc('hour', jr.start_date AT TIME ZONE 'UTC') AT TIME ZONE 'UTC', jr.schedule_id,
+				j.slug, COUNT(*), COUNT(*) FILTER (WHERE jr.status = $2), COUNT(*) FILTER (WHERE jr.status = $3),
+				COUNT(*) FILTER (WHERE jr.status = $4), COUNT(*) FILTER (WHERE jr.attempt > 1), ARRAY[%s]
+			FROM job_runs AS jr
+			JOIN jobs AS j ON j.schedule_id = jr.schedule_id
+			WHERE jr.start_date >= $5 AND jr.start_date < $6
+			GROUP BY 2, 3, 4
+			ON CONFLICT (granularity, bucket_start, schedule_id) DO UPDATE SET job_slug = EXCLUDED.job_slug,
+				total = EXCLUDED.total, succeed = EXCLUDED.succeed, failed = EXCLUDED.failed,
+				waiting = EXCLUDED.waiting, retried = EXCLUDED.retried,
+				duration_histogram = EXCLUDED.duration_histogram`, strings.Join(histogram, ", "))
+
+	_, err = tx.Exec(ctx, hourly, Hourly, JobSucceed, JobFailed, JobWaiting, from, to)
+	if err != nil {
+		return false, err
+	}
+
+	daily := `INSERT INTO job_run_rollups (granularity, bucket_start, schedule_id, job_slug, 
+				total, succeed, failed, waiting, retried, duration_histogram)
+			SELECT $1, date_trunc('day', r.bucket_start AT TIME ZONE 'UTC') AT TIME ZONE 'UTC', r.schedule_id,
+				MAX(r.job_slug), SUM(r.total), SUM(r.succeed), SUM(r.failed), SUM(r.waiting), SUM(r.retried),
+				array_sum(r.duration_histogram)
+			FROM job_run_rollups AS r
+			WHERE r.granularity = $2 AND r.bucket_start >= $3 AND r.bucket_start < $4
+			GROUP BY 2, 3
+			ON CONFLICT (granularity, bucket_start, schedule_id) DO UPDATE SET job_slug = EXCLUDED.job_slug,
+				total = EXCLUDED.total, succeed = EXCLUDED.succeed, failed = EXCLUDED.failed,
+				waiting = EXCLUDED.waiting, retried = EXCLUDED.retried,
+				duration_histogram = EXCLUDED.duration_histogram`
+
+	_, err = tx.Exec(ctx, daily, Daily, Hourly, truncateToBucket(from, Daily),
+		truncateToBucket(to.Add(-time.Nanosecond), Daily).Add(time.Hour*24))
+	if err != nil {
+		return false, err
+	}
+
+	_, err = tx.Exec(ctx, `INSERT INTO job_run_rollup_watermark (id, watermark) VALUES (1, $1)
+			ON CONFLICT (id) DO UPDATE SET watermark = EXCLUDED.watermark`, watermark)
+	if err != nil {
+		return false, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (pg Pgsql) DeleteRollups(ctx context.Context, granularity RollupGranularity, before time.Time) (int64, error) {
+	tag, err := pg.pool.Exec(ctx, `DELETE FROM job_run_rollups WHERE granularity = $1 AND bucket_start < $2`,
+		granularity, before)
+	if err != nil {
+		return 0, err
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+// GetJobRunRetentions returns job runs retentions of schedules overriding the global one
+func (pg Pgsql) GetJobRunRetentions(ctx context.Context) (map[uuid.UUID]string, error) {
+	rows, err := pg.pool.Query(ctx, `SELECT id, job_run_retention FROM schedules 
+			WHERE job_run_retention IS NOT NULL AND job_run_retention <> ''`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	retentions := make(map[uuid.UUID]string)
+	for rows.Next() {
+		var id uuid.UUID
+		var retention string
+		if err := rows.Scan(&id, &retention); err != nil {
+			return nil, err
+		}
+
+		retentions[id] = retention
+	}
+
+	return retentions, rows.Err()
+}
+
+// DeleteJobRuns deletes (or moves to archive) at most limit job runs started before given date, when
+// schedule id is nil job runs of all schedules without own retention are deleted
+func (pg Pgsql) DeleteJobRuns(ctx context.Context, scheduleId uuid.UUID, before time.Time, limit int,
+	archive bool) (int64, error) {
+	condition := `jr.schedule_id = $3`
+	args := []any{before, limit, scheduleId}
+	if scheduleId == uuid.Nil {
+		condition = `NOT EXISTS (SELECT 1 FROM schedules AS s WHERE s.id = jr.schedule_id 
+				AND s.job_run_retention IS NOT NULL AND s.job_run_retention <> '')`
+		args = args[:2]
+	}
+
+	batch := fmt.Sprintf(`SELECT jr.id FROM job_runs AS jr 
+			WHERE jr.start_date < $1 AND %s 
+			LIMIT $2`, condition)
+
+	sql := `DELETE FROM job_runs WHERE id IN (` + batch + `)`
+	if archive {
+		sql = `WITH moved AS (DELETE FROM job_runs WHERE id IN (` + batch + `) RETURNING *)
+			INSERT INTO job_runs_archive ` + archiveColumns("job_runs_archive")
+	}
+
+	tag, err := pg.pool.Exec(ctx, sql, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+// GetFinishedSchedules returns finished one-shot schedules executed last time before given date
+func (pg Pgsql) GetFinishedSchedules(ctx context.Context, before time.Time, limit int) ([]*Schedule, error) {
+	sql := `SELECT ` + scheduleColumns + `
+			FROM jobs AS j 
+			JOIN schedules AS s ON s.id = j.schedule_id
+			WHERE s.frequency = $1 AND s.status = $2 AND s.last_execution_date < $3
+			ORDER BY s.last_execution_date ASC
+			LIMIT $4`
+
+	rows, err := pg.pool.Query(ctx, sql, Once, Finished, before, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	schedules := make([]*Schedule, 0, limit)
+	for rows.Next() {
+		schedule, err := scanSchedule(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		schedules = append(schedules, schedule)
+	}
+
+	return schedules, rows.Err()
+}
+
+// ArchiveScheduleById moves schedule with its job and job runs to archive tables
+func (pg Pgsql) ArchiveScheduleById(ctx context.Context, id uuid.UUID) error {
+	tx, err := pg.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	statements := []string{
+		`WITH moved AS (DELETE FROM job_runs WHERE schedule_id = $1 RETURNING *)
+			INSERT INTO job_runs_archive ` + archiveColumns("job_runs_archive"),
+		`WITH moved AS (DELETE FROM jobs WHERE schedule_id = $1 RETURNING *)
+			INSERT INTO jobs_archive ` + archiveColumns("jobs_archive"),
+		`WITH moved AS (DELETE FROM schedules WHERE id = $1 RETURNING *)
+			INSERT INTO schedules_archive ` + archiveColumns("schedules_archive"),
+	}
+
+	for _, statement := range statements {
+		if _, err = tx.Exec(ctx, statement, id); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// partitionLockKey is advisory lock key which serializes partitions maintenance between instances
+const partitionLockKey = 7468_002
+
+// websocketLockKey is advisory lock key held by the only instance serving websocket workers
+const websocketLockKey = 7468_004
 
-	_, err := pg.pool.Exec(ctx, sql, jobRun.Status, jobRun.Reason, jobRun.EndDate, jobRun.Id)
+// LockWebsocketInstance takes session advisory lock on connection removed from pool, so it is held until process
+// exits, instance which fails to take it must not serve websocket transport
+func (pg Pgsql) LockWebsocketInstance(ctx context.Context) error {
+	conn, err := pg.pool.Acquire(ctx)
 	if err != nil {
 		return err
 	}
 
+	var acquired bool
+	if err = conn.QueryRow(ctx, `SELECT pg_try_advisory_lock($1)`, websocketLockKey).Scan(&acquired); err != nil {
+		conn.Release()
+		return err
+	}
+
+	if !acquired {
+		conn.Release()
+		return ErrWebsocketInstanceRunning
+	}
+
+	conn.Hijack()
+
 	return nil
 }
+
+// archiveColumns selects moved rows into archive table by column names, column order of source and archive
+// tables differs when columns were added by later migrations or table was recreated by partitioning
+func archiveColumns(archive string) string {
+	return `SELECT (jsonb_populate_record(NULL::` + archive + `,
+		to_jsonb(moved) || jsonb_build_object('archive_date', now()))).* FROM moved`
+}
+
+func (pg Pgsql) GetJobRunPartitions(ctx context.Context) ([]string, error) {
+	rows, err := pg.pool.Query(ctx, `SELECT c.relname FROM pg_inherits AS i
+			JOIN pg_class AS c ON c.oid = i.inhrelid
+			WHERE i.inhparent = 'job_runs'::regclass`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	names := make([]string, 0)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+
+		names = append(names, name)
+	}
+
+	return names, rows.Err()
+}
+
+// CreateJobRunPartition creates partition and attaches it to job_runs, job runs of partition range
+// which already landed in default partition are moved to the new one, otherwise attaching would fail
+func (pg Pgsql) CreateJobRunPartition(ctx context.Context, partition JobRunPartition) error {
+	tx, err := pg.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err = tx.Exec(ctx, `SELECT pg_advisory_xact_lock($1)`, partitionLockKey); err != nil {
+		return err
+	}
+
+	var exists bool
+	err = tx.QueryRow(ctx, `SELECT to_regclass($1) IS NOT NULL`, partition.Name).Scan(&exists)
+	if err != nil || exists {
+		return err
+	}
+
+	table := pgx.Identifier{partition.Name}.Sanitize()
+
+	_, err = tx.Exec(ctx, `CREATE TABLE `+table+` (LIKE job_runs INCLUDING DEFAULTS INCLUDING CONSTRAINTS)`)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `WITH moved AS (DELETE FROM job_runs_default 
+				WHERE start_date >= $1 AND start_date < $2 RETURNING *)
+			INSERT INTO `+table+` SELECT * FROM moved`, partition.From, partition.To)
+	if err != nil {
+		return err
+	}
+
+	// partition bounds can't be passed as parameters of DDL statement
+	_, err = tx.Exec(ctx, fmt.Sprintf(`ALTER TABLE job_runs ATTACH PARTITION %s FOR VALUES FROM ('%s') TO ('%s')`,
+		table, partition.From.Format(time.RFC3339), partition.To.Format(time.RFC3339)))
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// HasJobRunsOfSchedules reports if partition contains any job run of given schedules
+func (pg Pgsql) HasJobRunsOfSchedules(ctx context.Context, partition JobRunPartition,
+	scheduleIds []uuid.UUID) (bool, error) {
+	var exists bool
+	err := pg.pool.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM `+pgx.Identifier{partition.Name}.Sanitize()+`
+			WHERE schedule_id = ANY($1))`, scheduleIds).Scan(&exists)
+
+	return exists, err
+}
+
+// DropJobRunPartition drops partition, job runs of partition are moved to archive first when archive is set
+func (pg Pgsql) DropJobRunPartition(ctx context.Context, partition JobRunPartition, archive bool) error {
+	tx, err := pg.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err = tx.Exec(ctx, `SELECT pg_advisory_xact_lock($1)`, partitionLockKey); err != nil {
+		return err
+	}
+
+	var exists bool
+	err = tx.QueryRow(ctx, `SELECT to_regclass($1) IS NOT NULL`, partition.Name).Scan(&exists)
+	if err != nil || !exists {
+		return err
+	}
+
+	table := pgx.Identifier{partition.Name}.Sanitize()
+
+	if archive {
+		_, err = tx.Exec(ctx, `WITH moved AS (SELECT * FROM `+table+`)
+			INSERT INTO job_runs_archive `+archiveColumns("job_runs_archive"))
+		if err != nil {
+			return err
+		}
+	}
+
+	if _, err = tx.Exec(ctx, `DROP TABLE `+table); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// jobQueueReferences counts unfinished schedules dispatching to queue named after job slug
+const jobQueueReferences = `SELECT COUNT(*) FROM schedules AS s
+		JOIN jobs AS j ON j.schedule_id = s.id
+		WHERE j.slug = q.name AND s.transport_type = 'rabbitmq' AND s.exchange = '' AND s.status != 'finished'`
+
+func (pg Pgsql) GetJobQueues(ctx context.Context) ([]JobQueue, error) {
+	rows, err := pg.pool.Query(ctx, `SELECT q.name, q.externally_managed, (`+jobQueueReferences+`), q.creation_date
+			FROM job_queues AS q
+			ORDER BY q.name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	queues := make([]JobQueue, 0)
+	for rows.Next() {
+		var queue JobQueue
+		if err = rows.Scan(&queue.Name, &queue.ExternallyManaged, &queue.References, &queue.CreationDate); err != nil {
+			return nil, err
+		}
+
+		queues = append(queues, queue)
+	}
+
+	return queues, rows.Err()
+}
+
+// ClaimPullJob leases the oldest job of slugs which is not leased or whose lease expired, jobs locked
+// by concurrent claims are skipped, so each job is leased to single worker
+func (pg Pgsql) ClaimPullJob(ctx context.Context, slugs []string, lease time.Duration,
+	now time.Time) (*PullJob, error) {
+	var job PullJob
+	var payload string
+
+	err := pg.pool.QueryRow(ctx, `UPDATE pull_jobs SET deliveries = deliveries + 1, lease_expiry = $3
+			WHERE job_run_id = (
+				SELECT job_run_id FROM pull_jobs
+				WHERE slug = ANY($1) AND (lease_expiry IS NULL OR lease_expiry <= $2)
+				ORDER BY creation_date ASC
+				LIMIT 1
+				FOR UPDATE SKIP LOCKED)
+			RETURNING job_run_id, schedule_id, slug, payload, deliveries, lease_expiry, creation_date`,
+		slugs, now, now.Add(lease)).Scan(&job.JobRunId, &job.ScheduleId, &job.Slug, &payload, &job.Deliveries,
+		&job.LeaseExpiry, &job.CreationDate)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	job.Payload = []byte(payload)
+	return &job, nil
+}
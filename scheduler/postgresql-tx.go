@@ -0,0 +1,185 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// StorageTx is unit of work, all changes made through it are committed or rolled back together
+type StorageTx interface {
+	GetScheduleForUpdate(ctx context.Context, id uuid.UUID) (*Schedule, error)
+	GetJobRunForUpdate(ctx context.Context, id uuid.UUID) (*JobRun, error)
+	UpdateSchedule(ctx context.Context, schedule Schedule) error
+	AddJobRun(ctx context.Context, jobRun JobRun) error
+	UpdateJobRun(ctx context.Context, jobRun JobRun) error
+	AddOutboxMessage(ctx context.Context, message OutboxMessage) error
+	UpdateOutboxMessage(ctx context.Context, message OutboxMessage) error
+	AddPullJob(ctx context.Context, job PullJob) error
+	DeletePullJob(ctx context.Context, jobRunId uuid.UUID) error
+	AddInboxMessage(ctx context.Context, eventId uuid.UUID, jobRunId uuid.UUID) (bool, error)
+	GetJobQueueForUpdate(ctx context.Context, name string) (*JobQueue, error)
+	CountJobQueueReferences(ctx context.Context, name string) (int, error)
+	DeleteJobQueue(ctx context.Context, name string) error
+}
+
+type pgsqlTx struct {
+	tx pgx.Tx
+}
+
+// WithTx runs fn in transaction, which is committed when fn returns nil
+func (pg Pgsql) WithTx(ctx context.Context, fn func(tx StorageTx) error) error {
+	return pgx.BeginFunc(ctx, pg.pool, func(tx pgx.Tx) error {
+		return fn(pgsqlTx{tx: tx})
+	})
+}
+
+// GetScheduleForUpdate returns schedule with row lock held until end of transaction, so schedule
+// is changed by one transition at a time, schedule is always locked before its job runs
+func (t pgsqlTx) GetScheduleForUpdate(ctx context.Context, id uuid.UUID) (*Schedule, error) {
+	schedule, err := scanSchedule(t.tx.QueryRow(ctx, `SELECT `+scheduleColumns+`
+			FROM jobs AS j
+			JOIN schedules AS s ON s.id = j.schedule_id
+			WHERE s.id = $1
+			FOR UPDATE OF s`, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return schedule, nil
+}
+
+func (t pgsqlTx) GetJobRunForUpdate(ctx context.Context, id uuid.UUID) (*JobRun, error) {
+	jobRun, err := scanJobRun(t.tx.QueryRow(ctx, `SELECT `+jobRunColumns+`
+			FROM job_runs AS jr
+			WHERE jr.id = $1
+			FOR UPDATE`, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return jobRun, nil
+}
+
+func (t pgsqlTx) UpdateSchedule(ctx context.Context, schedule Schedule) error {
+	sql := `UPDATE schedules SET last_execution_date = $1, next_execution_date = $2, status = $3, group_id = $4,
+				attempt = $5
+			WHERE id = $6`
+
+	_, err := t.tx.Exec(ctx, sql, schedule.LastExecutionDate, schedule.NextExecutionDate, schedule.Status,
+		schedule.GroupId, schedule.Attempt, schedule.Id)
+
+	return err
+}
+
+func (t pgsqlTx) AddJobRun(ctx context.Context, jobRun JobRun) error {
+	sql := `INSERT INTO job_runs (id, group_id, schedule_id, status, attempt, scheduled_date, reason, start_date,
+				end_date)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`
+
+	_, err := t.tx.Exec(ctx, sql, jobRun.Id, jobRun.GroupId, jobRun.ScheduleId, jobRun.Status, jobRun.Attempt,
+		jobRun.ScheduledDate, jobRun.Reason, jobRun.StartDate, jobRun.EndDate)
+
+	return err
+}
+
+func (t pgsqlTx) UpdateJobRun(ctx context.Context, jobRun JobRun) error {
+	sql := `UPDATE job_runs SET status = $1, reason = $2, end_date = $3 WHERE id = $4`
+
+	_, err := t.tx.Exec(ctx, sql, jobRun.Status, jobRun.Reason, jobRun.EndDate, jobRun.Id)
+
+	return err
+}
+
+func (t pgsqlTx) AddOutboxMessage(ctx context.Context, message OutboxMessage) error {
+	sql := `INSERT INTO outbox (id, job_run_id, transport_type, exchange, routing_key, payload, attempts,
+				next_attempt_date, creation_date)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`
+
+	_, err := t.tx.Exec(ctx, sql, message.Id, message.JobRunId, message.Transport, message.Exchange,
+		message.RoutingKey, string(message.Payload), message.Attempts, message.NextAttemptDate, message.CreationDate)
+
+	return err
+}
+
+// UpdateOutboxMessage stores result of publish attempt of claimed message
+func (t pgsqlTx) UpdateOutboxMessage(ctx context.Context, message OutboxMessage) error {
+	sql := `UPDATE outbox SET attempts = $1, last_error = $2, next_attempt_date = $3, sent_date = $4,
+				discard_date = $5
+			WHERE id = $6`
+
+	_, err := t.tx.Exec(ctx, sql, message.Attempts, message.LastError, message.NextAttemptDate, message.SentDate,
+		message.DiscardDate, message.Id)
+
+	return err
+}
+
+func (t pgsqlTx) AddPullJob(ctx context.Context, job PullJob) error {
+	sql := `INSERT INTO pull_jobs (job_run_id, schedule_id, slug, payload, deliveries, lease_expiry, creation_date)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)`
+
+	_, err := t.tx.Exec(ctx, sql, job.JobRunId, job.ScheduleId, job.Slug, string(job.Payload), job.Deliveries,
+		job.LeaseExpiry, job.CreationDate)
+
+	return err
+}
+
+func (t pgsqlTx) DeletePullJob(ctx context.Context, jobRunId uuid.UUID) error {
+	_, err := t.tx.Exec(ctx, `DELETE FROM pull_jobs WHERE job_run_id = $1`, jobRunId)
+
+	return err
+}
+
+// AddInboxMessage records processed event, returns false when event has already been recorded
+func (t pgsqlTx) AddInboxMessage(ctx context.Context, eventId uuid.UUID, jobRunId uuid.UUID) (bool, error) {
+	tag, err := t.tx.Exec(ctx, `INSERT INTO inbox (event_id, job_run_id, processed_date) VALUES ($1, $2, $3)
+			ON CONFLICT (event_id) DO NOTHING`, eventId, jobRunId, time.Now())
+	if err != nil {
+		return false, err
+	}
+
+	return tag.RowsAffected() > 0, nil
+}
+
+// GetJobQueueForUpdate returns queue with row lock, registering new schedule of the job waits until lock
+// is released, so queue can't be removed while it gets new reference
+func (t pgsqlTx) GetJobQueueForUpdate(ctx context.Context, name string) (*JobQueue, error) {
+	var queue JobQueue
+	err := t.tx.QueryRow(ctx, `SELECT name, externally_managed, creation_date FROM job_queues
+			WHERE name = $1
+			FOR UPDATE`, name).Scan(&queue.Name, &queue.ExternallyManaged, &queue.CreationDate)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return &queue, nil
+}
+
+func (t pgsqlTx) CountJobQueueReferences(ctx context.Context, name string) (int, error) {
+	var references int
+	err := t.tx.QueryRow(ctx, `SELECT (`+jobQueueReferences+`) FROM (SELECT $1::TEXT AS name) AS q`,
+		name).Scan(&references)
+
+	return references, err
+}
+
+func (t pgsqlTx) DeleteJobQueue(ctx context.Context, name string) error {
+	_, err := t.tx.Exec(ctx, `DELETE FROM job_queues WHERE name = $1`, name)
+
+	return err
+}
@@ -0,0 +1,145 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	defaultPullMaxWait      = time.Second * 30
+	defaultPullLease        = time.Minute * 5
+	defaultPullPollInterval = time.Millisecond * 500
+)
+
+var ErrPullDisabled = &Error{
+	Code: "PULL_DISABLED",
+	Msg:  "pull transport is not enabled"}
+
+type PullConfig struct {
+	MaxWait      time.Duration // longest time claim waits for job, longer waits are shortened
+	DefaultLease time.Duration // lease of job when worker does not ask for one
+	MaxLease     time.Duration // zero allows any lease
+	PollInterval time.Duration // delay between claim attempts while waiting
+}
+
+// PullJob is job of pull transport waiting in storage until worker claims it, job is leased to worker
+// and returns to the queue when lease expires before worker reports job status
+type PullJob struct {
+	JobRunId     uuid.UUID
+	ScheduleId   uuid.UUID
+	Slug         string
+	Payload      json.RawMessage
+	Deliveries   int
+	LeaseExpiry  *time.Time
+	CreationDate time.Time
+}
+
+// PullClaim asks for next job of any of slugs, claim waits for job up to wait
+type PullClaim struct {
+	Slugs []string
+	Wait  time.Duration
+	Lease time.Duration
+}
+
+// ClaimedJob is job leased to worker, worker reports its status same way as of other transports
+type ClaimedJob struct {
+	ScheduleJobEvent
+	Deliveries  int       `json:"deliveries"`
+	LeaseExpiry time.Time `json:"leaseExpiry"`
+}
+
+func NewPullJob(schedule *Schedule, jobRun *JobRun, now func() time.Time) (PullJob, error) {
+	payload, err := json.Marshal(newScheduleJobEvent(schedule, jobRun))
+	if err != nil {
+		return PullJob{}, err
+	}
+
+	return PullJob{
+		JobRunId:     jobRun.Id,
+		ScheduleId:   schedule.Id,
+		Slug:         schedule.Job.Slug,
+		Payload:      payload,
+		CreationDate: now().Round(time.Second),
+	}, nil
+}
+
+func (s *Scheduler) pullEnabled() bool {
+	return s.pull != nil && slices.Contains(Supports, string(Pull))
+}
+
+// lease returns lease of claim limited by configuration
+func (c PullConfig) lease(requested time.Duration) time.Duration {
+	lease := requested
+	if lease <= 0 {
+		lease = c.DefaultLease
+	}
+
+	if lease <= 0 {
+		lease = defaultPullLease
+	}
+
+	if c.MaxLease > 0 {
+		lease = min(lease, c.MaxLease)
+	}
+
+	return lease
+}
+
+// ClaimJob leases next job of claimed slugs, when there is none it polls storage until job appears
+// or wait elapses, nil job means nothing was available
+func (s *Scheduler) ClaimJob(ctx context.Context, claim PullClaim) (*ClaimedJob, error) {
+	if !s.pullEnabled() {
+		return nil, ErrPullDisabled
+	}
+
+	config := *s.pull
+	if config.MaxWait <= 0 {
+		config.MaxWait = defaultPullMaxWait
+	}
+
+	if config.PollInterval <= 0 {
+		config.PollInterval = defaultPullPollInterval
+	}
+
+	lease := config.lease(claim.Lease)
+	deadline := time.Now().Add(min(claim.Wait, config.MaxWait))
+
+	for {
+		job, err := s.Storage.ClaimPullJob(ctx, claim.Slugs, lease, time.Now())
+		if err != nil {
+			return nil, err
+		}
+
+		if job != nil {
+			return newClaimedJob(job)
+		}
+
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return nil, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(min(config.PollInterval, remaining)):
+		}
+	}
+}
+
+func newClaimedJob(job *PullJob) (*ClaimedJob, error) {
+	claimed := ClaimedJob{Deliveries: job.Deliveries}
+	if err := json.Unmarshal(job.Payload, &claimed.ScheduleJobEvent); err != nil {
+		return nil, err
+	}
+
+	if job.LeaseExpiry != nil {
+		claimed.LeaseExpiry = *job.LeaseExpiry
+	}
+
+	return &claimed, nil
+}
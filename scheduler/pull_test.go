@@ -0,0 +1,133 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// pullStorageFake returns queued jobs one by one from claims, remaining storage methods are not used
+type pullStorageFake struct {
+	StorageDriver
+	jobs   []*PullJob
+	claims int
+	leases []time.Duration
+}
+
+func (s *pullStorageFake) ClaimPullJob(ctx context.Context, slugs []string, lease time.Duration,
+	now time.Time) (*PullJob, error) {
+	s.claims++
+	s.leases = append(s.leases, lease)
+
+	if len(s.jobs) == 0 {
+		return nil, nil
+	}
+
+	job := s.jobs[0]
+	s.jobs = s.jobs[1:]
+	expiry := now.Add(lease)
+	job.LeaseExpiry = &expiry
+	job.Deliveries++
+
+	return job, nil
+}
+
+func newPullScheduler(storage StorageDriver, config *PullConfig) *Scheduler {
+	Supports = []string{string(Pull)}
+
+	return &Scheduler{Storage: storage, pull: config, logger: zap.NewNop().Sugar()}
+}
+
+func TestPullConfigLease(t *testing.T) {
+	tests := map[string]struct {
+		config    PullConfig
+		requested time.Duration
+
+		expect time.Duration
+	}{
+		"requested": {
+			config:    PullConfig{DefaultLease: time.Minute},
+			requested: time.Second * 10,
+			expect:    time.Second * 10,
+		},
+		"default": {
+			config: PullConfig{DefaultLease: time.Minute},
+			expect: time.Minute,
+		},
+		"missing_default": {
+			expect: defaultPullLease,
+		},
+		"limited": {
+			config:    PullConfig{MaxLease: time.Minute},
+			requested: time.Hour,
+			expect:    time.Minute,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if lease := test.config.lease(test.requested); lease != test.expect {
+				t.Errorf("expect result %+v, got %+v", test.expect, lease)
+			}
+		})
+	}
+}
+
+func TestClaimJob(t *testing.T) {
+	s := NewSchedule("description", "once", getStubDate,
+		WithConfiguration(Pull, ""),
+		WithJob("slug", nil))
+	jobRun := NewJobRun(s.Id, s.GroupId, 1, getStubDate(), getStubDate)
+
+	job, err := NewPullJob(&s, &jobRun, getStubDate)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	storage := &pullStorageFake{jobs: []*PullJob{&job}}
+	scheduler := newPullScheduler(storage, &PullConfig{DefaultLease: time.Minute})
+
+	claimed, err := scheduler.ClaimJob(context.Background(), PullClaim{Slugs: []string{"slug"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if claimed == nil || claimed.JobRunId != jobRun.Id || claimed.Job != "slug" || claimed.Deliveries != 1 ||
+		claimed.LeaseExpiry.IsZero() {
+		t.Errorf("expect result %+v, got %+v", job, claimed)
+	}
+
+	if storage.leases[0] != time.Minute {
+		t.Errorf("expect result %+v, got %+v", time.Minute, storage.leases[0])
+	}
+}
+
+func TestClaimJobWait(t *testing.T) {
+	storage := &pullStorageFake{}
+	scheduler := newPullScheduler(storage, &PullConfig{
+		MaxWait:      time.Millisecond * 100,
+		PollInterval: time.Millisecond * 20,
+	})
+
+	// wait is shortened to max wait, storage is polled until it elapses
+	claimed, err := scheduler.ClaimJob(context.Background(), PullClaim{Slugs: []string{"slug"}, Wait: time.Hour})
+	if err != nil || claimed != nil {
+		t.Errorf("expect result %+v, got %+v %v", nil, claimed, err)
+	}
+
+	if storage.claims < 2 {
+		t.Errorf("expect result %+v, got %+v", "at least 2 claims", storage.claims)
+	}
+}
+
+func TestClaimJobDisabled(t *testing.T) {
+	scheduler := newPullScheduler(&pullStorageFake{}, nil)
+
+	_, err := scheduler.ClaimJob(context.Background(), PullClaim{Slugs: []string{"slug"}})
+	if !errors.Is(err, ErrPullDisabled) {
+		t.Errorf("expect error %+v, got %+v", ErrPullDisabled, err)
+	}
+}
@@ -0,0 +1,183 @@
+package scheduler
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"go.uber.org/zap"
+)
+
+type ConnectionState string
+
+const (
+	Connected    ConnectionState = "connected"
+	Reconnecting ConnectionState = "reconnecting"
+)
+
+const (
+	minReconnectBackoff = time.Second
+	maxReconnectBackoff = time.Second * 30
+)
+
+var ErrConnectionUnavailable = &Error{
+	Code: "CONNECTION_UNAVAILABLE",
+	Msg:  "rabbitmq connection is not available"}
+
+// recoverableConnection re-dials lost connection with backoff for as long as it takes,
+// onReconnect is called after each reconnect, before waiting consumers are released
+type recoverableConnection struct {
+	mu         sync.RWMutex
+	url        string
+	vhost      string
+	connection *amqp.Connection
+	state      ConnectionState
+	attempt    int
+	ready      chan struct{} // closed while connection is available
+
+	onReconnect func()
+	logger      *zap.SugaredLogger
+}
+
+func establishRcvConn(url, vhost string, logger *zap.SugaredLogger,
+	onReconnect func()) (*recoverableConnection, error) {
+	conn, err := connect(url, vhost)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+	logger.Info("connected to rabbitmq")
+
+	ready := make(chan struct{})
+	close(ready)
+
+	rcvConn := &recoverableConnection{
+		url:         url,
+		vhost:       vhost,
+		connection:  conn,
+		state:       Connected,
+		ready:       ready,
+		onReconnect: onReconnect,
+		logger:      logger,
+	}
+
+	go rcvConn.watch(conn)
+
+	return rcvConn, nil
+}
+
+// connect dials broker, empty vhost means vhost of url
+func connect(url, vhost string) (*amqp.Connection, error) {
+	conn, err := amqp.DialConfig(url, amqp.Config{
+		Vhost: vhost,
+		Properties: amqp.Table{
+			"product":  "timely",
+			"version":  "v0.1.0",
+			"platform": "golang",
+		}})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return conn, nil
+}
+
+func (rc *recoverableConnection) watch(conn *amqp.Connection) {
+	for {
+		closeErr := <-conn.NotifyClose(make(chan *amqp.Error, 1))
+		if closeErr != nil {
+			rc.logger.Errorf("rabbitmq non graceful connection close - %s", closeErr.Error())
+		} else {
+			rc.logger.Warnln("rabbitmq graceful connection close")
+		}
+
+		rc.mu.Lock()
+		rc.state = Reconnecting
+		rc.ready = make(chan struct{})
+		rc.mu.Unlock()
+
+		conn = rc.redial()
+
+		rc.mu.Lock()
+		rc.connection = conn
+		rc.state = Connected
+		ready := rc.ready
+		attempts := rc.attempt
+		rc.attempt = 0
+		rc.mu.Unlock()
+
+		rc.logger.Infof("reconnected to rabbitmq after %d attempt", attempts)
+
+		if rc.onReconnect != nil {
+			rc.onReconnect()
+		}
+
+		close(ready)
+	}
+}
+
+func (rc *recoverableConnection) redial() *amqp.Connection {
+	for {
+		rc.mu.Lock()
+		rc.attempt++
+		attempt := rc.attempt
+		rc.mu.Unlock()
+
+		conn, err := connect(rc.url, rc.vhost)
+		if err == nil {
+			return conn
+		}
+
+		backoff := reconnectBackoff(attempt)
+		rc.logger.Errorf("rabbitmq reconnect attempt %d failed, next in %s - %v", attempt, backoff, err)
+		time.Sleep(backoff)
+	}
+}
+
+// channel opens new channel, fails fast when connection is being recovered
+func (rc *recoverableConnection) channel() (*amqp.Channel, error) {
+	rc.mu.RLock()
+	defer rc.mu.RUnlock()
+
+	if rc.state != Connected {
+		return nil, ErrConnectionUnavailable
+	}
+
+	return rc.connection.Channel()
+}
+
+// wait blocks until connection is available
+func (rc *recoverableConnection) wait(ctx context.Context) error {
+	rc.mu.RLock()
+	ready := rc.ready
+	rc.mu.RUnlock()
+
+	select {
+	case <-ready:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (rc *recoverableConnection) State() ConnectionState {
+	rc.mu.RLock()
+	defer rc.mu.RUnlock()
+
+	return rc.state
+}
+
+// reconnectBackoff grows exponentially from minimal to maximal backoff
+func reconnectBackoff(attempt int) time.Duration {
+	if attempt < 1 {
+		return minReconnectBackoff
+	}
+
+	if attempt > 6 {
+		return maxReconnectBackoff
+	}
+
+	return min(minReconnectBackoff*time.Duration(1<<(attempt-1)), maxReconnectBackoff)
+}
@@ -0,0 +1,82 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestReconnectBackoff(t *testing.T) {
+	tests := map[string]struct {
+		attempt int
+		result  time.Duration
+	}{
+		"zero_attempt":   {attempt: 0, result: time.Second},
+		"first_attempt":  {attempt: 1, result: time.Second},
+		"third_attempt":  {attempt: 3, result: time.Second * 4},
+		"sixth_attempt":  {attempt: 6, result: time.Second * 30},
+		"capped_attempt": {attempt: 100, result: time.Second * 30},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			result := reconnectBackoff(test.attempt)
+
+			if result != test.result {
+				t.Errorf("expect result %+v, got %+v", test.result, result)
+			}
+		})
+	}
+}
+
+func TestRecoverableConnectionWait(t *testing.T) {
+	tests := map[string]struct {
+		state    ConnectionState
+		released bool
+		err      error
+	}{
+		"connected": {
+			state: Connected,
+			err:   nil,
+		},
+		"reconnected": {
+			state:    Reconnecting,
+			released: true,
+			err:      nil,
+		},
+		"still_reconnecting": {
+			state: Reconnecting,
+			err:   context.DeadlineExceeded,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ready := make(chan struct{})
+			if test.state == Connected {
+				close(ready)
+			}
+			rc := &recoverableConnection{state: test.state, ready: ready}
+
+			if test.released {
+				time.AfterFunc(time.Millisecond*10, func() { close(ready) })
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*100)
+			defer cancel()
+
+			if err := rc.wait(ctx); !errors.Is(err, test.err) {
+				t.Errorf("expect error %+v, got %+v", test.err, err)
+			}
+		})
+	}
+}
+
+func TestRecoverableConnectionChannelWhileReconnecting(t *testing.T) {
+	rc := &recoverableConnection{state: Reconnecting, ready: make(chan struct{})}
+
+	if _, err := rc.channel(); !errors.Is(err, ErrConnectionUnavailable) {
+		t.Errorf("expect error %+v, got %+v", ErrConnectionUnavailable, err)
+	}
+}
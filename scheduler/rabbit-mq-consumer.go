@@ -0,0 +1,285 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+const (
+	defaultPrefetch = 20
+	defaultWorkers  = 20
+
+	// requeueCountHeader counts how many times message has been put back after failed processing
+	requeueCountHeader = "x-timely-requeues"
+	delayQueueSuffix   = "-delay"
+)
+
+var ErrConsumerCancelled = &Error{
+	Code: "CONSUMER_CANCELLED",
+	Msg:  "consumer has been cancelled by broker"}
+
+// SubscribeOptions controls how deliveries of single subscription are processed
+type SubscribeOptions struct {
+	Prefetch     int           // unacked messages broker sends to consumer
+	Workers      int           // messages processed concurrently
+	RequeueDelay time.Duration // delay before failed message is redelivered, zero drops failed messages
+	MaxRequeues  int           // redeliveries of failed message before it is dropped, zero means no limit
+}
+
+type SubscribeOption func(*SubscribeOptions)
+
+func WithPrefetch(prefetch int) SubscribeOption {
+	return func(o *SubscribeOptions) {
+		if prefetch > 0 {
+			o.Prefetch = prefetch
+		}
+	}
+}
+
+func WithWorkers(workers int) SubscribeOption {
+	return func(o *SubscribeOptions) {
+		if workers > 0 {
+			o.Workers = workers
+		}
+	}
+}
+
+// WithRequeueDelay redelivers failed messages through delay queue instead of dropping them
+func WithRequeueDelay(delay time.Duration, maxRequeues int) SubscribeOption {
+	return func(o *SubscribeOptions) {
+		o.RequeueDelay = delay
+		o.MaxRequeues = maxRequeues
+	}
+}
+
+// WithSubscribeDefaults sets options used by every subscription, options passed to Subscribe take precedence
+func WithSubscribeDefaults(opts ...SubscribeOption) RabbitMqOption {
+	return func(t *RabbitMqTransport) {
+		t.subscribeOpts = append(t.subscribeOpts, opts...)
+	}
+}
+
+func newSubscribeOptions(opts ...SubscribeOption) SubscribeOptions {
+	options := SubscribeOptions{
+		Prefetch: defaultPrefetch,
+		Workers:  defaultWorkers,
+	}
+
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	return options
+}
+
+// Subscribe consumes queue until context is cancelled or consumer is cancelled by broker (eg. queue was
+// deleted), consumer is re-attached after connection or channel is recovered
+func (t *RabbitMqTransport) Subscribe(ctx context.Context, queue string, handle func(message []byte) error,
+	opts ...SubscribeOption) error {
+	options := newSubscribeOptions(append(slices.Clone(t.subscribeOpts), opts...)...)
+	queue = t.topology.name(queue)
+
+	err := t.createQueue(queue, t.topology.queueArgs())
+	if err != nil {
+		return err
+	}
+
+	if options.RequeueDelay > 0 {
+		err = t.createQueue(queue+delayQueueSuffix, t.topology.delayQueueArgs(queue, options.RequeueDelay))
+		if err != nil {
+			return err
+		}
+	}
+
+	for attempt := 1; ; attempt++ {
+		err = t.consume(ctx, queue, handle, options)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		if errors.Is(err, ErrConsumerCancelled) {
+			// queue is most likely gone, it is declared again by the next subscription
+			t.registry.invalidateQueue(queue)
+			return err
+		}
+
+		// consumer was running, so backoff starts over
+		if err == nil {
+			attempt = 1
+		}
+
+		backoff := reconnectBackoff(attempt)
+		t.logger.Warnf("consumer of %s interrupted, resubscribing in %s - %v", queue, backoff, err)
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		if err = t.rcvConnection.wait(ctx); err != nil {
+			return err
+		}
+	}
+}
+
+// consume processes deliveries until their channel is closed, in-flight messages are finished before return
+func (t *RabbitMqTransport) consume(ctx context.Context, queue string, handle func(message []byte) error,
+	options SubscribeOptions) error {
+	chann, err := t.rcvConnection.channel()
+	if err != nil {
+		return err
+	}
+	defer chann.Close()
+
+	closed := chann.NotifyClose(make(chan *amqp.Error, 1))
+
+	if err = chann.Qos(options.Prefetch, 0, false); err != nil {
+		return err
+	}
+
+	deliveries, err := chann.ConsumeWithContext(ctx, queue, "", false,
+		false, false, false, amqp.Table{})
+	if err != nil {
+		t.logger.Errorf("error during rabbitmq consume - %v", err)
+		return err
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < options.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for delivery := range deliveries {
+				t.process(ctx, queue, delivery, handle, options)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	// deliveries of open channel are closed only when broker cancels consumer
+	if !chann.IsClosed() {
+		return ErrConsumerCancelled
+	}
+
+	if closeErr := <-closed; closeErr != nil {
+		if closeErr.Code == amqp.NotFound {
+			return errors.Join(ErrConsumerCancelled, closeErr)
+		}
+
+		return closeErr
+	}
+
+	return nil
+}
+
+func (t *RabbitMqTransport) process(ctx context.Context, queue string, delivery amqp.Delivery,
+	handle func(message []byte) error, options SubscribeOptions) {
+	err := handle(delivery.Body)
+	if err == nil {
+		if err = delivery.Ack(false); err != nil {
+			t.logger.Errorf("error during ack - %v", err)
+		}
+		return
+	}
+
+	t.logger.Errorf("error during consumer action processing - %v", err)
+
+	if count, ok := nextRequeue(delivery.Headers, options); ok {
+		err = t.requeue(ctx, queue, delivery, count)
+		if err == nil {
+			if err = delivery.Ack(false); err != nil {
+				t.logger.Errorf("error during ack - %v", err)
+			}
+			return
+		}
+
+		t.logger.Errorf("error during requeue to %s - %v", queue+delayQueueSuffix, err)
+	}
+
+	if err = delivery.Nack(false, false); err != nil {
+		t.logger.Errorf("error during nack - %v", err)
+	}
+}
+
+// requeue publishes copy of failed message to delay queue, which dead letters it back after delay
+func (t *RabbitMqTransport) requeue(ctx context.Context, queue string, delivery amqp.Delivery, count int) error {
+	headers := amqp.Table{}
+	for key, value := range delivery.Headers {
+		headers[key] = value
+	}
+	headers[requeueCountHeader] = int32(count)
+
+	chann, err := t.getConfirmChannel(queue + delayQueueSuffix)
+	if err != nil {
+		return err
+	}
+
+	chann.mu.Lock()
+	defer chann.mu.Unlock()
+
+	confirmation, err := chann.channel.PublishWithDeferredConfirmWithContext(ctx, "", queue+delayQueueSuffix,
+		false, false, amqp.Publishing{
+			Headers:      headers,
+			MessageId:    delivery.MessageId,
+			DeliveryMode: amqp.Persistent,
+			Timestamp:    delivery.Timestamp,
+			ContentType:  delivery.ContentType,
+			Body:         delivery.Body,
+		})
+	if err != nil {
+		return err
+	}
+
+	confirmCtx, cancel := context.WithTimeout(ctx, t.confirmTimeout)
+	defer cancel()
+
+	acked, err := confirmation.WaitContext(confirmCtx)
+	if err != nil {
+		return errors.Join(ErrPublishConfirmTimeout, err)
+	}
+
+	if !acked {
+		return ErrPublishNacked
+	}
+
+	return nil
+}
+
+// nextRequeue returns requeue count of failed message and whether it should be requeued
+func nextRequeue(headers amqp.Table, options SubscribeOptions) (int, bool) {
+	if options.RequeueDelay <= 0 {
+		return 0, false
+	}
+
+	count := 0
+	switch value := headers[requeueCountHeader].(type) {
+	case int32:
+		count = int(value)
+	case int64:
+		count = int(value)
+	case int:
+		count = value
+	}
+
+	return retryAttempt(count, options)
+}
+
+// retryAttempt returns number of the next retry of message already retried count times
+func retryAttempt(count int, options SubscribeOptions) (int, bool) {
+	if options.RequeueDelay <= 0 || options.MaxRequeues > 0 && count >= options.MaxRequeues {
+		return count, false
+	}
+
+	return count + 1, true
+}
@@ -0,0 +1,67 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+func TestNextRequeue(t *testing.T) {
+	tests := map[string]struct {
+		headers amqp.Table
+		options SubscribeOptions
+		count   int
+		requeue bool
+	}{
+		"requeue_disabled": {
+			headers: amqp.Table{},
+			options: SubscribeOptions{},
+			count:   0,
+			requeue: false,
+		},
+		"first_requeue": {
+			headers: amqp.Table{},
+			options: SubscribeOptions{RequeueDelay: time.Second, MaxRequeues: 3},
+			count:   1,
+			requeue: true,
+		},
+		"next_requeue": {
+			headers: amqp.Table{requeueCountHeader: int32(2)},
+			options: SubscribeOptions{RequeueDelay: time.Second, MaxRequeues: 3},
+			count:   3,
+			requeue: true,
+		},
+		"max_requeues_reached": {
+			headers: amqp.Table{requeueCountHeader: int64(3)},
+			options: SubscribeOptions{RequeueDelay: time.Second, MaxRequeues: 3},
+			count:   3,
+			requeue: false,
+		},
+		"unlimited_requeues": {
+			headers: amqp.Table{requeueCountHeader: int32(100)},
+			options: SubscribeOptions{RequeueDelay: time.Second},
+			count:   101,
+			requeue: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			count, requeue := nextRequeue(test.headers, test.options)
+
+			if count != test.count || requeue != test.requeue {
+				t.Errorf("expect result %+v %+v, got %+v %+v", test.count, test.requeue, count, requeue)
+			}
+		})
+	}
+}
+
+func TestNewSubscribeOptions(t *testing.T) {
+	options := newSubscribeOptions(WithPrefetch(0), WithWorkers(5), WithPrefetch(10))
+
+	expected := SubscribeOptions{Prefetch: 10, Workers: 5}
+	if options != expected {
+		t.Errorf("expect result %+v, got %+v", expected, options)
+	}
+}
@@ -0,0 +1,90 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+var ErrManagementNotConfigured = &Error{
+	Code: "MANAGEMENT_NOT_CONFIGURED",
+	Msg:  "rabbitmq management api is not configured"}
+
+// rabbitMqManagement is client of rabbitmq management http api
+type rabbitMqManagement struct {
+	url      string
+	username string
+	password string
+	client   *http.Client
+}
+
+type managementBinding struct {
+	Destination     string `json:"destination"`
+	DestinationType string `json:"destination_type"`
+}
+
+// WithManagement enables management api, which is used to find queues on broker
+func WithManagement(managementUrl, username, password string) RabbitMqOption {
+	return func(t *RabbitMqTransport) {
+		if managementUrl == "" {
+			return
+		}
+
+		t.management = &rabbitMqManagement{
+			url:      strings.TrimSuffix(managementUrl, "/"),
+			username: username,
+			password: password,
+			client:   &http.Client{Timeout: time.Second * 10},
+		}
+	}
+}
+
+// GetBoundQueues returns queues bound to given exchange, only queues with environment prefix are returned
+// and the prefix is removed from their names
+func (t *RabbitMqTransport) GetBoundQueues(ctx context.Context, exchange string) ([]string, error) {
+	if t.management == nil {
+		return nil, ErrManagementNotConfigured
+	}
+
+	var bindings []managementBinding
+	err := t.management.get(ctx, fmt.Sprintf("/api/exchanges/%s/%s/bindings/source",
+		url.PathEscape(t.vhost), url.PathEscape(t.topology.name(exchange))), &bindings)
+	if err != nil {
+		return nil, err
+	}
+
+	queues := make([]string, 0, len(bindings))
+	for _, binding := range bindings {
+		if binding.DestinationType != "queue" || !strings.HasPrefix(binding.Destination, t.topology.Prefix) {
+			continue
+		}
+
+		queues = append(queues, strings.TrimPrefix(binding.Destination, t.topology.Prefix))
+	}
+
+	return queues, nil
+}
+
+func (m *rabbitMqManagement) get(ctx context.Context, path string, result any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, m.url+path, nil)
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(m.username, m.password)
+
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("management api %s responded with %d", path, resp.StatusCode)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(result)
+}
@@ -0,0 +1,43 @@
+package scheduler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestGetBoundQueues(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if user, password, _ := req.BasicAuth(); user != "guest" || password != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		if req.URL.EscapedPath() != "/api/exchanges/%2F/dev.timely-schedule-job/bindings/source" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		_, _ = w.Write([]byte(`[
+			{"destination": "dev.orders", "destination_type": "queue"},
+			{"destination": "prod.orders", "destination_type": "queue"},
+			{"destination": "dev.exchange", "destination_type": "exchange"}
+		]`))
+	}))
+	defer server.Close()
+
+	transport := &RabbitMqTransport{topology: TopologyConfig{Prefix: "dev."}, vhost: "/"}
+	WithManagement(server.URL, "guest", "secret")(transport)
+
+	result, err := transport.GetBoundQueues(context.Background(), string(JobScheduleExchange))
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	expected := []string{"orders"}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("expect result %+v, got %+v", expected, result)
+	}
+}
@@ -0,0 +1,189 @@
+package scheduler
+
+import (
+	"sort"
+	"sync"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+type topologyKind int
+
+// kinds are ordered the way entities have to be declared
+const (
+	exchangeEntity topologyKind = iota
+	queueEntity
+	bindingEntity
+)
+
+type queueBinding struct {
+	queue      string
+	exchange   string
+	routingKey string
+}
+
+// topologyEntry is queue, exchange or binding declared on broker, concurrent callers of the same entity
+// wait for single declaration
+type topologyEntry struct {
+	kind    topologyKind
+	name    string // queue or exchange name
+	binding queueBinding
+	checked bool // exchange managed outside of timely, declaration only checks that it exists
+	declare func(chann *amqp.Channel) error
+
+	done chan struct{}
+	err  error
+}
+
+// topologyRegistry remembers declared topology, so each entity is declared once and redeclared after reconnect,
+// entities removed from broker are invalidated and declared again on the next use
+type topologyRegistry struct {
+	mu      sync.Mutex
+	entries map[string]*topologyEntry
+}
+
+func newTopologyRegistry() *topologyRegistry {
+	return &topologyRegistry{entries: map[string]*topologyEntry{}}
+}
+
+func queueKey(queue string) string {
+	return "queue:" + queue
+}
+
+func exchangeKey(exchange string) string {
+	return "exchange:" + exchange
+}
+
+func bindingKey(binding queueBinding) string {
+	return "binding:" + binding.queue + ":" + binding.exchange + ":" + binding.routingKey
+}
+
+// ensure declares entity unless it has been already declared, failed declaration is not remembered
+func (r *topologyRegistry) ensure(key string, entry *topologyEntry, chann func() (*amqp.Channel, error)) error {
+	r.mu.Lock()
+	if existing, ok := r.entries[key]; ok {
+		r.mu.Unlock()
+		<-existing.done
+		return existing.err
+	}
+
+	entry.done = make(chan struct{})
+	r.entries[key] = entry
+	r.mu.Unlock()
+
+	entry.err = func() error {
+		ch, err := chann()
+		if err != nil {
+			return err
+		}
+
+		return entry.declare(ch)
+	}()
+	close(entry.done)
+
+	if entry.err != nil {
+		r.remove(key, entry)
+	}
+
+	return entry.err
+}
+
+func (r *topologyRegistry) remove(key string, entry *topologyEntry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.entries[key] == entry {
+		delete(r.entries, key)
+	}
+}
+
+func (r *topologyRegistry) isDeclared(key string) bool {
+	r.mu.Lock()
+	entry, ok := r.entries[key]
+	r.mu.Unlock()
+
+	if !ok {
+		return false
+	}
+
+	<-entry.done
+	return entry.err == nil
+}
+
+// invalidateQueue forgets queue with its bindings
+func (r *topologyRegistry) invalidateQueue(queue string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for key, entry := range r.entries {
+		if entry.kind == queueEntity && entry.name == queue ||
+			entry.kind == bindingEntity && entry.binding.queue == queue {
+			delete(r.entries, key)
+		}
+	}
+}
+
+// invalidateExchange forgets exchange with its bindings
+func (r *topologyRegistry) invalidateExchange(exchange string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for key, entry := range r.entries {
+		if entry.kind == exchangeEntity && entry.name == exchange ||
+			entry.kind == bindingEntity && entry.binding.exchange == exchange {
+			delete(r.entries, key)
+		}
+	}
+}
+
+// invalidateCheckedExchange forgets existence of exchange managed outside of timely, so it is checked again
+func (r *topologyRegistry) invalidateCheckedExchange(exchange string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if entry, ok := r.entries[exchangeKey(exchange)]; ok && entry.checked {
+		delete(r.entries, exchangeKey(exchange))
+	}
+}
+
+// invalidateRoute forgets queues bound with given routing key, message which could not be routed means
+// that they were removed from broker
+func (r *topologyRegistry) invalidateRoute(exchange, routingKey string) {
+	r.mu.Lock()
+	var queues []string
+	for _, entry := range r.entries {
+		if entry.kind == bindingEntity && entry.binding.exchange == exchange &&
+			entry.binding.routingKey == routingKey {
+			queues = append(queues, entry.binding.queue)
+		}
+	}
+	r.mu.Unlock()
+
+	for _, queue := range queues {
+		r.invalidateQueue(queue)
+	}
+}
+
+// declared returns successfully declared entities in order they have to be declared
+func (r *topologyRegistry) declared() []*topologyEntry {
+	r.mu.Lock()
+	entries := make([]*topologyEntry, 0, len(r.entries))
+	for _, entry := range r.entries {
+		entries = append(entries, entry)
+	}
+	r.mu.Unlock()
+
+	declared := make([]*topologyEntry, 0, len(entries))
+	for _, entry := range entries {
+		<-entry.done
+		if entry.err == nil {
+			declared = append(declared, entry)
+		}
+	}
+
+	sort.SliceStable(declared, func(i, j int) bool {
+		return declared[i].kind < declared[j].kind
+	})
+
+	return declared
+}
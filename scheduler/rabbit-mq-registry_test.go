@@ -0,0 +1,171 @@
+package scheduler
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+func noChannel() (*amqp.Channel, error) {
+	return nil, nil
+}
+
+func TestTopologyRegistryDeclaresOnce(t *testing.T) {
+	registry := newTopologyRegistry()
+
+	var declarations atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			_ = registry.ensure(queueKey("jobs"), &topologyEntry{
+				kind: queueEntity,
+				name: "jobs",
+				declare: func(chann *amqp.Channel) error {
+					declarations.Add(1)
+					return nil
+				},
+			}, noChannel)
+		}()
+	}
+	wg.Wait()
+
+	if declarations.Load() != 1 {
+		t.Errorf("expect result %+v, got %+v", 1, declarations.Load())
+	}
+}
+
+func TestTopologyRegistryForgetsFailedDeclaration(t *testing.T) {
+	registry := newTopologyRegistry()
+	declareErr := errors.New("declare failed")
+
+	err := registry.ensure(queueKey("jobs"), &topologyEntry{
+		kind:    queueEntity,
+		name:    "jobs",
+		declare: func(chann *amqp.Channel) error { return declareErr },
+	}, noChannel)
+
+	if !errors.Is(err, declareErr) {
+		t.Errorf("expect error %+v, got %+v", declareErr, err)
+	}
+
+	if registry.isDeclared(queueKey("jobs")) {
+		t.Errorf("expect failed queue not to be declared")
+	}
+}
+
+func TestTopologyRegistryInvalidation(t *testing.T) {
+	tests := map[string]struct {
+		invalidate func(registry *topologyRegistry)
+
+		expectQueue    bool
+		expectExchange bool
+		expectBinding  bool
+	}{
+		"invalidate_queue": {
+			invalidate:     func(registry *topologyRegistry) { registry.invalidateQueue("jobs") },
+			expectExchange: true,
+		},
+		"invalidate_exchange": {
+			invalidate:  func(registry *topologyRegistry) { registry.invalidateExchange("schedule") },
+			expectQueue: true,
+		},
+		"invalidate_route": {
+			invalidate:     func(registry *topologyRegistry) { registry.invalidateRoute("schedule", "jobs") },
+			expectExchange: true,
+		},
+		"invalidate_other_route": {
+			invalidate:     func(registry *topologyRegistry) { registry.invalidateRoute("schedule", "other") },
+			expectQueue:    true,
+			expectExchange: true,
+			expectBinding:  true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			registry := newTopologyRegistry()
+			binding := queueBinding{queue: "jobs", exchange: "schedule", routingKey: "jobs"}
+			declare := func(chann *amqp.Channel) error { return nil }
+
+			_ = registry.ensure(bindingKey(binding), &topologyEntry{kind: bindingEntity, name: "jobs",
+				binding: binding, declare: declare}, noChannel)
+			_ = registry.ensure(queueKey("jobs"), &topologyEntry{kind: queueEntity, name: "jobs",
+				declare: declare}, noChannel)
+			_ = registry.ensure(exchangeKey("schedule"), &topologyEntry{kind: exchangeEntity, name: "schedule",
+				declare: declare}, noChannel)
+
+			test.invalidate(registry)
+
+			if registry.isDeclared(queueKey("jobs")) != test.expectQueue {
+				t.Errorf("expect queue declared %v", test.expectQueue)
+			}
+
+			if registry.isDeclared(exchangeKey("schedule")) != test.expectExchange {
+				t.Errorf("expect exchange declared %v", test.expectExchange)
+			}
+
+			if registry.isDeclared(bindingKey(binding)) != test.expectBinding {
+				t.Errorf("expect binding declared %v", test.expectBinding)
+			}
+		})
+	}
+}
+
+func TestTopologyRegistryDeclaredOrder(t *testing.T) {
+	registry := newTopologyRegistry()
+	declare := func(chann *amqp.Channel) error { return nil }
+	binding := queueBinding{queue: "jobs", exchange: "schedule", routingKey: "jobs"}
+
+	_ = registry.ensure(bindingKey(binding), &topologyEntry{kind: bindingEntity, binding: binding,
+		declare: declare}, noChannel)
+	_ = registry.ensure(queueKey("jobs"), &topologyEntry{kind: queueEntity, name: "jobs",
+		declare: declare}, noChannel)
+	_ = registry.ensure(exchangeKey("schedule"), &topologyEntry{kind: exchangeEntity, name: "schedule",
+		declare: declare}, noChannel)
+
+	entries := registry.declared()
+
+	expected := []topologyKind{exchangeEntity, queueEntity, bindingEntity}
+	for i, entry := range entries {
+		if entry.kind != expected[i] {
+			t.Errorf("expect result %+v, got %+v", expected[i], entry.kind)
+		}
+	}
+}
+
+func TestTopologyRegistryInvalidateCheckedExchange(t *testing.T) {
+	tests := map[string]struct {
+		checked bool
+
+		expectDeclared bool
+	}{
+		"checked_exchange": {
+			checked:        true,
+			expectDeclared: false,
+		},
+		"declared_exchange": {
+			checked:        false,
+			expectDeclared: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			registry := newTopologyRegistry()
+			_ = registry.ensure(exchangeKey("orders"), &topologyEntry{kind: exchangeEntity, name: "orders",
+				checked: test.checked, declare: func(chann *amqp.Channel) error { return nil }}, noChannel)
+
+			registry.invalidateCheckedExchange("orders")
+
+			if registry.isDeclared(exchangeKey("orders")) != test.expectDeclared {
+				t.Errorf("expect result %+v, got %+v", test.expectDeclared, !test.expectDeclared)
+			}
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package scheduler
+
+import (
+	"errors"
+	"slices"
+	"strconv"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+type QueueType string
+
+const (
+	ClassicQueue QueueType = "classic"
+	QuorumQueue  QueueType = "quorum"
+	StreamQueue  QueueType = "stream"
+)
+
+var (
+	ErrInvalidQueueType = &Error{
+		Code: "INVALID_QUEUE_TYPE",
+		Msg:  "queue type has to be classic, quorum or stream"}
+	ErrInvalidExchangeType = &Error{
+		Code: "INVALID_EXCHANGE_TYPE",
+		Msg:  "exchange type has to be direct, topic, fanout or headers"}
+	ErrStreamDeadLetter = &Error{
+		Code: "STREAM_DEAD_LETTER",
+		Msg:  "stream queues do not support dead letter exchange"}
+)
+
+// TopologyConfig describes how queues and exchanges are declared, all names are prefixed with Prefix,
+// so multiple environments can share single broker or vhost
+type TopologyConfig struct {
+	Prefix             string
+	Vhost              string // overrides vhost of connection string
+	QueueType          QueueType
+	ExchangeType       string
+	DeadLetterExchange string        // receives rejected and expired messages of job queues
+	MessageTTL         time.Duration // published messages expire when not consumed in time
+}
+
+func defaultTopology() TopologyConfig {
+	return TopologyConfig{
+		QueueType:    ClassicQueue,
+		ExchangeType: amqp.ExchangeDirect,
+	}
+}
+
+// WithTopology sets declaration arguments of queues and exchanges, empty fields keep defaults
+func WithTopology(config TopologyConfig) RabbitMqOption {
+	return func(t *RabbitMqTransport) {
+		if config.QueueType == "" {
+			config.QueueType = ClassicQueue
+		}
+
+		if config.ExchangeType == "" {
+			config.ExchangeType = amqp.ExchangeDirect
+		}
+
+		t.topology = config
+	}
+}
+
+func (c TopologyConfig) Validate() error {
+	var err error
+
+	if !slices.Contains([]QueueType{ClassicQueue, QuorumQueue, StreamQueue}, c.QueueType) {
+		err = errors.Join(err, ErrInvalidQueueType)
+	}
+
+	if !slices.Contains([]string{amqp.ExchangeDirect, amqp.ExchangeTopic, amqp.ExchangeFanout,
+		amqp.ExchangeHeaders}, c.ExchangeType) {
+		err = errors.Join(err, ErrInvalidExchangeType)
+	}
+
+	if c.QueueType == StreamQueue && c.DeadLetterExchange != "" {
+		err = errors.Join(err, ErrStreamDeadLetter)
+	}
+
+	return err
+}
+
+// name returns name of queue or exchange with environment prefix, default exchange is kept as is
+func (c TopologyConfig) name(name string) string {
+	if name == "" {
+		return name
+	}
+
+	return c.Prefix + name
+}
+
+func (c TopologyConfig) queueArgs() amqp.Table {
+	args := amqp.Table{}
+
+	if c.QueueType != ClassicQueue {
+		args[amqp.QueueTypeArg] = string(c.QueueType)
+	}
+
+	if c.DeadLetterExchange != "" {
+		args["x-dead-letter-exchange"] = c.name(c.DeadLetterExchange)
+	}
+
+	return args
+}
+
+// delayQueueArgs returns arguments of queue which holds failed messages for delay and dead letters them back,
+// streams can't dead letter so classic queue is used instead
+func (c TopologyConfig) delayQueueArgs(queue string, delay time.Duration) amqp.Table {
+	args := amqp.Table{
+		amqp.QueueMessageTTLArg:     delay.Milliseconds(),
+		"x-dead-letter-exchange":    "",
+		"x-dead-letter-routing-key": queue,
+	}
+
+	if c.QueueType == QuorumQueue {
+		args[amqp.QueueTypeArg] = string(QuorumQueue)
+	}
+
+	return args
+}
+
+// expiration returns per message TTL in format expected by broker
+func (c TopologyConfig) expiration() string {
+	if c.MessageTTL <= 0 {
+		return ""
+	}
+
+	return strconv.FormatInt(c.MessageTTL.Milliseconds(), 10)
+}
@@ -0,0 +1,103 @@
+package scheduler
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+func TestTopologyConfigValidate(t *testing.T) {
+	tests := map[string]struct {
+		config TopologyConfig
+		err    error
+	}{
+		"default_topology": {
+			config: defaultTopology(),
+		},
+		"quorum_queues_with_dead_letter": {
+			config: TopologyConfig{QueueType: QuorumQueue, ExchangeType: amqp.ExchangeTopic,
+				DeadLetterExchange: "dlx"},
+		},
+		"unknown_queue_type": {
+			config: TopologyConfig{QueueType: "lazy", ExchangeType: amqp.ExchangeDirect},
+			err:    ErrInvalidQueueType,
+		},
+		"unknown_exchange_type": {
+			config: TopologyConfig{QueueType: ClassicQueue, ExchangeType: "x-delayed"},
+			err:    ErrInvalidExchangeType,
+		},
+		"stream_with_dead_letter": {
+			config: TopologyConfig{QueueType: StreamQueue, ExchangeType: amqp.ExchangeDirect,
+				DeadLetterExchange: "dlx"},
+			err: ErrStreamDeadLetter,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := test.config.Validate()
+
+			if !errors.Is(err, test.err) {
+				t.Errorf("expect error %+v, got %+v", test.err, err)
+			}
+		})
+	}
+}
+
+func TestTopologyConfigQueueArgs(t *testing.T) {
+	tests := map[string]struct {
+		config TopologyConfig
+		result amqp.Table
+	}{
+		"classic_queue": {
+			config: defaultTopology(),
+			result: amqp.Table{},
+		},
+		"prefixed_dead_letter_exchange": {
+			config: TopologyConfig{Prefix: "dev.", QueueType: ClassicQueue, DeadLetterExchange: "dlx"},
+			result: amqp.Table{"x-dead-letter-exchange": "dev.dlx"},
+		},
+		"quorum_queue": {
+			config: TopologyConfig{QueueType: QuorumQueue},
+			result: amqp.Table{amqp.QueueTypeArg: "quorum"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			result := test.config.queueArgs()
+
+			if !reflect.DeepEqual(result, test.result) {
+				t.Errorf("expect result %+v, got %+v", test.result, result)
+			}
+		})
+	}
+}
+
+func TestTopologyConfigName(t *testing.T) {
+	config := TopologyConfig{Prefix: "dev."}
+
+	if result := config.name("jobs"); result != "dev.jobs" {
+		t.Errorf("expect result %+v, got %+v", "dev.jobs", result)
+	}
+
+	// default exchange can't be prefixed
+	if result := config.name(""); result != "" {
+		t.Errorf("expect result %+v, got %+v", "", result)
+	}
+}
+
+func TestTopologyConfigExpiration(t *testing.T) {
+	config := TopologyConfig{MessageTTL: time.Minute}
+
+	if result := config.expiration(); result != "60000" {
+		t.Errorf("expect result %+v, got %+v", "60000", result)
+	}
+
+	if result := defaultTopology().expiration(); result != "" {
+		t.Errorf("expect result %+v, got %+v", "", result)
+	}
+}
@@ -4,161 +4,230 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
-	"slices"
+	"fmt"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/google/uuid"
 	amqp "github.com/rabbitmq/amqp091-go"
 	"go.uber.org/zap"
 )
 
 const (
 	TimelyAdminChannel string = "timely-admin-channel"
-	WorkerCount        int    = 20
 )
 
 type AsyncTransportDriver interface {
 	Publish(ctx context.Context, exchange, routingKey string, message any) error
-	Subscribe(ctx context.Context, queue string, handle func(message []byte) error) error
-	CreateQueue(queue string) error
+	Subscribe(ctx context.Context, queue string, handle func(message []byte) error, opts ...SubscribeOption) error
+	// Prepare makes sure that job of schedule can be published, it is called before each job run
+	Prepare(ctx context.Context, schedule *Schedule) error
+	// Route returns exchange and routing key job of schedule is published with
+	Route(schedule *Schedule) (string, string)
 	DeleteQueue(queue string) error
-	CreateExchange(exchange string) error
-	BindQueue(queue, exchange, routingKey string) error
 }
 
 type RabbitMqTransport struct {
-	rcvConnection *recoverableConnection
-	channels      *sync.Map
+	rcvConnection   *recoverableConnection
+	channels        *sync.Map
+	confirmChannels *sync.Map
+	confirmTimeout  time.Duration
+	subscribeOpts   []SubscribeOption
+	topology        TopologyConfig
+	vhost           string
+	management      *rabbitMqManagement
 
-	declaredQueues    []string
-	declaredExchanges []string
+	channelsMu        sync.Mutex
+	confirmChannelsMu sync.Mutex
+
+	// topology is redeclared after reconnect, broker may have lost non durable state
+	registry *topologyRegistry
 
 	logger *zap.SugaredLogger
 }
 
-type recoverableConnection struct {
-	connection *amqp.Connection
-	attempt    int
+type RabbitMqOption func(*RabbitMqTransport)
+
+// WithConfirmTimeout sets how long publish waits for broker confirmation
+func WithConfirmTimeout(timeout time.Duration) RabbitMqOption {
+	return func(t *RabbitMqTransport) {
+		if timeout > 0 {
+			t.confirmTimeout = timeout
+		}
+	}
 }
 
-func NewRabbitMqTransport(url string, logger *zap.SugaredLogger) (*RabbitMqTransport, error) {
-	conn, err := establishRcvConn(url, logger)
+// confirmChannel is channel in confirm mode, publishes are serialized so basic.return
+// of the message is always received before its confirmation is checked
+type confirmChannel struct {
+	mu      sync.Mutex
+	channel *amqp.Channel
+	returns chan amqp.Return
+}
+
+const defaultConfirmTimeout = time.Second * 5
+
+var (
+	ErrMessageUnroutable = &Error{
+		Code: "MESSAGE_UNROUTABLE",
+		Msg:  "message could not be routed to any queue"}
+	ErrPublishNacked = &Error{
+		Code: "PUBLISH_NACKED",
+		Msg:  "message was rejected by broker"}
+	ErrPublishConfirmTimeout = &Error{
+		Code: "PUBLISH_CONFIRM_TIMEOUT",
+		Msg:  "broker did not confirm message in time"}
+)
+
+func NewRabbitMqTransport(url string, logger *zap.SugaredLogger, opts ...RabbitMqOption) (*RabbitMqTransport, error) {
+	transport := &RabbitMqTransport{
+		channels:        &sync.Map{},
+		confirmChannels: &sync.Map{},
+		confirmTimeout:  defaultConfirmTimeout,
+		topology:        defaultTopology(),
+		registry:        newTopologyRegistry(),
+		logger:          logger,
+	}
+
+	for _, opt := range opts {
+		opt(transport)
+	}
+
+	if err := transport.topology.Validate(); err != nil {
+		return nil, err
+	}
+
+	uri, err := amqp.ParseURI(url)
 	if err != nil {
 		return nil, err
 	}
 
-	channelStore := &sync.Map{}
-	transport := &RabbitMqTransport{
-		rcvConnection: conn,
-		channels:      channelStore,
-		logger:        logger,
+	transport.vhost = uri.Vhost
+	if transport.topology.Vhost != "" {
+		transport.vhost = transport.topology.Vhost
+	}
+
+	conn, err := establishRcvConn(url, transport.topology.Vhost, logger, transport.redeclareTopology)
+	if err != nil {
+		return nil, err
+	}
+	transport.rcvConnection = conn
+
+	if err = transport.createDeadLetterExchange(); err != nil {
+		return nil, err
 	}
 
 	return transport, nil
 }
 
-func establishRcvConn(url string, logger *zap.SugaredLogger) (*recoverableConnection, error) {
-	conn, err := connect(url)
+// createDeadLetterExchange declares dead letter exchange with queue keeping all dead lettered messages
+func (t *RabbitMqTransport) createDeadLetterExchange() error {
+	if t.topology.DeadLetterExchange == "" {
+		return nil
+	}
 
-	if err != nil {
-		logger.Error(err)
-		return nil, err
+	name := t.topology.name(t.topology.DeadLetterExchange)
+	if err := t.createExchange(name, amqp.ExchangeFanout); err != nil {
+		return err
 	}
-	logger.Info("connected to rabbitmq")
 
-	rcvConn := &recoverableConnection{connection: conn}
-	go func(rConn *recoverableConnection, logger *zap.SugaredLogger) {
-		closeErr := <-rConn.connection.NotifyClose(make(chan *amqp.Error))
-		if closeErr != nil {
-			logger.Errorf("rabbitmq non graceful connection close - %s", closeErr.Error())
-		} else {
-			logger.Warnln("rabbitmq graceful connection close")
-		}
+	args := amqp.Table{}
+	if t.topology.QueueType != ClassicQueue {
+		args[amqp.QueueTypeArg] = string(t.topology.QueueType)
+	}
 
-		for {
-			rConn.attempt++
-			conn, err := connect(url)
-			if err != nil {
-				logger.Error(err)
-				time.Sleep(time.Second)
-				continue
-			}
-			rConn.connection = conn
-			break
-		}
-		logger.Infof("reconnected to rabbitmq after %d attempt", rConn.attempt)
-	}(rcvConn, logger)
+	if err := t.createQueue(name, args); err != nil {
+		return err
+	}
 
-	return rcvConn, nil
+	return t.bindQueue(name, name, "")
 }
 
-func connect(url string) (*amqp.Connection, error) {
-	conn, err := amqp.DialConfig(url, amqp.Config{
-		Properties: amqp.Table{
-			"product":  "timely",
-			"version":  "v0.1.0",
-			"platform": "golang",
-		}})
+// State returns state of connection to broker
+func (t *RabbitMqTransport) State() ConnectionState {
+	return t.rcvConnection.State()
+}
 
-	if err != nil {
-		return nil, err
+// Health reports error while connection to broker is being recovered
+func (t *RabbitMqTransport) Health(ctx context.Context) error {
+	if t.State() != Connected {
+		return ErrConnectionUnavailable
 	}
 
-	return conn, nil
+	return nil
 }
 
-func (t *RabbitMqTransport) getChannel(key string) (*amqp.Channel, error) {
-	if channel, exists := t.channels.Load(key); exists {
-		return channel.(*amqp.Channel), nil
+// redeclareTopology drops channels of lost connection and declares known queues, exchanges and bindings again
+func (t *RabbitMqTransport) redeclareTopology() {
+	t.channelsMu.Lock()
+	t.channels.Range(func(key, _ any) bool {
+		t.channels.Delete(key)
+		return true
+	})
+	t.channelsMu.Unlock()
+
+	t.confirmChannelsMu.Lock()
+	t.confirmChannels.Range(func(key, _ any) bool {
+		t.confirmChannels.Delete(key)
+		return true
+	})
+	t.confirmChannelsMu.Unlock()
+
+	entries := t.registry.declared()
+	for _, entry := range entries {
+		// failed declaration closes channel, so channel is taken for each entity
+		chann, err := t.getChannel(TimelyAdminChannel)
+		if err != nil {
+			t.logger.Errorf("unable to redeclare rabbitmq topology - %v", err)
+			return
+		}
+
+		if err = entry.declare(chann); err != nil {
+			t.logger.Errorf("redeclaring %s error - %v", entry.name, err)
+			t.invalidate(entry)
+		}
 	}
 
-	channel, err := t.createChannel(key)
-	if err != nil {
-		return nil, err
+	t.logger.Infof("redeclared %d exchanges, queues and bindings", len(entries))
+}
+
+func (t *RabbitMqTransport) invalidate(entry *topologyEntry) {
+	switch entry.kind {
+	case queueEntity:
+		t.registry.invalidateQueue(entry.name)
+	case exchangeEntity:
+		t.registry.invalidateExchange(entry.name)
+	case bindingEntity:
+		t.registry.invalidateQueue(entry.binding.queue)
 	}
+}
 
-	t.channels.Store(key, channel)
-	return channel, nil
+func (t *RabbitMqTransport) adminChannel() (*amqp.Channel, error) {
+	return t.getChannel(TimelyAdminChannel)
 }
 
-func (t *RabbitMqTransport) createChannel(key string) (*amqp.Channel, error) {
-	//t.logger.Infof("creating new channel for %s", key)
-	channel, err := t.rcvConnection.connection.Channel()
+// getChannel returns shared channel for given key, channel closed by broker is reopened
+func (t *RabbitMqTransport) getChannel(key string) (*amqp.Channel, error) {
+	t.channelsMu.Lock()
+	defer t.channelsMu.Unlock()
+
+	if channel, exists := t.channels.Load(key); exists && !channel.(*amqp.Channel).IsClosed() {
+		return channel.(*amqp.Channel), nil
+	}
+
+	channel, err := t.rcvConnection.channel()
 	if err != nil {
 		t.logger.Errorf("unable to open connection channel %s", err.Error())
 		return nil, err
 	}
 
-	go func(channel *amqp.Channel, key string) {
-		closeErr := <-channel.NotifyClose(make(chan *amqp.Error))
-		if closeErr != nil {
-			t.logger.Errorf("rabbitmq non graceful channel close - %s", closeErr.Error())
-		} else {
-			t.logger.Warnln("rabbitmq graceful channel close")
-		}
-
-		for {
-			// wait for reconnection
-			if t.rcvConnection.connection.IsClosed() {
-				continue
-			}
-
-			channel, err := t.rcvConnection.connection.Channel()
-			if err != nil {
-				t.logger.Error(err)
-				time.Sleep(time.Second)
-				continue
-			}
-			t.channels.Store(key, channel)
-			break
-		}
-		t.logger.Infof("created a new %s channel", key)
-	}(channel, key)
-
+	t.channels.Store(key, channel)
 	return channel, nil
 }
 
+// Publish sends mandatory message and waits for broker confirmation, message which can't be routed
+// to any queue or is nacked by broker is reported as error
 func (t *RabbitMqTransport) Publish(ctx context.Context, exchange, routingKey string, message any) error {
 	data, err := json.Marshal(message)
 	if err != nil {
@@ -166,148 +235,260 @@ func (t *RabbitMqTransport) Publish(ctx context.Context, exchange, routingKey st
 	}
 
 	msg := amqp.Publishing{
+		MessageId:    uuid.NewString(),
 		DeliveryMode: amqp.Persistent,
 		Timestamp:    time.Now(),
+		Expiration:   t.topology.expiration(),
 		ContentType:  ApplicationJson,
 		Body:         data,
 		//CorrelationId   string // correlation identifier, might be usefull
 	}
 
-	chann, err := t.getChannel(exchange)
+	// routing key of default exchange is queue name, so it is prefixed as well
+	if exchange == "" {
+		routingKey = t.topology.name(routingKey)
+	}
+	exchange = t.topology.name(exchange)
+
+	chann, err := t.getConfirmChannel(exchange)
 	if err != nil {
 		t.logger.Errorf("get channel error - %v", err)
 		return err
 	}
 
-	err = chann.PublishWithContext(ctx, exchange,
-		routingKey, false, false, msg)
+	chann.mu.Lock()
+	defer chann.mu.Unlock()
 
+	confirmation, err := chann.channel.PublishWithDeferredConfirmWithContext(ctx, exchange,
+		routingKey, true, false, msg)
 	if err != nil {
 		t.logger.Errorf("publish error - %v", err)
 		return err
 	}
 
+	confirmCtx, cancel := context.WithTimeout(ctx, t.confirmTimeout)
+	defer cancel()
+
+	acked, err := confirmation.WaitContext(confirmCtx)
+	if err != nil {
+		return errors.Join(ErrPublishConfirmTimeout, err)
+	}
+
+	// basic.return is delivered before confirmation of the same message
+	if returned, ok := chann.returned(msg.MessageId); ok {
+		// queue bound with the routing key was most likely removed, it is declared again on the next use,
+		// bindings of exchange managed outside of timely are unknown, so its existence is checked again
+		t.registry.invalidateRoute(exchange, routingKey)
+		t.registry.invalidateCheckedExchange(exchange)
+		return errors.Join(ErrMessageUnroutable, fmt.Errorf("%s - %s", returned.ReplyText, routingKey))
+	}
+
+	if !acked {
+		return ErrPublishNacked
+	}
+
 	return nil
 }
 
-func (t *RabbitMqTransport) Subscribe(ctx context.Context, queue string, handle func(message []byte) error) error {
-	err := t.CreateQueue(queue)
-	if err != nil {
-		return err
+func (t *RabbitMqTransport) getConfirmChannel(key string) (*confirmChannel, error) {
+	t.confirmChannelsMu.Lock()
+	defer t.confirmChannelsMu.Unlock()
+
+	if chann, exists := t.confirmChannels.Load(key); exists && !chann.(*confirmChannel).channel.IsClosed() {
+		return chann.(*confirmChannel), nil
 	}
 
-	chann, err := t.getChannel(queue)
+	channel, err := t.rcvConnection.channel()
 	if err != nil {
-		return err
+		t.logger.Errorf("unable to open connection channel %s", err.Error())
+		return nil, err
 	}
 
-	delivery, err := chann.ConsumeWithContext(ctx, queue, "", false,
-		false, false, false, amqp.Table{})
-	if err != nil {
-		t.logger.Errorf("error during rabbitmq consume - %v", err)
-		return err
+	if err = channel.Confirm(false); err != nil {
+		return nil, err
+	}
+
+	chann := &confirmChannel{
+		channel: channel,
+		returns: channel.NotifyReturn(make(chan amqp.Return, 16)),
 	}
 
-	sem := make(chan struct{}, WorkerCount)
+	// publish to exchange removed from broker closes channel, exchange is declared again on the next use
+	go func(closed chan *amqp.Error) {
+		if closeErr := <-closed; closeErr != nil && closeErr.Code == amqp.NotFound {
+			t.logger.Warnf("publish channel %s closed - %v", key, closeErr)
+			t.registry.invalidateExchange(key)
+		}
+	}(channel.NotifyClose(make(chan *amqp.Error, 1)))
+
+	// previous channel is closed, it is replaced with the new one
+	t.confirmChannels.Store(key, chann)
+
+	return chann, nil
+}
 
+// returned finds basic.return of given message, returns of previously timed out publishes are dropped
+func (c *confirmChannel) returned(messageId string) (amqp.Return, bool) {
 	for {
-		sem <- struct{}{}
-		go func(delivery amqp.Delivery, handle func(message []byte) error) {
-			err := handle(delivery.Body)
-
-			if err != nil {
-				t.logger.Errorf("error during consumer action processing - %v", err)
-
-				err = delivery.Nack(false, false)
-				if err != nil {
-					t.logger.Errorf("error during nack - %v", err)
-				}
-			} else {
-				err = delivery.Ack(false)
-				if err != nil {
-					t.logger.Errorf("error during ack - %v", err)
-				}
+		select {
+		case returned := <-c.returns:
+			if returned.MessageId == messageId {
+				return returned, true
 			}
-
-			<-sem
-		}(<-delivery, handle)
+		default:
+			return amqp.Return{}, false
+		}
 	}
 }
 
+// IsDispatchFailure reports if publish error means that broker refused message, such failures are not
+// retried by outbox but go through retry policy of schedule, missing confirm is retried as message
+// may have been delivered
+func IsDispatchFailure(err error) bool {
+	return errors.Is(err, ErrMessageUnroutable) || errors.Is(err, ErrPublishNacked)
+}
+
+// CreateQueue declares durable queue of configured type, its name is prefixed
 func (t *RabbitMqTransport) CreateQueue(queue string) error {
-	queue = strings.Trim(queue, " ")
+	return t.createQueue(t.topology.name(strings.Trim(queue, " ")), t.topology.queueArgs())
+}
 
-	if slices.Contains(t.declaredQueues, queue) {
-		return nil
-	}
+func (t *RabbitMqTransport) createQueue(queue string, args amqp.Table) error {
+	err := t.registry.ensure(queueKey(queue), &topologyEntry{
+		kind: queueEntity,
+		name: queue,
+		declare: func(chann *amqp.Channel) error {
+			return declareQueue(chann, queue, args)
+		},
+	}, t.adminChannel)
 
-	chann, err := t.getChannel(TimelyAdminChannel)
 	if err != nil {
-		return err
+		t.logger.Errorf("creating queue error %s - %v", queue, err)
 	}
 
-	createdQueue, err := chann.QueueDeclare(queue, true, false, false,
-		false, amqp.Table{})
+	return err
+}
+
+// CreateExchange declares durable exchange of configured type, its name is prefixed
+func (t *RabbitMqTransport) CreateExchange(exchange string) error {
+	return t.createExchange(t.topology.name(strings.Trim(exchange, " ")), t.topology.ExchangeType)
+}
+
+func (t *RabbitMqTransport) createExchange(exchange, kind string) error {
+	err := t.registry.ensure(exchangeKey(exchange), &topologyEntry{
+		kind: exchangeEntity,
+		name: exchange,
+		declare: func(chann *amqp.Channel) error {
+			return declareExchange(chann, exchange, kind)
+		},
+	}, t.adminChannel)
 
 	if err != nil {
-		t.logger.Infof("creating queue error %s - %v", string(JobStatusExchange), err)
-		return err
+		t.logger.Errorf("creating exchange error %s - %v", exchange, err)
 	}
 
-	t.declaredQueues = append(t.declaredQueues, createdQueue.Name)
-
-	return nil
+	return err
 }
 
-func (t *RabbitMqTransport) CreateExchange(exchange string) error {
-	exchange = strings.Trim(exchange, " ")
+// BindQueue binds queue to exchange, binding is made once and then remembered
+func (t *RabbitMqTransport) BindQueue(queue, exchange, routingKey string) error {
+	return t.bindQueue(t.topology.name(queue), t.topology.name(exchange), routingKey)
+}
 
-	if slices.Contains(t.declaredExchanges, exchange) {
-		return nil
+func (t *RabbitMqTransport) bindQueue(queue, exchange, routingKey string) error {
+	if !t.registry.isDeclared(exchangeKey(exchange)) {
+		return errors.New("exchange has not beed declared")
 	}
 
-	chann, err := t.getChannel(TimelyAdminChannel)
-	if err != nil {
-		return err
+	if !t.registry.isDeclared(queueKey(queue)) {
+		return errors.New("queue has not beed declared")
 	}
 
-	err = chann.ExchangeDeclare(exchange, "direct", true, false,
-		false, false, amqp.Table{})
+	binding := queueBinding{queue: queue, exchange: exchange, routingKey: routingKey}
+	err := t.registry.ensure(bindingKey(binding), &topologyEntry{
+		kind:    bindingEntity,
+		name:    queue,
+		binding: binding,
+		declare: func(chann *amqp.Channel) error {
+			return chann.QueueBind(queue, routingKey, exchange, false, amqp.Table{})
+		},
+	}, t.adminChannel)
+
 	if err != nil {
-		t.logger.Errorf("creating exchange error %s - %v", string(JobScheduleExchange), err)
-		return err
+		t.logger.Errorf("exchange %s queue %s with routing key %s binding error - %v", exchange, queue, routingKey, err)
+
+		// queue or exchange was removed from broker after it was declared
+		var amqpErr *amqp.Error
+		if errors.As(err, &amqpErr) && amqpErr.Code == amqp.NotFound {
+			t.registry.invalidateQueue(queue)
+			t.registry.invalidateExchange(exchange)
+		}
 	}
 
-	t.declaredExchanges = append(t.declaredExchanges, exchange)
+	return err
+}
+
+func declareQueue(chann *amqp.Channel, queue string, args amqp.Table) error {
+	_, err := chann.QueueDeclare(queue, true, false, false,
+		false, args)
 
-	return nil
+	return err
 }
 
-func (t *RabbitMqTransport) BindQueue(queue, exchange, routingKey string) error {
-	if !slices.Contains(t.declaredExchanges, exchange) {
-		return errors.New("exchange has not beed declared")
-	}
+func declareExchange(chann *amqp.Channel, exchange, kind string) error {
+	return chann.ExchangeDeclare(exchange, kind, true, false,
+		false, false, amqp.Table{})
+}
 
-	if !slices.Contains(t.declaredQueues, queue) {
-		return errors.New("queue has not beed declared")
+// Prepare declares queue for the job, declarations are idempotent, custom exchange is managed
+// outside of timely so only its existence is checked
+func (t *RabbitMqTransport) Prepare(ctx context.Context, schedule *Schedule) error {
+	if !schedule.UsesJobQueue() {
+		exchange, _ := schedule.Destination()
+		return t.CheckExchange(exchange)
 	}
 
-	chann, err := t.getChannel(TimelyAdminChannel)
+	err := t.CreateQueue(schedule.Job.Slug)
 	if err != nil {
 		return err
 	}
 
-	err = chann.QueueBind(queue, routingKey, exchange, false, amqp.Table{})
+	return t.BindQueue(schedule.Job.Slug, string(JobScheduleExchange), schedule.Job.Slug)
+}
 
+func (t *RabbitMqTransport) Route(schedule *Schedule) (string, string) {
+	return schedule.Destination()
+}
+
+// CheckExchange verifies that exchange exists, exchanges managed outside of timely are not declared,
+// existing exchange is remembered until publish to it fails
+func (t *RabbitMqTransport) CheckExchange(exchange string) error {
+	exchange = t.topology.name(exchange)
+
+	return t.registry.ensure(exchangeKey(exchange), &topologyEntry{
+		kind:    exchangeEntity,
+		name:    exchange,
+		checked: true,
+		declare: func(*amqp.Channel) error {
+			return t.checkExchange(exchange)
+		},
+	}, t.adminChannel)
+}
+
+// checkExchange declares exchange passively, failed passive declaration closes channel, so dedicated one is used
+func (t *RabbitMqTransport) checkExchange(exchange string) error {
+	chann, err := t.rcvConnection.channel()
 	if err != nil {
-		t.logger.Errorf("exchange %s queue %s with routing key %s binding error - %v", exchange, queue, routingKey, err)
 		return err
 	}
+	defer chann.Close()
 
-	return nil
+	return chann.ExchangeDeclarePassive(exchange, "", true, false, false, false, amqp.Table{})
 }
 
 func (t *RabbitMqTransport) DeleteQueue(queue string) error {
+	queue = t.topology.name(queue)
+
 	channel, err := t.getChannel(TimelyAdminChannel)
 	if err != nil {
 		return err
@@ -318,5 +499,8 @@ func (t *RabbitMqTransport) DeleteQueue(queue string) error {
 		return err
 	}
 
+	// deleted queue must not be redeclared after reconnect
+	t.registry.invalidateQueue(queue)
+
 	return nil
 }
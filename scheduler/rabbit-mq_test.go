@@ -0,0 +1,81 @@
+package scheduler
+
+import (
+	"sync"
+	"testing"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"go.uber.org/zap"
+)
+
+func TestConfirmChannelReturned(t *testing.T) {
+	tests := map[string]struct {
+		returns  []amqp.Return
+		returned bool
+	}{
+		"no_return": {
+			returns:  nil,
+			returned: false,
+		},
+		"returned": {
+			returns:  []amqp.Return{{MessageId: "message", ReplyText: "NO_ROUTE"}},
+			returned: true,
+		},
+		"stale_returns_dropped": {
+			returns: []amqp.Return{
+				{MessageId: "timed-out", ReplyText: "NO_ROUTE"},
+				{MessageId: "message", ReplyText: "NO_ROUTE"},
+			},
+			returned: true,
+		},
+		"other_message_returned": {
+			returns:  []amqp.Return{{MessageId: "timed-out", ReplyText: "NO_ROUTE"}},
+			returned: false,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			chann := &confirmChannel{returns: make(chan amqp.Return, len(test.returns))}
+			for _, returned := range test.returns {
+				chann.returns <- returned
+			}
+
+			returned, ok := chann.returned("message")
+			if ok != test.returned {
+				t.Errorf("expect result %+v, got %+v", test.returned, ok)
+			}
+
+			if ok && returned.MessageId != "message" {
+				t.Errorf("expect result %+v, got %+v", "message", returned.MessageId)
+			}
+
+			if len(chann.returns) != 0 {
+				t.Errorf("expect result %+v, got %+v", 0, len(chann.returns))
+			}
+		})
+	}
+}
+
+func TestRedeclareTopologyDropsChannels(t *testing.T) {
+	transport := &RabbitMqTransport{
+		channels:        &sync.Map{},
+		confirmChannels: &sync.Map{},
+		registry:        newTopologyRegistry(),
+		logger:          zap.NewNop().Sugar(),
+	}
+	transport.channels.Store(TimelyAdminChannel, &amqp.Channel{})
+	transport.confirmChannels.Store("exchange", &confirmChannel{channel: &amqp.Channel{}})
+
+	transport.redeclareTopology()
+
+	for name, channels := range map[string]*sync.Map{
+		"channels":         transport.channels,
+		"confirm_channels": transport.confirmChannels,
+	} {
+		channels.Range(func(key, _ any) bool {
+			t.Errorf("expect %s to be dropped, got %+v", name, key)
+			return true
+		})
+	}
+}
@@ -0,0 +1,399 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultRedisConsumerGroup = "timely"
+	defaultRedisClaimIdle     = time.Minute
+	defaultRedisBlockTimeout  = time.Second * 5
+
+	// consumers of restarted instances without pending messages are removed from group after retention
+	redisConsumerRetention = time.Hour * 24
+
+	redisPayloadField    = "payload"
+	redisRoutingKeyField = "routingKey"
+)
+
+// RedisConfig describes streams, schedule events are added to stream named after job slug, all stream names
+// are prefixed with Prefix
+type RedisConfig struct {
+	Url           string
+	Prefix        string
+	ConsumerGroup string        // consumer group of job status subscription
+	Consumer      string        // consumer name unique per instance, random when empty
+	MaxLen        int64         // streams are trimmed to approximately max length, zero disables trimming
+	ClaimIdle     time.Duration // pending messages idle for longer are reclaimed from crashed consumers
+	BlockTimeout  time.Duration
+}
+
+type RedisTransport struct {
+	config        RedisConfig
+	client        *redis.Client
+	subscribeOpts []SubscribeOption
+
+	logger *zap.SugaredLogger
+}
+
+type RedisOption func(*RedisTransport)
+
+// WithRedisSubscribeDefaults sets options used by every subscription, prefetch is count of messages read at once
+// and workers limit concurrently processed messages
+func WithRedisSubscribeDefaults(opts ...SubscribeOption) RedisOption {
+	return func(t *RedisTransport) {
+		t.subscribeOpts = append(t.subscribeOpts, opts...)
+	}
+}
+
+func NewRedisTransport(config RedisConfig, logger *zap.SugaredLogger, opts ...RedisOption) (*RedisTransport, error) {
+	if config.ConsumerGroup == "" {
+		config.ConsumerGroup = defaultRedisConsumerGroup
+	}
+
+	if config.Consumer == "" {
+		config.Consumer = "timely-" + uuid.NewString()
+	}
+
+	if config.ClaimIdle <= 0 {
+		config.ClaimIdle = defaultRedisClaimIdle
+	}
+
+	if config.BlockTimeout <= 0 {
+		config.BlockTimeout = defaultRedisBlockTimeout
+	}
+
+	options, err := redis.ParseURL(config.Url)
+	if err != nil {
+		return nil, err
+	}
+
+	transport := &RedisTransport{
+		config: config,
+		client: redis.NewClient(options),
+		logger: logger,
+	}
+
+	for _, opt := range opts {
+		opt(transport)
+	}
+
+	if err = transport.Health(context.Background()); err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+	logger.Info("connected to redis")
+
+	return transport, nil
+}
+
+func (c RedisConfig) name(stream string) string {
+	return c.Prefix + stream
+}
+
+// Publish adds message to stream, routing key is added as separate field so consumers can filter by it
+func (t *RedisTransport) Publish(ctx context.Context, stream, routingKey string, message any) error {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return errors.New("invalid message format")
+	}
+
+	values := []any{redisPayloadField, data}
+	if routingKey != "" {
+		values = append(values, redisRoutingKeyField, routingKey)
+	}
+
+	err = t.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: t.config.name(stream),
+		MaxLen: t.config.MaxLen,
+		Approx: true,
+		Values: values,
+	}).Err()
+	if err != nil {
+		t.logger.Errorf("error during redis publish - %v", err)
+	}
+
+	return redisPublishError(err)
+}
+
+// redisPublishError maps replies which reject message regardless of retries to dispatch failures, connection
+// errors are left to outbox retries
+func redisPublishError(err error) error {
+	var redisErr redis.Error
+	if errors.As(err, &redisErr) && strings.HasPrefix(redisErr.Error(), "WRONGTYPE") {
+		return errors.Join(ErrMessageUnroutable, err)
+	}
+
+	return err
+}
+
+// Subscribe reads stream in consumer group until context is cancelled, processed message is acked, message
+// of crashed consumer is reclaimed once it is idle for ClaimIdle
+func (t *RedisTransport) Subscribe(ctx context.Context, queue string, handle func(message []byte) error,
+	opts ...SubscribeOption) error {
+	options := newSubscribeOptions(append(slices.Clone(t.subscribeOpts), opts...)...)
+	stream := t.config.name(queue)
+
+	for attempt := 1; ; attempt++ {
+		err := t.createGroup(ctx, stream)
+		if err == nil {
+			err = t.consume(ctx, stream, handle, options)
+		}
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		backoff := reconnectBackoff(attempt)
+		t.logger.Warnf("consumer of %s interrupted, resubscribing in %s - %v", stream, backoff, err)
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// createGroup creates consumer group with its stream, existing group is kept
+func (t *RedisTransport) createGroup(ctx context.Context, stream string) error {
+	err := t.client.XGroupCreateMkStream(ctx, stream, t.config.ConsumerGroup, "0").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return err
+	}
+
+	return nil
+}
+
+// consume reads new and reclaimed messages until reading fails, in-flight messages are finished before return
+func (t *RedisTransport) consume(ctx context.Context, stream string, handle func(message []byte) error,
+	options SubscribeOptions) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	messages := make(chan redis.XMessage)
+	var wg sync.WaitGroup
+	for i := 0; i < options.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for message := range messages {
+				t.process(ctx, stream, message, handle, options)
+			}
+		}()
+	}
+
+	reclaimErr := make(chan error, 1)
+	go func() {
+		defer cancel()
+		reclaimErr <- t.reclaim(ctx, stream, messages, options)
+	}()
+
+	err := t.read(ctx, stream, messages, options)
+	cancel()
+
+	err = errors.Join(err, <-reclaimErr)
+	close(messages)
+	wg.Wait()
+
+	return err
+}
+
+func (t *RedisTransport) read(ctx context.Context, stream string, messages chan<- redis.XMessage,
+	options SubscribeOptions) error {
+	for {
+		streams, err := t.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+			Group:    t.config.ConsumerGroup,
+			Consumer: t.config.Consumer,
+			Streams:  []string{stream, ">"},
+			Count:    int64(options.Prefetch),
+			Block:    t.config.BlockTimeout,
+		}).Result()
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
+
+		if err != nil {
+			return err
+		}
+
+		for _, s := range streams {
+			if err = dispatch(ctx, s.Messages, messages); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// reclaim periodically claims messages left pending by crashed consumers, trims stream and removes
+// consumers which are gone
+func (t *RedisTransport) reclaim(ctx context.Context, stream string, messages chan<- redis.XMessage,
+	options SubscribeOptions) error {
+	ticker := time.NewTicker(max(t.config.ClaimIdle/2, time.Millisecond))
+	defer ticker.Stop()
+
+	for {
+		start := "0-0"
+		for {
+			claimed, next, err := t.client.XAutoClaim(ctx, &redis.XAutoClaimArgs{
+				Stream:   stream,
+				Group:    t.config.ConsumerGroup,
+				Consumer: t.config.Consumer,
+				MinIdle:  t.config.ClaimIdle,
+				Start:    start,
+				Count:    int64(options.Prefetch),
+			}).Result()
+			if err != nil {
+				return err
+			}
+
+			if len(claimed) > 0 {
+				t.logger.Warnf("reclaimed %d pending messages of %s", len(claimed), stream)
+			}
+
+			if err = dispatch(ctx, claimed, messages); err != nil {
+				return err
+			}
+
+			if next == "0-0" || next == "" {
+				break
+			}
+			start = next
+		}
+
+		if t.config.MaxLen > 0 {
+			if err := t.client.XTrimMaxLenApprox(ctx, stream, t.config.MaxLen, 0).Err(); err != nil {
+				t.logger.Errorf("error during trimming %s - %v", stream, err)
+			}
+		}
+
+		if err := t.removeGoneConsumers(ctx, stream); err != nil {
+			t.logger.Errorf("error during removing consumers of %s - %v", stream, err)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func dispatch(ctx context.Context, batch []redis.XMessage, messages chan<- redis.XMessage) error {
+	for _, message := range batch {
+		select {
+		case messages <- message:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	return nil
+}
+
+// removeGoneConsumers removes consumers of restarted instances, their pending messages were reclaimed already
+func (t *RedisTransport) removeGoneConsumers(ctx context.Context, stream string) error {
+	consumers, err := t.client.XInfoConsumers(ctx, stream, t.config.ConsumerGroup).Result()
+	if err != nil {
+		return err
+	}
+
+	for _, consumer := range consumers {
+		if consumer.Name == t.config.Consumer || consumer.Pending > 0 || consumer.Idle < redisConsumerRetention {
+			continue
+		}
+
+		err = t.client.XGroupDelConsumer(ctx, stream, t.config.ConsumerGroup, consumer.Name).Err()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// process retries failed message in place after requeue delay, message is acked once processed or once
+// retries are exhausted, message interrupted by shutdown stays pending and is reclaimed later
+func (t *RedisTransport) process(ctx context.Context, stream string, message redis.XMessage,
+	handle func(message []byte) error, options SubscribeOptions) {
+	payload, _ := message.Values[redisPayloadField].(string)
+
+	for count := 0; ; {
+		err := handle([]byte(payload))
+		if err == nil {
+			break
+		}
+
+		t.logger.Errorf("error during consumer action processing - %v", err)
+
+		var retry bool
+		if count, retry = retryAttempt(count, options); !retry {
+			t.logger.Warnf("dropping message %s of %s after %d retries", message.ID, stream, count)
+			break
+		}
+
+		select {
+		case <-time.After(options.RequeueDelay):
+		case <-ctx.Done():
+			return
+		}
+	}
+
+	// context of subscription may be cancelled already, message is acked anyway
+	if err := t.client.XAck(context.WithoutCancel(ctx), stream, t.config.ConsumerGroup, message.ID).Err(); err != nil {
+		t.logger.Errorf("error during ack - %v", err)
+	}
+}
+
+// Prepare checks that custom stream exists, stream named after job slug is created with its first message
+func (t *RedisTransport) Prepare(ctx context.Context, schedule *Schedule) error {
+	if schedule.Configuration.Exchange == "" {
+		return nil
+	}
+
+	stream, _ := t.Route(schedule)
+	exists, err := t.client.Exists(ctx, t.config.name(stream)).Result()
+	if err != nil {
+		return err
+	}
+
+	if exists == 0 {
+		return errors.Join(ErrStreamNotFound, fmt.Errorf("stream %s", stream))
+	}
+
+	return nil
+}
+
+// Route returns stream and routing key of schedule events, custom stream takes precedence
+func (t *RedisTransport) Route(schedule *Schedule) (string, string) {
+	if schedule.Configuration.Exchange != "" {
+		return schedule.Configuration.Exchange, schedule.Configuration.RoutingKey
+	}
+
+	return schedule.Job.Slug, ""
+}
+
+// DeleteQueue deletes stream with its consumer groups
+func (t *RedisTransport) DeleteQueue(stream string) error {
+	return t.client.Del(context.Background(), t.config.name(stream)).Err()
+}
+
+func (t *RedisTransport) Health(ctx context.Context) error {
+	return t.client.Ping(ctx).Err()
+}
+
+func (t *RedisTransport) Close() error {
+	return t.client.Close()
+}
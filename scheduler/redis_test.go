@@ -0,0 +1,201 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+func startRedisTransport(t *testing.T, config RedisConfig) *RedisTransport {
+	t.Helper()
+
+	mr := miniredis.RunT(t)
+	config.Url = "redis://" + mr.Addr()
+	config.BlockTimeout = time.Millisecond * 50
+
+	transport, err := NewRedisTransport(config, zap.NewNop().Sugar())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = transport.Close() })
+
+	return transport
+}
+
+func TestRedisRoute(t *testing.T) {
+	tests := map[string]struct {
+		exchange   string
+		routingKey string
+
+		expectStream     string
+		expectRoutingKey string
+	}{
+		"stream_per_job": {
+			expectStream: "slug",
+		},
+		"custom_stream_with_routing_key": {
+			exchange:         "orders",
+			routingKey:       "created",
+			expectStream:     "orders",
+			expectRoutingKey: "created",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			s := NewSchedule("description", "once", getStubDate,
+				WithConfiguration(Redis, ""),
+				WithRouting(test.exchange, test.routingKey),
+				WithJob("slug", nil))
+
+			transport := &RedisTransport{}
+			stream, routingKey := transport.Route(&s)
+			if stream != test.expectStream || routingKey != test.expectRoutingKey {
+				t.Errorf("expect result %s %s, got %s %s", test.expectStream, test.expectRoutingKey,
+					stream, routingKey)
+			}
+		})
+	}
+}
+
+func TestRedisPrepare(t *testing.T) {
+	transport := startRedisTransport(t, RedisConfig{Prefix: "test-"})
+	ctx := context.Background()
+
+	s := NewSchedule("description", "once", getStubDate,
+		WithConfiguration(Redis, ""),
+		WithRouting("orders", ""),
+		WithJob("slug", nil))
+
+	if err := transport.Prepare(ctx, &s); !errors.Is(err, ErrStreamNotFound) {
+		t.Errorf("expect error %+v, got %+v", ErrStreamNotFound, err)
+	}
+
+	if err := transport.Publish(ctx, "orders", "", ScheduleJobEvent{Job: "slug"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := transport.Prepare(ctx, &s); err != nil {
+		t.Errorf("expect error %+v, got %+v", nil, err)
+	}
+}
+
+func TestRedisSubscribe(t *testing.T) {
+	transport := startRedisTransport(t, RedisConfig{Prefix: "test-", MaxLen: 10})
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	if err := transport.Publish(ctx, string(JobStatusQueue), "", JobStatusEvent{Status: "succeed"}); err != nil {
+		t.Fatal(err)
+	}
+
+	var deliveries atomic.Int32
+	err := transport.Subscribe(ctx, string(JobStatusQueue), func(message []byte) error {
+		// first attempt fails, so message is retried after requeue delay
+		if deliveries.Add(1) == 1 {
+			return errors.New("processing error")
+		}
+
+		cancel()
+		return nil
+	}, WithRequeueDelay(time.Millisecond*10, 1), WithWorkers(1))
+
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expect error %+v, got %+v", context.Canceled, err)
+	}
+
+	if deliveries.Load() != 2 {
+		t.Errorf("expect result %+v, got %+v", 2, deliveries.Load())
+	}
+
+	pending, err := transport.client.XPending(context.Background(), "test-"+string(JobStatusQueue),
+		defaultRedisConsumerGroup).Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if pending.Count != 0 {
+		t.Errorf("expect result %+v, got %+v", 0, pending.Count)
+	}
+}
+
+func TestRedisReclaim(t *testing.T) {
+	transport := startRedisTransport(t, RedisConfig{ClaimIdle: time.Millisecond * 50})
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	stream := string(JobStatusQueue)
+	if err := transport.createGroup(ctx, stream); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := transport.Publish(ctx, stream, "", JobStatusEvent{Status: "succeed"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// message is delivered to consumer which crashes before ack
+	err := transport.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+		Group:    defaultRedisConsumerGroup,
+		Consumer: "crashed",
+		Streams:  []string{stream, ">"},
+	}).Err()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var deliveries atomic.Int32
+	err = transport.Subscribe(ctx, stream, func(message []byte) error {
+		deliveries.Add(1)
+		cancel()
+		return nil
+	})
+
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expect error %+v, got %+v", context.Canceled, err)
+	}
+
+	if deliveries.Load() != 1 {
+		t.Errorf("expect result %+v, got %+v", 1, deliveries.Load())
+	}
+}
+
+func TestRedisPublishError(t *testing.T) {
+	tests := map[string]struct {
+		key func(mr *miniredis.Miniredis)
+
+		expected bool
+	}{
+		"new_stream": {
+			key:      func(mr *miniredis.Miniredis) {},
+			expected: false,
+		},
+		"wrong_type": {
+			key:      func(mr *miniredis.Miniredis) { _ = mr.Set("slug", "value") },
+			expected: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			mr := miniredis.RunT(t)
+			test.key(mr)
+
+			transport, err := NewRedisTransport(RedisConfig{Url: "redis://" + mr.Addr()}, zap.NewNop().Sugar())
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { _ = transport.Close() })
+
+			err = transport.Publish(context.Background(), "slug", "", "payload")
+			if IsDispatchFailure(err) != test.expected {
+				t.Errorf("expect result %+v, got %+v", test.expected, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type RetentionConfig struct {
+	Interval          time.Duration // delay between purges
+	BatchSize         int           // rows deleted in one statement, keeps locks short
+	Archive           bool          // move rows to archive tables instead of deleting them
+	JobRuns           time.Duration // zero keeps job runs forever, schedules can override it
+	FinishedSchedules time.Duration // grace period of finished one-shot schedules, zero keeps them forever
+}
+
+const (
+	defaultRetentionInterval  = time.Minute * 10
+	defaultRetentionBatchSize = 1000
+)
+
+func (s *Scheduler) purgeExpiredData(ctx context.Context, config RetentionConfig) {
+	s.logger.Info("starting data retention")
+
+	if config.Interval <= 0 {
+		config.Interval = defaultRetentionInterval
+	}
+
+	if config.BatchSize <= 0 {
+		config.BatchSize = defaultRetentionBatchSize
+	}
+
+	for {
+		if err := s.purgeJobRuns(ctx, config, time.Now); err != nil {
+			s.logger.Errorf("error during job runs purge - %v", err)
+		}
+
+		if err := s.purgeFinishedSchedules(ctx, config, time.Now); err != nil {
+			s.logger.Errorf("error during finished schedules purge - %v", err)
+		}
+
+		time.Sleep(config.Interval)
+	}
+}
+
+func (s *Scheduler) purgeJobRuns(ctx context.Context, config RetentionConfig, now func() time.Time) error {
+	overrides, err := s.Storage.GetJobRunRetentions(ctx)
+	if err != nil {
+		return err
+	}
+
+	cutoffs := getJobRunCutoffs(config.JobRuns, overrides, now())
+
+	for scheduleId, before := range cutoffs {
+		var deleted int64
+		for {
+			count, err := s.Storage.DeleteJobRuns(ctx, scheduleId, before, config.BatchSize, config.Archive)
+			if err != nil {
+				return err
+			}
+
+			deleted += count
+			if count < int64(config.BatchSize) {
+				break
+			}
+		}
+
+		if deleted > 0 {
+			s.logger.Infof("purged %d job runs started before %s", deleted, before.Format(time.RFC3339))
+		}
+	}
+
+	return nil
+}
+
+func (s *Scheduler) purgeFinishedSchedules(ctx context.Context, config RetentionConfig, now func() time.Time) error {
+	if config.FinishedSchedules <= 0 {
+		return nil
+	}
+
+	before := now().Add(-config.FinishedSchedules)
+
+	for {
+		schedules, err := s.Storage.GetFinishedSchedules(ctx, before, config.BatchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, schedule := range schedules {
+			if err := s.purgeSchedule(ctx, schedule, config.Archive); err != nil {
+				return err
+			}
+		}
+
+		if len(schedules) < config.BatchSize {
+			return nil
+		}
+	}
+}
+
+func (s *Scheduler) purgeSchedule(ctx context.Context, schedule *Schedule, archive bool) error {
+	// queue is released first, so failed removal is retried during the next purge, finished schedule
+	// is not its reference anymore
+	if transport, ok := s.asyncTransport(Rabbitmq); ok && schedule.UsesJobQueue() {
+		if _, err := ReleaseJobQueue(ctx, s.Storage, transport, schedule.Job.Slug); err != nil {
+			return err
+		}
+	}
+
+	var err error
+	if archive {
+		err = s.Storage.ArchiveScheduleById(ctx, schedule.Id)
+	} else {
+		err = s.Storage.DeleteScheduleById(ctx, schedule.Id)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	s.logger.Infof("purged finished schedule %s", schedule.Id)
+
+	return nil
+}
+
+// getJobRunCutoffs returns dates before which job runs are purged, nil id stands for schedules
+// without own retention, invalid overrides are skipped so their job runs are kept
+func getJobRunCutoffs(global time.Duration, overrides map[uuid.UUID]string,
+	now time.Time) map[uuid.UUID]time.Time {
+	cutoffs := make(map[uuid.UUID]time.Time)
+
+	if global > 0 {
+		cutoffs[uuid.Nil] = now.Add(-global)
+	}
+
+	for id, override := range overrides {
+		retention, err := time.ParseDuration(override)
+		if err != nil || retention <= 0 {
+			continue
+		}
+
+		cutoffs[id] = now.Add(-retention)
+	}
+
+	return cutoffs
+}
@@ -0,0 +1,60 @@
+package scheduler
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestGetJobRunCutoffs(t *testing.T) {
+	now := time.Date(2000, time.January, 10, 12, 0, 0, 0, time.UTC)
+	scheduleId := uuid.MustParse("0b6f1e4c-4b8a-4d3e-9a59-0d6c1f7e2a11")
+
+	tests := map[string]struct {
+		global    time.Duration
+		overrides map[uuid.UUID]string
+
+		expected map[uuid.UUID]time.Time
+	}{
+		"global_retention": {
+			global:    time.Hour * 24,
+			overrides: map[uuid.UUID]string{},
+			expected: map[uuid.UUID]time.Time{
+				uuid.Nil: time.Date(2000, time.January, 9, 12, 0, 0, 0, time.UTC),
+			},
+		},
+		"schedule_override": {
+			global:    time.Hour * 24,
+			overrides: map[uuid.UUID]string{scheduleId: "2h"},
+			expected: map[uuid.UUID]time.Time{
+				uuid.Nil:   time.Date(2000, time.January, 9, 12, 0, 0, 0, time.UTC),
+				scheduleId: time.Date(2000, time.January, 10, 10, 0, 0, 0, time.UTC),
+			},
+		},
+		"override_without_global_retention": {
+			overrides: map[uuid.UUID]string{scheduleId: "2h"},
+			expected: map[uuid.UUID]time.Time{
+				scheduleId: time.Date(2000, time.January, 10, 10, 0, 0, 0, time.UTC),
+			},
+		},
+		"invalid_override_keeps_job_runs": {
+			global:    time.Hour * 24,
+			overrides: map[uuid.UUID]string{scheduleId: "forever"},
+			expected: map[uuid.UUID]time.Time{
+				uuid.Nil: time.Date(2000, time.January, 9, 12, 0, 0, 0, time.UTC),
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			result := getJobRunCutoffs(test.global, test.overrides, now)
+
+			if !reflect.DeepEqual(result, test.expected) {
+				t.Errorf("expect result %+v, got %+v", test.expected, result)
+			}
+		})
+	}
+}
@@ -0,0 +1,187 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+)
+
+type RollupGranularity string
+
+const (
+	Hourly RollupGranularity = "hour"
+	Daily  RollupGranularity = "day"
+)
+
+// DurationHistogramBounds are upper bounds (in seconds) of job run duration histogram buckets,
+// histogram has one more bucket for durations above the last bound
+var DurationHistogramBounds = []float64{1, 5, 10, 30, 60, 300, 900, 1800, 3600}
+
+type RollupConfig struct {
+	Interval        time.Duration // delay between aggregations
+	Lateness        time.Duration // how long already aggregated buckets are recomputed, covers late job statuses
+	HourlyRetention time.Duration // zero keeps rollups forever
+	DailyRetention  time.Duration // zero keeps rollups forever
+}
+
+const (
+	rollupBackfillStep    = time.Hour * 24
+	defaultRollupInterval = time.Minute
+	defaultRollupLateness = time.Hour * 2
+)
+
+func (s *Scheduler) aggregateRollups(ctx context.Context, config RollupConfig) {
+	s.logger.Info("starting job runs rollups aggregation")
+
+	if config.Interval <= 0 {
+		config.Interval = defaultRollupInterval
+	}
+
+	if config.Lateness <= 0 {
+		config.Lateness = defaultRollupLateness
+	}
+
+	for {
+		caughtUp, err := s.rollup(ctx, config, time.Now)
+		if err != nil {
+			s.logger.Errorf("error during job runs rollup - %v", err)
+		}
+
+		if purgeErr := s.purgeRollups(ctx, config, time.Now); purgeErr != nil {
+			s.logger.Errorf("error during job runs rollups purge - %v", purgeErr)
+		}
+
+		// during backfill next chunk is processed without waiting
+		if caughtUp || err != nil {
+			time.Sleep(config.Interval)
+		}
+	}
+}
+
+// rollup aggregates next range of job runs into hourly and daily buckets, returns
+// true when aggregation reached current bucket
+func (s *Scheduler) rollup(ctx context.Context, config RollupConfig, now func() time.Time) (bool, error) {
+	watermark, err := s.Storage.GetRollupWatermark(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	var oldest *time.Time
+	if watermark == nil {
+		oldest, err = s.Storage.GetOldestJobRunDate(ctx)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	from, to, next := getRollupRange(watermark, oldest, config.Lateness, now())
+
+	acquired, err := s.Storage.RollupJobRuns(ctx, from, to, next)
+	if err != nil {
+		return false, err
+	}
+
+	if !acquired {
+		// other instance is aggregating
+		return true, nil
+	}
+
+	return !to.Before(truncateToBucket(now(), Hourly)), nil
+}
+
+func (s *Scheduler) purgeRollups(ctx context.Context, config RollupConfig, now func() time.Time) error {
+	retentions := map[RollupGranularity]time.Duration{
+		Hourly: config.HourlyRetention,
+		Daily:  config.DailyRetention,
+	}
+
+	for granularity, retention := range retentions {
+		if retention <= 0 {
+			continue
+		}
+
+		deleted, err := s.Storage.DeleteRollups(ctx, granularity, now().Add(-retention))
+		if err != nil {
+			return err
+		}
+
+		if deleted > 0 {
+			s.logger.Infof("purged %d %s job runs rollups", deleted, granularity)
+		}
+	}
+
+	return nil
+}
+
+// getRollupRange returns range of hourly buckets to (re)compute and watermark to store after it,
+// current bucket is always recomputed because it is still open, watermark never moves past it
+func getRollupRange(watermark *time.Time, oldest *time.Time, lateness time.Duration,
+	now time.Time) (time.Time, time.Time, time.Time) {
+	current := truncateToBucket(now, Hourly)
+
+	var from time.Time
+	switch {
+	case watermark != nil:
+		from = truncateToBucket(watermark.Add(-lateness), Hourly)
+	case oldest != nil:
+		from = truncateToBucket(*oldest, Hourly)
+	default:
+		from = current
+	}
+
+	to := from.Add(rollupBackfillStep)
+	if !to.Before(current) {
+		return from, current.Add(time.Hour), current
+	}
+
+	return from, to, to
+}
+
+func truncateToBucket(date time.Time, granularity RollupGranularity) time.Time {
+	if granularity == Daily {
+		return date.UTC().Truncate(time.Hour * 24)
+	}
+
+	return date.UTC().Truncate(time.Hour)
+}
+
+// HistogramPercentiles estimates percentiles from duration histogram with linear interpolation
+// inside bucket, durations above the last bound are estimated as the last bound
+func HistogramPercentiles(bounds []float64, counts []int, percentiles ...float64) []float64 {
+	total := 0
+	for _, c := range counts {
+		total += c
+	}
+
+	if total == 0 {
+		return []float64{}
+	}
+
+	result := make([]float64, 0, len(percentiles))
+	for _, p := range percentiles {
+		target := p * float64(total)
+		cumulative := 0.0
+
+		value := bounds[len(bounds)-1]
+		for i, c := range counts {
+			if i >= len(bounds) {
+				break
+			}
+
+			if cumulative+float64(c) >= target && c > 0 {
+				lower := 0.0
+				if i > 0 {
+					lower = bounds[i-1]
+				}
+
+				value = lower + (bounds[i]-lower)*(target-cumulative)/float64(c)
+				break
+			}
+
+			cumulative += float64(c)
+		}
+
+		result = append(result, value)
+	}
+
+	return result
+}
@@ -0,0 +1,91 @@
+package scheduler
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestGetRollupRange(t *testing.T) {
+	now := time.Date(2000, time.January, 10, 12, 30, 0, 0, time.UTC)
+	oldest := time.Date(2000, time.January, 1, 8, 15, 0, 0, time.UTC)
+	watermark := time.Date(2000, time.January, 10, 12, 0, 0, 0, time.UTC)
+
+	tests := map[string]struct {
+		watermark *time.Time
+		oldest    *time.Time
+
+		expectedFrom      time.Time
+		expectedTo        time.Time
+		expectedWatermark time.Time
+	}{
+		"without_job_runs_starts_at_current_bucket": {
+			expectedFrom:      time.Date(2000, time.January, 10, 12, 0, 0, 0, time.UTC),
+			expectedTo:        time.Date(2000, time.January, 10, 13, 0, 0, 0, time.UTC),
+			expectedWatermark: time.Date(2000, time.January, 10, 12, 0, 0, 0, time.UTC),
+		},
+		"backfill_starts_at_oldest_job_run_in_steps": {
+			oldest:            &oldest,
+			expectedFrom:      time.Date(2000, time.January, 1, 8, 0, 0, 0, time.UTC),
+			expectedTo:        time.Date(2000, time.January, 2, 8, 0, 0, 0, time.UTC),
+			expectedWatermark: time.Date(2000, time.January, 2, 8, 0, 0, 0, time.UTC),
+		},
+		"caught_up_recomputes_late_buckets": {
+			watermark:         &watermark,
+			expectedFrom:      time.Date(2000, time.January, 10, 10, 0, 0, 0, time.UTC),
+			expectedTo:        time.Date(2000, time.January, 10, 13, 0, 0, 0, time.UTC),
+			expectedWatermark: time.Date(2000, time.January, 10, 12, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			from, to, next := getRollupRange(test.watermark, test.oldest, time.Hour*2, now)
+
+			if !from.Equal(test.expectedFrom) {
+				t.Errorf("expect from %+v, got %+v", test.expectedFrom, from)
+			}
+
+			if !to.Equal(test.expectedTo) {
+				t.Errorf("expect to %+v, got %+v", test.expectedTo, to)
+			}
+
+			if !next.Equal(test.expectedWatermark) {
+				t.Errorf("expect watermark %+v, got %+v", test.expectedWatermark, next)
+			}
+		})
+	}
+}
+
+func TestHistogramPercentiles(t *testing.T) {
+	bounds := []float64{1, 5, 10}
+
+	tests := map[string]struct {
+		counts []int
+
+		expected []float64
+	}{
+		"empty_histogram": {
+			counts:   []int{0, 0, 0, 0},
+			expected: []float64{},
+		},
+		"interpolates_inside_bucket": {
+			counts:   []int{0, 10, 0, 0},
+			expected: []float64{3, 4.8},
+		},
+		"overflow_bucket_uses_last_bound": {
+			counts:   []int{1, 0, 0, 9},
+			expected: []float64{10, 10},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			percentiles := HistogramPercentiles(bounds, test.counts, 0.5, 0.95)
+
+			if !reflect.DeepEqual(percentiles, test.expected) {
+				t.Errorf("expect result %+v, got %+v", test.expected, percentiles)
+			}
+		})
+	}
+}
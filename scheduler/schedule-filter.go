@@ -0,0 +1,96 @@
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+type ScheduleSortField string
+
+const (
+	SortByLastExecutionDate ScheduleSortField = "lastExecutionDate"
+	SortByNextExecutionDate ScheduleSortField = "nextExecutionDate"
+	SortByDescription       ScheduleSortField = "description"
+)
+
+type SortOrder string
+
+const (
+	Ascending  SortOrder = "asc"
+	Descending SortOrder = "desc"
+)
+
+const DefaultNamespace = "default"
+
+type ScheduleFilter struct {
+	Namespace        string
+	Status           ScheduleStatus
+	TransportType    TransportType
+	JobSlug          string
+	LastJobRunStatus JobRunStatus
+	Labels           map[string]string // all labels have to match
+	Search           string            // free text search on description
+	SortBy           ScheduleSortField
+	SortOrder        SortOrder
+}
+
+var (
+	labelKeyRegex   = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9._/-]{0,62})$`)
+	labelValueRegex = regexp.MustCompile(`^[a-zA-Z0-9._/-]{0,256}$`)
+	namespaceRegex  = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,62})$`)
+)
+
+const maxLabelsCount = 32
+
+// ParseLabelSelector parses selector in format key1=value1,key2=value2
+func ParseLabelSelector(selector string) (map[string]string, error) {
+	labels := make(map[string]string)
+
+	if strings.TrimSpace(selector) == "" {
+		return labels, nil
+	}
+
+	for _, pair := range strings.Split(selector, ",") {
+		key, value, found := strings.Cut(strings.TrimSpace(pair), "=")
+		if !found {
+			return nil, fmt.Errorf("invalid label selector %s", pair)
+		}
+
+		labels[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+
+	if err := ValidateLabels(labels); err != nil {
+		return nil, err
+	}
+
+	return labels, nil
+}
+
+func ValidateLabels(labels map[string]string) error {
+	if len(labels) > maxLabelsCount {
+		return fmt.Errorf("too many labels, maximum is %d", maxLabelsCount)
+	}
+
+	var err error
+	for key, value := range labels {
+		if !labelKeyRegex.MatchString(key) {
+			err = errors.Join(err, fmt.Errorf("invalid label key %s", key))
+		}
+
+		if !labelValueRegex.MatchString(value) {
+			err = errors.Join(err, fmt.Errorf("invalid label value %s", value))
+		}
+	}
+
+	return err
+}
+
+func ValidateNamespace(namespace string) error {
+	if !namespaceRegex.MatchString(namespace) {
+		return errors.New("invalid namespace")
+	}
+
+	return nil
+}
@@ -0,0 +1,75 @@
+package scheduler
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseLabelSelector(t *testing.T) {
+	tests := map[string]struct {
+		selector string
+
+		expected  map[string]string
+		expectErr string
+	}{
+		"empty_selector": {
+			selector: "",
+			expected: map[string]string{},
+		},
+		"single_label": {
+			selector: "team=billing",
+			expected: map[string]string{"team": "billing"},
+		},
+		"multiple_labels_with_spaces": {
+			selector: "team=billing, env = prod",
+			expected: map[string]string{"team": "billing", "env": "prod"},
+		},
+		"missing_value_separator": {
+			selector:  "team",
+			expectErr: "invalid label selector team",
+		},
+		"invalid_label_key": {
+			selector:  "-team=billing",
+			expectErr: "invalid label key -team",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			labels, err := ParseLabelSelector(test.selector)
+
+			if test.expectErr != "" {
+				if err == nil || test.expectErr != err.Error() {
+					t.Errorf("expect error %s, got %v", test.expectErr, err)
+				}
+			} else {
+				if !reflect.DeepEqual(labels, test.expected) {
+					t.Errorf("expect result %+v, got %+v", test.expected, labels)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateNamespace(t *testing.T) {
+	tests := map[string]struct {
+		namespace string
+
+		expectErr bool
+	}{
+		"valid_namespace":       {namespace: "billing-prod"},
+		"uppercase_namespace":   {namespace: "Billing", expectErr: true},
+		"empty_namespace":       {namespace: "", expectErr: true},
+		"starts_with_separator": {namespace: "-billing", expectErr: true},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := ValidateNamespace(test.namespace)
+
+			if test.expectErr != (err != nil) {
+				t.Errorf("expect error %v, got %v", test.expectErr, err)
+			}
+		})
+	}
+}
@@ -16,6 +16,34 @@ func WithScheduleStart(time *time.Time) ScheduleOption {
 	}
 }
 
+func WithNamespace(namespace string) ScheduleOption {
+	return func(s *Schedule) {
+		if namespace != "" {
+			s.Namespace = namespace
+		}
+	}
+}
+
+func WithLabels(labels map[string]string) ScheduleOption {
+	return func(s *Schedule) {
+		if labels != nil {
+			s.Labels = labels
+		}
+	}
+}
+
+func WithJitter(jitter string) ScheduleOption {
+	return func(s *Schedule) {
+		s.Jitter = jitter
+	}
+}
+
+func WithJobRunRetention(retention string) ScheduleOption {
+	return func(s *Schedule) {
+		s.JobRunRetention = retention
+	}
+}
+
 func WithConfiguration(transportType TransportType, url string) ScheduleOption {
 	return func(s *Schedule) {
 		s.Configuration = ScheduleConfiguration{
@@ -25,6 +53,21 @@ func WithConfiguration(transportType TransportType, url string) ScheduleOption {
 	}
 }
 
+// WithRouting dispatches rabbitmq job to given exchange instead of queue named after job slug
+func WithRouting(exchange, routingKey string) ScheduleOption {
+	return func(s *Schedule) {
+		s.Configuration.Exchange = exchange
+		s.Configuration.RoutingKey = routingKey
+	}
+}
+
+// WithExternalQueue marks queue of the job as managed outside of timely
+func WithExternalQueue(external bool) ScheduleOption {
+	return func(s *Schedule) {
+		s.Configuration.ExternalQueue = external
+	}
+}
+
 func WithJob(slug string, data *map[string]any) ScheduleOption {
 	return func(s *Schedule) {
 		s.Job = NewJob(slug, data)
@@ -1,6 +1,7 @@
 package scheduler
 
 import (
+	"hash/fnv"
 	"time"
 
 	"github.com/google/uuid"
@@ -21,67 +22,83 @@ const (
 
 type Schedule struct {
 	Id                uuid.UUID
-	GroupId           uuid.UUID
+	GroupId           uuid.UUID // current occurrence, rotated when schedule moves to the next one
+	Attempt           int       // attempts started within current occurrence
+	Namespace         string
 	Description       string
+	Labels            map[string]string
 	Frequency         string
 	ScheduleStart     *time.Time
 	Status            ScheduleStatus
 	RetryPolicy       RetryPolicy
+	Jitter            string
+	JobRunRetention   string // overrides global job runs retention, empty uses global one
 	Configuration     ScheduleConfiguration
 	LastExecutionDate *time.Time
 	NextExecutionDate *time.Time
+	CreationDate      time.Time
 	Job               *Job
 }
 
 type TransportType string
 
 const (
-	Http     TransportType = "http"
-	Rabbitmq TransportType = "rabbitmq"
+	Http      TransportType = "http"
+	Rabbitmq  TransportType = "rabbitmq"
+	Kafka     TransportType = "kafka"
+	Nats      TransportType = "nats"
+	Redis     TransportType = "redis"
+	Grpc      TransportType = "grpc"
+	Pull      TransportType = "pull"
+	Websocket TransportType = "websocket"
 )
 
 type ScheduleConfiguration struct {
 	TransportType TransportType
 	Url           string
+	Exchange      string // overrides exchange (topic of kafka) of async jobs, empty uses transport default
+	RoutingKey    string // routing key (message key of kafka) used with custom exchange
+	ExternalQueue bool   // queue named after job slug is managed outside of timely, it is never removed
 }
 
-func NewSchedule(description, frequency string, time func() time.Time, opts ...ScheduleOption) Schedule {
+func NewSchedule(description, frequency string, now func() time.Time, opts ...ScheduleOption) Schedule {
 	s := Schedule{
 		Id:                uuid.New(),
 		GroupId:           uuid.New(),
+		Namespace:         DefaultNamespace,
 		Description:       description,
+		Labels:            map[string]string{},
 		Frequency:         frequency,
 		Status:            Waiting,
 		LastExecutionDate: nil,
+		CreationDate:      now().Round(time.Second),
 	}
 
 	for _, opt := range opts {
 		opt(&s)
 	}
 
-	execution := getFirstExecutionTime(s.Frequency, s.ScheduleStart, time)
+	execution := getFirstExecutionTime(s.Frequency, s.ScheduleStart, s.jitterOffset(), now)
 	s.NextExecutionDate = &execution
 
 	return s
 }
 
+// IsAwaiting reports if schedule waits for its execution which is already due
+func (s *Schedule) IsAwaiting(now func() time.Time) bool {
+	return s.Status == Waiting && s.NextExecutionDate != nil && !s.NextExecutionDate.After(now())
+}
+
 func (s *Schedule) Start(now func() time.Time) {
 	lastExecAt := now().Round(time.Second)
 	s.LastExecutionDate = &lastExecAt
 	s.NextExecutionDate = nil
 	s.Status = Scheduled
+	s.Attempt++
 }
 
 func (s *Schedule) Succeed(now func() time.Time) {
-	nextExecAt := getNextExecutionTime(s.Frequency, now)
-
-	if nextExecAt == (time.Time{}) {
-		s.NextExecutionDate = nil
-		s.Status = Finished
-	} else {
-		s.NextExecutionDate = &nextExecAt
-		s.Status = Waiting
-	}
+	s.nextOccurrence(now)
 }
 
 func (s *Schedule) Failed(attempt int, now func() time.Time) {
@@ -96,17 +113,45 @@ func (s *Schedule) Failed(attempt int, now func() time.Time) {
 		}
 	}
 
-	nextExecAt := getNextExecutionTime(s.Frequency, now)
+	s.nextOccurrence(now)
+}
+
+// nextOccurrence moves schedule to the next occurrence with its own group and retry budget,
+// or finishes it if there is none
+func (s *Schedule) nextOccurrence(now func() time.Time) {
+	nextExecAt := getNextExecutionTime(s.Frequency, s.jitterOffset(), now)
+
 	if nextExecAt == (time.Time{}) {
 		s.NextExecutionDate = nil
 		s.Status = Finished
 	} else {
 		s.NextExecutionDate = &nextExecAt
 		s.Status = Waiting
+		s.GroupId = uuid.New()
+		s.Attempt = 0
+	}
+}
+
+// jitterOffset returns offset within jitter window, seeded by schedule id so it stays
+// the same across restarts
+func (s *Schedule) jitterOffset() time.Duration {
+	if s.Jitter == "" {
+		return 0
+	}
+
+	window, err := time.ParseDuration(s.Jitter)
+	if err != nil || window < time.Second {
+		return 0
 	}
+
+	h := fnv.New64a()
+	_, _ = h.Write(s.Id[:])
+
+	return time.Duration(h.Sum64()%uint64(window/time.Second)) * time.Second
 }
 
-func getFirstExecutionTime(frequency string, scheduleStart *time.Time, now func() time.Time) time.Time {
+func getFirstExecutionTime(frequency string, scheduleStart *time.Time, offset time.Duration,
+	now func() time.Time) time.Time {
 	if scheduleStart != nil {
 		return *scheduleStart
 	}
@@ -117,16 +162,35 @@ func getFirstExecutionTime(frequency string, scheduleStart *time.Time, now func(
 
 	sch, _ := CronParser.Parse(frequency)
 
-	return sch.Next(now().Round(time.Second))
+	return sch.Next(now().Round(time.Second).Add(-offset)).Add(offset)
 }
 
-func getNextExecutionTime(frequency string, now func() time.Time) time.Time {
+func getNextExecutionTime(frequency string, offset time.Duration, now func() time.Time) time.Time {
 	if frequency == string(Once) {
 		return time.Time{}
 	}
 
+	// occurrences are shifted by offset, so the next one is searched from the shifted point in time
 	sch, _ := CronParser.Parse(frequency)
-	nextExec := sch.Next(now().Round(time.Second))
+	nextExec := sch.Next(now().Round(time.Second).Add(-offset)).Add(offset)
 
 	return nextExec
 }
+
+// Destination returns exchange and routing key the job is dispatched to
+func (s *Schedule) Destination() (string, string) {
+	if s.Configuration.Exchange == "" {
+		return string(JobScheduleExchange), s.Job.Slug
+	}
+
+	if s.Configuration.RoutingKey == "" {
+		return s.Configuration.Exchange, s.Job.Slug
+	}
+
+	return s.Configuration.Exchange, s.Configuration.RoutingKey
+}
+
+// UsesJobQueue reports if the job is dispatched to queue named after job slug, such queue is managed by timely
+func (s *Schedule) UsesJobQueue() bool {
+	return s.Configuration.TransportType == Rabbitmq && s.Configuration.Exchange == ""
+}
@@ -1,8 +1,11 @@
 package scheduler
 
 import (
+	"reflect"
 	"testing"
 	"time"
+
+	"github.com/google/uuid"
 )
 
 func TestNewSchedule(t *testing.T) {
@@ -10,7 +13,9 @@ func TestNewSchedule(t *testing.T) {
 	expected := Schedule{
 		Id:          [16]byte{},
 		GroupId:     [16]byte{},
+		Namespace:   DefaultNamespace,
 		Description: "description",
+		Labels:      map[string]string{},
 		Frequency:   "once",
 		Status:      Waiting,
 		RetryPolicy: RetryPolicy{
@@ -24,6 +29,7 @@ func TestNewSchedule(t *testing.T) {
 		},
 		LastExecutionDate: nil,
 		NextExecutionDate: &net,
+		CreationDate:      getStubDate(),
 		Job: &Job{
 			Slug: "slug",
 			Data: nil,
@@ -50,7 +56,7 @@ func TestNewSchedule(t *testing.T) {
 
 	expected.NextExecutionDate = s.NextExecutionDate
 	expected.Job = s.Job
-	if s != expected {
+	if !reflect.DeepEqual(s, expected) {
 		t.Errorf("expect result %+v, got %+v", expected, s)
 	}
 }
@@ -70,6 +76,73 @@ func TestStart(t *testing.T) {
 	}
 }
 
+func TestStartIncrementsAttempt(t *testing.T) {
+	s := NewSchedule("", "once", getStubDate)
+
+	s.Start(getStubDate)
+	s.Start(getStubDate)
+
+	if s.Attempt != 2 {
+		t.Errorf("expect result %+v, got %+v", 2, s.Attempt)
+	}
+}
+
+func TestSucceedStartsNewOccurrence(t *testing.T) {
+	s := NewSchedule("", "*/10 * * * * *", getStubDate)
+	groupId := s.GroupId
+	s.Start(getStubDate)
+
+	s.Succeed(getStubDate)
+
+	if s.GroupId == groupId {
+		t.Errorf("expect new group, got %+v", s.GroupId)
+	}
+
+	if s.Attempt != 0 {
+		t.Errorf("expect result %+v, got %+v", 0, s.Attempt)
+	}
+}
+
+func TestFailedWithRetryKeepsOccurrence(t *testing.T) {
+	rp, _ := NewRetryPolicy(Constant, 3, "15s")
+	s := NewSchedule("", "*/10 * * * * *", getStubDate, WithRetryPolicy(rp))
+	groupId := s.GroupId
+	s.Start(getStubDate)
+
+	s.Failed(s.Attempt, getStubDate)
+
+	if s.GroupId != groupId {
+		t.Errorf("expect result %+v, got %+v", groupId, s.GroupId)
+	}
+
+	if s.Attempt != 1 {
+		t.Errorf("expect result %+v, got %+v", 1, s.Attempt)
+	}
+}
+
+func TestFailedWithExhaustedRetriesStartsNewOccurrence(t *testing.T) {
+	rp, _ := NewRetryPolicy(Constant, 1, "15s")
+	s := NewSchedule("", "*/10 * * * * *", getStubDate, WithRetryPolicy(rp))
+	groupId := s.GroupId
+	s.Start(getStubDate)
+	s.Start(getStubDate)
+
+	s.Failed(s.Attempt, getStubDate)
+
+	if s.GroupId == groupId {
+		t.Errorf("expect new group, got %+v", s.GroupId)
+	}
+
+	if s.Attempt != 0 {
+		t.Errorf("expect result %+v, got %+v", 0, s.Attempt)
+	}
+
+	expected := getStubDate().Add(time.Second * 10)
+	if *s.NextExecutionDate != expected {
+		t.Errorf("expect result %+v, got %+v", expected, *s.NextExecutionDate)
+	}
+}
+
 func TestNewScheduleWithSpecifiedScheduleStart(t *testing.T) {
 	scheduleStart := getStubDate()
 	s := NewSchedule("", "once", getStubDate, WithScheduleStart(&scheduleStart))
@@ -191,6 +264,153 @@ func TestFailedWithoutRetryPolicyWithNextExecutionTime(t *testing.T) {
 	}
 }
 
+func TestNewScheduleWithJitter(t *testing.T) {
+	s := NewSchedule("", "0 */15 * * * *", getStubDate, WithJitter("5m"))
+
+	offset := s.jitterOffset()
+	if offset < 0 || offset >= time.Minute*5 {
+		t.Errorf("expect offset within %+v, got %+v", time.Minute*5, offset)
+	}
+
+	if s.NextExecutionDate.Sub(getStubDate()) <= 0 {
+		t.Errorf("expect next execution after %+v, got %+v", getStubDate(), *s.NextExecutionDate)
+	}
+
+	if s.NextExecutionDate.Add(-offset).Minute()%15 != 0 {
+		t.Errorf("expect occurrence shifted by %+v, got %+v", offset, *s.NextExecutionDate)
+	}
+}
+
+func TestJitterOffsetIsStableForScheduleId(t *testing.T) {
+	id := uuid.MustParse("ad39c83f-59b1-4f01-8c6d-0196ce59127f")
+	first := Schedule{Id: id, Jitter: "10m"}
+	second := Schedule{Id: id, Jitter: "10m"}
+
+	if first.jitterOffset() != second.jitterOffset() {
+		t.Errorf("expect result %+v, got %+v", first.jitterOffset(), second.jitterOffset())
+	}
+}
+
+func TestJitterOffsetWithoutValidJitter(t *testing.T) {
+	tests := map[string]struct {
+		jitter string
+	}{
+		"missing_jitter":        {jitter: ""},
+		"invalid_jitter":        {jitter: "1xd"},
+		"jitter_below_a_second": {jitter: "500ms"},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			s := Schedule{Id: uuid.New(), Jitter: test.jitter}
+
+			if s.jitterOffset() != 0 {
+				t.Errorf("expect result %+v, got %+v", 0, s.jitterOffset())
+			}
+		})
+	}
+}
+
+func TestSucceedWithJitterKeepsOffset(t *testing.T) {
+	s := NewSchedule("", "*/10 * * * * *", getStubDate, WithJitter("10s"))
+	offset := s.jitterOffset()
+
+	s.Succeed(func() time.Time { return getStubDate().Add(time.Minute) })
+
+	expected := getStubDate().Add(time.Minute + offset)
+	if offset == 0 {
+		expected = expected.Add(time.Second * 10)
+	}
+
+	if *s.NextExecutionDate != expected {
+		t.Errorf("expect result %+v, got %+v", expected, *s.NextExecutionDate)
+	}
+}
+
 func getStubDate() time.Time {
 	return time.Date(2000, time.January, 1, 0, 0, 0, 0, time.Local).Round(time.Second)
 }
+
+func TestIsAwaiting(t *testing.T) {
+	future := getStubDate().Add(time.Minute)
+
+	tests := map[string]struct {
+		status            ScheduleStatus
+		nextExecutionDate *time.Time
+
+		expected bool
+	}{
+		"due_waiting_schedule": {
+			status:            Waiting,
+			nextExecutionDate: func() *time.Time { d := getStubDate(); return &d }(),
+			expected:          true,
+		},
+		"future_waiting_schedule": {
+			status:            Waiting,
+			nextExecutionDate: &future,
+		},
+		"already_started_schedule": {
+			status: Scheduled,
+		},
+		"finished_schedule": {
+			status: Finished,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			s := Schedule{Status: test.status, NextExecutionDate: test.nextExecutionDate}
+
+			if result := s.IsAwaiting(getStubDate); result != test.expected {
+				t.Errorf("expect result %+v, got %+v", test.expected, result)
+			}
+		})
+	}
+}
+
+func TestDestination(t *testing.T) {
+	tests := map[string]struct {
+		exchange   string
+		routingKey string
+
+		expectExchange   string
+		expectRoutingKey string
+		expectJobQueue   bool
+	}{
+		"default_job_queue": {
+			expectExchange:   string(JobScheduleExchange),
+			expectRoutingKey: "slug",
+			expectJobQueue:   true,
+		},
+		"custom_exchange_with_slug_routing_key": {
+			exchange:         "orders",
+			expectExchange:   "orders",
+			expectRoutingKey: "slug",
+		},
+		"custom_exchange_with_routing_key": {
+			exchange:         "orders",
+			routingKey:       "orders.created",
+			expectExchange:   "orders",
+			expectRoutingKey: "orders.created",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			s := NewSchedule("description", "once", getStubDate,
+				WithConfiguration(Rabbitmq, ""),
+				WithRouting(test.exchange, test.routingKey),
+				WithJob("slug", nil))
+
+			exchange, routingKey := s.Destination()
+			if exchange != test.expectExchange || routingKey != test.expectRoutingKey {
+				t.Errorf("expect result %s %s, got %s %s", test.expectExchange, test.expectRoutingKey,
+					exchange, routingKey)
+			}
+
+			if s.UsesJobQueue() != test.expectJobQueue {
+				t.Errorf("expect job queue %v, got %v", test.expectJobQueue, s.UsesJobQueue())
+			}
+		})
+	}
+}
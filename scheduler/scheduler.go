@@ -4,7 +4,6 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
-	"fmt"
 	"slices"
 	"time"
 
@@ -13,14 +12,52 @@ import (
 )
 
 type Scheduler struct {
-	Id             uuid.UUID
-	Storage        StorageDriver
-	AsyncTransport AsyncTransportDriver
-	SyncTransport  SyncTransportDriver
-	logger         *zap.SugaredLogger
+	Id              uuid.UUID
+	Storage         StorageDriver
+	AsyncTransports map[TransportType]AsyncTransportDriver
+	SyncTransports  map[TransportType]SyncTransportDriver
+	rollups         *RollupConfig
+	retention       *RetentionConfig
+	partitions      *PartitionConfig
+	reconciliation  *QueueReconciliationConfig
+	pull            *PullConfig
+	logger          *zap.SugaredLogger
+}
+
+type Option func(*Scheduler)
+
+func WithRollups(config RollupConfig) Option {
+	return func(s *Scheduler) {
+		s.rollups = &config
+	}
+}
+
+func WithRetention(config RetentionConfig) Option {
+	return func(s *Scheduler) {
+		s.retention = &config
+	}
+}
+
+func WithPartitions(config PartitionConfig) Option {
+	return func(s *Scheduler) {
+		s.partitions = &config
+	}
+}
+
+func WithQueueReconciliation(config QueueReconciliationConfig) Option {
+	return func(s *Scheduler) {
+		s.reconciliation = &config
+	}
+}
+
+func WithPull(config PullConfig) Option {
+	return func(s *Scheduler) {
+		s.pull = &config
+	}
 }
 
 type JobStatusEvent struct {
+	EventId    uuid.UUID `json:"eventId"`
 	ScheduleId uuid.UUID `json:"scheduleId"`
 	GroupId    uuid.UUID `json:"groupId"`
 	JobRunId   uuid.UUID `json:"jobRunId"`
@@ -29,10 +66,13 @@ type JobStatusEvent struct {
 }
 
 type ScheduleJobEvent struct {
-	ScheduleId uuid.UUID       `json:"scheduleId"`
-	GroupId    uuid.UUID       `json:"groupId"`
-	JobRunId   uuid.UUID       `json:"jobRunId"`
-	Data       *map[string]any `json:"data"`
+	Job           string          `json:"job"`
+	ScheduleId    uuid.UUID       `json:"scheduleId"`
+	GroupId       uuid.UUID       `json:"groupId"`
+	JobRunId      uuid.UUID       `json:"jobRunId"`
+	Attempt       int             `json:"attempt"`
+	ScheduledDate time.Time       `json:"scheduledDate"`
+	Data          *map[string]any `json:"data"`
 }
 
 var (
@@ -42,6 +82,12 @@ var (
 	ErrReceivedStatusForUnknownJobRun = &Error{
 		Code: "UNKNOWN_JOB_RUN",
 		Msg:  "received status for unknown job run"}
+	ErrStaleJobRunGroup = &Error{
+		Code: "STALE_JOB_RUN_GROUP",
+		Msg:  "received status for job run of past occurrence"}
+	ErrInvalidJobStatus = &Error{
+		Code: "INVALID_JOB_STATUS",
+		Msg:  "received invalid job status"}
 	ErrFetchAwaitingSchedules = &Error{
 		Code: "FETCH_AWAITING_SCHEDULES_ERROR",
 		Msg:  "fetch awaiting schedules failed"}
@@ -53,27 +99,54 @@ const MAX_SCHEDULES_CONCURRENCY = 2
 
 var Supports []string
 
-func Start(ctx context.Context, storage StorageDriver, asyncTransport AsyncTransportDriver,
-	syncTransport SyncTransportDriver, supports []string, logger *zap.SugaredLogger) *Scheduler {
+func Start(ctx context.Context, storage StorageDriver, asyncTransports map[TransportType]AsyncTransportDriver,
+	syncTransports map[TransportType]SyncTransportDriver, supports []string, logger *zap.SugaredLogger,
+	opts ...Option) *Scheduler {
 
 	scheduler := Scheduler{
-		Id:             uuid.New(),
-		Storage:        storage,
-		AsyncTransport: asyncTransport,
-		SyncTransport:  syncTransport,
-		logger:         logger,
+		Id:              uuid.New(),
+		Storage:         storage,
+		AsyncTransports: asyncTransports,
+		SyncTransports:  syncTransports,
+		logger:          logger,
+	}
+
+	for _, opt := range opts {
+		opt(&scheduler)
 	}
 
 	Supports = supports
 
 	logger.Infof("starting scheduler with id %s", scheduler.Id)
 
-	if slices.Contains(Supports, "rabbitmq") {
-		go scheduler.listenForJobStatusEvents(ctx)
+	for transportType, transport := range scheduler.AsyncTransports {
+		go scheduler.listenForJobStatusEvents(ctx, transportType, transport)
+	}
+
+	if len(scheduler.AsyncTransports) > 0 {
+		go scheduler.relayOutbox(ctx)
+	}
+
+	if _, ok := scheduler.asyncTransport(Rabbitmq); ok && scheduler.reconciliation != nil {
+		go scheduler.reconcileJobQueues(ctx, *scheduler.reconciliation)
 	}
 
+	go scheduler.purgeProcessedMessages(ctx)
+
 	go scheduler.staleJobSearch(ctx)
 
+	if scheduler.rollups != nil {
+		go scheduler.aggregateRollups(ctx, *scheduler.rollups)
+	}
+
+	if scheduler.partitions != nil {
+		go scheduler.maintainPartitions(ctx, *scheduler.partitions)
+	}
+
+	if scheduler.retention != nil {
+		go scheduler.purgeExpiredData(ctx, *scheduler.retention)
+	}
+
 	go func() {
 		for {
 			err := scheduler.processTick(ctx)
@@ -88,6 +161,67 @@ func Start(ctx context.Context, storage StorageDriver, asyncTransport AsyncTrans
 	return &scheduler
 }
 
+func (s *Scheduler) RollupsEnabled() bool {
+	return s.rollups != nil
+}
+
+// syncTransport returns sync transport of given type when it is enabled
+func (s *Scheduler) syncTransport(transportType TransportType) (SyncTransportDriver, bool) {
+	transport, ok := s.SyncTransports[transportType]
+	return transport, ok && slices.Contains(Supports, string(transportType))
+}
+
+// transports returns transport types this instance can dispatch, eg. websocket is served by single instance
+func (s *Scheduler) transports() []TransportType {
+	transports := make([]TransportType, 0, len(s.SyncTransports)+len(s.AsyncTransports)+1)
+	for transportType := range s.SyncTransports {
+		if _, ok := s.syncTransport(transportType); ok {
+			transports = append(transports, transportType)
+		}
+	}
+
+	for transportType := range s.AsyncTransports {
+		if _, ok := s.asyncTransport(transportType); ok {
+			transports = append(transports, transportType)
+		}
+	}
+
+	if s.pullEnabled() {
+		transports = append(transports, Pull)
+	}
+	slices.Sort(transports)
+
+	return transports
+}
+
+// asyncTransport returns async transport of given type when it is enabled
+func (s *Scheduler) asyncTransport(transportType TransportType) (AsyncTransportDriver, bool) {
+	transport, ok := s.AsyncTransports[transportType]
+	return transport, ok && slices.Contains(Supports, string(transportType))
+}
+
+// HealthChecker is implemented by dependencies able to report their availability
+type HealthChecker interface {
+	Health(ctx context.Context) error
+}
+
+// Health returns availability of scheduler dependencies, nil error means component is healthy
+func (s *Scheduler) Health(ctx context.Context) map[string]error {
+	components := map[string]error{}
+
+	if storage, ok := s.Storage.(HealthChecker); ok {
+		components["storage"] = storage.Health(ctx)
+	}
+
+	for transportType, transport := range s.AsyncTransports {
+		if checker, ok := transport.(HealthChecker); ok && slices.Contains(Supports, string(transportType)) {
+			components[string(transportType)] = checker.Health(ctx)
+		}
+	}
+
+	return components
+}
+
 func (s *Scheduler) staleJobSearch(ctx context.Context) {
 	s.logger.Info("starting stale jobs searching")
 
@@ -108,7 +242,7 @@ func (s *Scheduler) staleJobSearch(ctx context.Context) {
 }
 
 func (s *Scheduler) processTick(ctx context.Context) error {
-	schedules, err := s.Storage.GetAwaitingSchedules(ctx)
+	schedules, err := s.Storage.GetAwaitingSchedules(ctx, s.transports())
 	if err != nil {
 		return errors.Join(ErrFetchAwaitingSchedules, err)
 	}
@@ -125,86 +259,149 @@ func (s *Scheduler) processTick(ctx context.Context) error {
 
 func (s *Scheduler) processSchedule(ctx context.Context, schedule *Schedule, sem chan struct{}) {
 	defer func() { <-sem }()
-	schedule.Start(time.Now)
-	jobRun := NewJobRun(schedule.Id, schedule.GroupId, time.Now)
-
-	var schueduleStartErr error
-	switch schedule.Configuration.TransportType {
-	case Http:
-		schueduleStartErr = s.handleHttp(ctx, schedule, &jobRun)
-	case Rabbitmq:
-		schueduleStartErr = s.handleRabbitMq(ctx, schedule, &jobRun)
+
+	// transport resources are prepared outside of transaction, so row lock is not held during network calls
+	var prepareErr error
+	syncTransport, sync := s.syncTransport(schedule.Configuration.TransportType)
+	asyncTransport, async := s.asyncTransport(schedule.Configuration.TransportType)
+	pull := schedule.Configuration.TransportType == Pull && s.pullEnabled()
+	switch {
+	case sync:
+		// job is started after job run is stored
+	case pull:
+		// job waits in storage until worker claims it
+	case async:
+		prepareErr = asyncTransport.Prepare(ctx, schedule)
 	default:
-		schueduleStartErr = fmt.Errorf("unsupported transport type - %s",
+		// other instance supporting the transport starts schedule, its run is not failed here
+		s.logger.Warnf("skipping schedule %s of unsupported transport type %s", schedule.Id,
 			schedule.Configuration.TransportType)
+		return
 	}
 
-	if schueduleStartErr != nil {
-		s.logger.Errorf("failed to start job for schedule %s - %v", schedule.Id, schueduleStartErr)
-		groupRuns, innerErr := s.Storage.GetJobRunGroup(ctx, schedule.Id, jobRun.GroupId)
-		if innerErr != nil {
-			s.logger.Errorf("error getting job run group for schedule %s - %v", schedule.Id, innerErr)
-			jobRun.Failed(errors.Join(schueduleStartErr, innerErr).Error(), time.Now)
-			schedule.Failed(1, time.Now) // TODO: probably infinite loop
-		} else {
-			jobRun.Failed(schueduleStartErr.Error(), time.Now)
-			// len + 1 because current job run is not yet stored in persistent storage
-			schedule.Failed(len(groupRuns)+1, time.Now)
+	var jobRun JobRun
+	started := false
+	err := s.Storage.WithTx(ctx, func(tx StorageTx) error {
+		locked, err := tx.GetScheduleForUpdate(ctx, schedule.Id)
+		if err != nil {
+			return err
 		}
 
-	} else {
-		s.logger.Infof("scheduled job %s/%s, run %s", schedule.Job.Id, schedule.Job.Slug, jobRun.Id)
-	}
+		// schedule could be started by other instance or changed by job status since it was fetched
+		if locked == nil || !locked.IsAwaiting(time.Now) {
+			return nil
+		}
+
+		scheduledDate := *locked.NextExecutionDate
+		locked.Start(time.Now)
+		jobRun = NewJobRun(locked.Id, locked.GroupId, locked.Attempt, scheduledDate, time.Now)
+
+		if prepareErr != nil {
+			jobRun.Failed(prepareErr.Error(), time.Now)
+			locked.Failed(jobRun.Attempt, time.Now)
+		} else if async {
+			// message is published by outbox relay after commit, so job statuses can't arrive before job run exists
+			message, err := newScheduleJobMessage(asyncTransport, locked, &jobRun)
+			if err != nil {
+				return err
+			}
+
+			if err = tx.AddOutboxMessage(ctx, message); err != nil {
+				return err
+			}
+		} else if pull {
+			job, err := NewPullJob(locked, &jobRun, time.Now)
+			if err != nil {
+				return err
+			}
+
+			if err = tx.AddPullJob(ctx, job); err != nil {
+				return err
+			}
+		}
+
+		if err = tx.AddJobRun(ctx, jobRun); err != nil {
+			return err
+		}
+
+		if err = tx.UpdateSchedule(ctx, *locked); err != nil {
+			return err
+		}
+
+		*schedule = *locked
+		started = prepareErr == nil
+
+		return nil
+	})
 
-	// TODO: starting schedule should be transactional so outbox is most likely needed for async transport
-	// Job run has to be created before starting job because we can hit race condition with job statuses
-	err := s.Storage.AddJobRun(ctx, jobRun)
 	if err != nil {
-		s.logger.Errorf("error adding job run - %v", err)
+		s.logger.Errorf("error starting schedule %s - %v", schedule.Id, err)
 		return
 	}
 
-	err = s.Storage.UpdateSchedule(ctx, *schedule)
-	if err != nil {
-		s.logger.Errorf("error updating schedule status - %v", err)
+	if prepareErr != nil {
+		s.logger.Errorf("failed to start job for schedule %s - %v", schedule.Id, prepareErr)
 		return
 	}
-}
 
-func (s *Scheduler) handleHttp(ctx context.Context, schedule *Schedule, jobRun *JobRun) error {
-	err := s.SyncTransport.Start(ctx, schedule.Configuration.Url,
-		ScheduleJobRequest{
-			ScheduleId: schedule.Id,
-			GroupId:    jobRun.GroupId,
-			JobRunId:   jobRun.Id,
-			Job:        schedule.Job.Slug,
-			Data:       schedule.Job.Data,
-		})
+	if !started {
+		return
+	}
 
-	if err != nil {
-		return err
+	if sync {
+		if err = s.startSyncJob(ctx, syncTransport, schedule, &jobRun); err != nil {
+			s.logger.Errorf("failed to start job for schedule %s - %v", schedule.Id, err)
+			s.failJobRun(ctx, jobRun.Id, err)
+			return
+		}
 	}
 
-	return nil
+	s.logger.Infof("scheduled job %s/%s, run %s attempt %d", schedule.Job.Id, schedule.Job.Slug,
+		jobRun.Id, jobRun.Attempt)
 }
 
-func (s *Scheduler) handleRabbitMq(ctx context.Context, schedule *Schedule, jobRun *JobRun) error {
-	err := s.AsyncTransport.CreateQueue(schedule.Job.Slug)
-	if err != nil {
-		return err
+// failJobRun applies failed start of already stored job run same way as failed job status
+func (s *Scheduler) failJobRun(ctx context.Context, jobRunId uuid.UUID, reason error) {
+	// job run is read without lock only to find its schedule, schedule has to be locked first
+	jobRun, err := s.Storage.GetJobRun(ctx, jobRunId)
+	if err != nil || jobRun == nil {
+		s.logger.Errorf("error getting failed job run %s - %v", jobRunId, err)
+		return
 	}
 
-	err = s.AsyncTransport.BindQueue(schedule.Job.Slug, string(JobScheduleExchange), schedule.Job.Slug)
+	err = s.Storage.WithTx(ctx, func(tx StorageTx) error {
+		return failJobRunTx(ctx, tx, jobRun, reason)
+	})
+
 	if err != nil {
-		return err
+		s.logger.Errorf("error storing failed job run %s - %v", jobRun.Id, err)
 	}
+}
+
+// failJobRunTx fails job run within transaction of the caller
+func failJobRunTx(ctx context.Context, tx StorageTx, jobRun *JobRun, reason error) error {
+	_, err := applyJobStatusTx(ctx, tx, JobStatusEvent{
+		ScheduleId: jobRun.ScheduleId,
+		GroupId:    jobRun.GroupId,
+		JobRunId:   jobRun.Id,
+		Status:     string(JobFailed),
+		Reason:     reason.Error(),
+	})
+
+	return err
+}
 
-	err = s.AsyncTransport.Publish(ctx, string(JobScheduleExchange), schedule.Job.Slug,
-		ScheduleJobEvent{
-			ScheduleId: schedule.Id,
-			GroupId:    jobRun.GroupId,
-			JobRunId:   jobRun.Id,
-			Data:       schedule.Job.Data,
+func (s *Scheduler) startSyncJob(ctx context.Context, transport SyncTransportDriver, schedule *Schedule,
+	jobRun *JobRun) error {
+	err := transport.Start(ctx, schedule.Configuration.Url,
+		ScheduleJobRequest{
+			ScheduleId:    schedule.Id,
+			GroupId:       jobRun.GroupId,
+			JobRunId:      jobRun.Id,
+			Attempt:       jobRun.Attempt,
+			ScheduledDate: jobRun.ScheduledDate,
+			Job:           schedule.Job.Slug,
+			Data:          schedule.Job.Data,
 		})
 
 	if err != nil {
@@ -214,9 +411,32 @@ func (s *Scheduler) handleRabbitMq(ctx context.Context, schedule *Schedule, jobR
 	return nil
 }
 
-func (s *Scheduler) listenForJobStatusEvents(ctx context.Context) {
+func newScheduleJobMessage(transport AsyncTransportDriver, schedule *Schedule,
+	jobRun *JobRun) (OutboxMessage, error) {
+	exchange, routingKey := transport.Route(schedule)
+
+	return NewOutboxMessage(jobRun.Id, schedule.Configuration.TransportType, exchange, routingKey,
+		newScheduleJobEvent(schedule, jobRun), time.Now)
+}
+
+func newScheduleJobEvent(schedule *Schedule, jobRun *JobRun) ScheduleJobEvent {
+	return ScheduleJobEvent{
+		Job:           schedule.Job.Slug,
+		ScheduleId:    schedule.Id,
+		GroupId:       jobRun.GroupId,
+		JobRunId:      jobRun.Id,
+		Attempt:       jobRun.Attempt,
+		ScheduledDate: jobRun.ScheduledDate,
+		Data:          schedule.Job.Data,
+	}
+}
+
+func (s *Scheduler) listenForJobStatusEvents(ctx context.Context, transportType TransportType,
+	transport AsyncTransportDriver) {
+	s.logger.Infof("listening for job status events from %s", transportType)
+
 	for {
-		err := s.AsyncTransport.Subscribe(ctx, string(JobStatusQueue), func(message []byte) error {
+		err := transport.Subscribe(ctx, string(JobStatusQueue), func(message []byte) error {
 			err := s.HandleJobStatusEvent(ctx, message)
 			if err != nil {
 				s.logger.Errorf("error during job status event processing - %v", err)
@@ -226,15 +446,27 @@ func (s *Scheduler) listenForJobStatusEvents(ctx context.Context) {
 			return nil
 		})
 
-		if err != nil {
-			s.logger.Errorf("error during subscribing to process job status events - %v", err)
-			time.Sleep(time.Second)
-		} else {
-			break
+		if ctx.Err() != nil {
+			return
 		}
+
+		s.logger.Errorf("error during subscribing to process job status events from %s - %v", transportType, err)
+		time.Sleep(time.Second)
 	}
 }
 
+// jobStatusEventNamespace seeds event ids of events sent by workers without event id
+var jobStatusEventNamespace = uuid.MustParse("8f2d5c1e-6a4b-4e0f-9d3a-2b7c1e5f4a90")
+
+func (e JobStatusEvent) id() uuid.UUID {
+	if e.EventId != uuid.Nil {
+		return e.EventId
+	}
+
+	return uuid.NewSHA1(jobStatusEventNamespace, []byte(e.JobRunId.String()+e.Status))
+}
+
+// HandleJobStatusEvent decodes and applies job status exactly once, redelivered events are skipped
 func (s *Scheduler) HandleJobStatusEvent(ctx context.Context, message []byte) error {
 	jobStatus := JobStatusEvent{}
 	err := json.Unmarshal(message, &jobStatus)
@@ -243,68 +475,139 @@ func (s *Scheduler) HandleJobStatusEvent(ctx context.Context, message []byte) er
 		return err
 	}
 
+	return s.ApplyJobStatusEvent(ctx, jobStatus)
+}
+
+// ApplyJobStatusEvent applies job status exactly once, redelivered events are skipped
+func (s *Scheduler) ApplyJobStatusEvent(ctx context.Context, jobStatus JobStatusEvent) error {
 	s.logger.Infof("received status %+v", jobStatus)
 
-	schedule, err := s.Storage.GetScheduleById(ctx, jobStatus.ScheduleId)
+	var finished *Schedule
+	// recorded is false only for event which already is in inbox, rejected events are recorded but not applied
+	recorded := false
+	err := s.Storage.WithTx(ctx, func(tx StorageTx) error {
+		var err error
+		recorded, err = tx.AddInboxMessage(ctx, jobStatus.id(), jobStatus.JobRunId)
+		if err != nil || !recorded {
+			return err
+		}
+
+		schedule, err := applyJobStatusTx(ctx, tx, jobStatus)
+		if err != nil && isRejectedJobStatus(err) {
+			// redelivery can't make such event applicable, it is recorded in inbox, so it is acked and skipped
+			s.logger.Warnf("rejected job status event %s for job run %s - %v", jobStatus.id(),
+				jobStatus.JobRunId, err)
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if schedule.Status == Finished {
+			finished = schedule
+		}
+
+		return nil
+	})
+
 	if err != nil {
 		return err
 	}
 
-	if schedule == nil {
-		return ErrReceivedStatusForUnknownSchedule
+	if !recorded {
+		s.logger.Infof("skipping already processed event %s", jobStatus.id())
+		return nil
+	}
+
+	if finished != nil {
+		s.onScheduleFinish(finished)
 	}
 
-	groupRuns, err := s.Storage.GetJobRunGroup(ctx, jobStatus.ScheduleId, jobStatus.GroupId)
+	return nil
+}
+
+// isRejectedJobStatus reports whether job status can never be applied, no matter how many times it is delivered
+func isRejectedJobStatus(err error) bool {
+	return errors.Is(err, ErrStaleJobRunGroup) || errors.Is(err, ErrIllegalJobRunTransition) ||
+		errors.Is(err, ErrInvalidJobStatus)
+}
+
+// applyJobStatusTx applies job status to locked schedule and job run, returns updated schedule
+func applyJobStatusTx(ctx context.Context, tx StorageTx, event JobStatusEvent) (*Schedule, error) {
+	schedule, err := tx.GetScheduleForUpdate(ctx, event.ScheduleId)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	var jobRun *JobRun
-	for _, jr := range groupRuns {
-		if jr.Id == jobStatus.JobRunId {
-			jobRun = jr
-			break
-		}
+	jobRun, err := tx.GetJobRunForUpdate(ctx, event.JobRunId)
+	if err != nil {
+		return nil, err
 	}
 
-	if jobRun == nil {
-		return ErrReceivedStatusForUnknownJobRun
+	if err = applyJobStatus(event, schedule, jobRun, time.Now); err != nil {
+		return nil, err
 	}
 
-	switch jobStatus.Status {
-	case string(JobFailed):
-		{
-			jobRun.Failed(jobStatus.Reason, time.Now)
-			schedule.Failed(len(groupRuns), time.Now)
-		}
-	case string(JobSucceed):
-		{
-			jobRun.Succeed(time.Now)
-			schedule.Succeed(time.Now)
-			s.onScheduleFinish(schedule)
-		}
+	if err = tx.UpdateJobRun(ctx, *jobRun); err != nil {
+		return nil, err
 	}
 
-	err = s.Storage.UpdateJobRun(ctx, *jobRun)
-	if err != nil {
-		return err
+	// pulled job is finished, so it can't be claimed again after its lease expires
+	if err = tx.DeletePullJob(ctx, jobRun.Id); err != nil {
+		return nil, err
 	}
 
-	err = s.Storage.UpdateSchedule(ctx, *schedule)
-	if err != nil {
-		return err
+	if err = tx.UpdateSchedule(ctx, *schedule); err != nil {
+		return nil, err
+	}
+
+	return schedule, nil
+}
+
+// applyJobStatus moves job run to its final status, only status of the current attempt of the current
+// occurrence drives schedule, status of superseded attempt is only recorded on its job run
+func applyJobStatus(event JobStatusEvent, schedule *Schedule, jobRun *JobRun, now func() time.Time) error {
+	if schedule == nil {
+		return ErrReceivedStatusForUnknownSchedule
+	}
+
+	if jobRun == nil || jobRun.ScheduleId != event.ScheduleId || jobRun.GroupId != event.GroupId {
+		return ErrReceivedStatusForUnknownJobRun
+	}
+
+	if jobRun.GroupId != schedule.GroupId {
+		return ErrStaleJobRunGroup
+	}
+
+	current := jobRun.Attempt == schedule.Attempt
+
+	switch JobRunStatus(event.Status) {
+	case JobFailed:
+		if err := jobRun.Failed(event.Reason, now); err != nil {
+			return err
+		}
+
+		if current {
+			schedule.Failed(jobRun.Attempt, now)
+		}
+	case JobSucceed:
+		if err := jobRun.Succeed(now); err != nil {
+			return err
+		}
+
+		if current {
+			schedule.Succeed(now)
+		}
+	default:
+		return ErrInvalidJobStatus
 	}
 
 	return nil
 }
 
 func (s *Scheduler) onScheduleFinish(schedule *Schedule) {
-	if schedule.Frequency == string(Once) &&
-		schedule.Configuration.TransportType == Rabbitmq {
-		err := s.AsyncTransport.DeleteQueue(schedule.Job.Slug)
-		if err != nil {
-			s.logger.Errorf("error during deleting queue - %s", err)
-			return
-		}
+	if schedule.Frequency == string(Once) {
+		s.releaseJobQueue(context.Background(), schedule)
 	}
 }
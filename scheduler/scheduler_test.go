@@ -0,0 +1,375 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestApplyJobStatus(t *testing.T) {
+	tests := map[string]struct {
+		status      JobRunStatus
+		runStatus   JobRunStatus
+		runAttempt  int
+		staleGroup  bool
+		unknownRun  bool
+		withRetries bool
+
+		expectErr            error
+		expectRunStatus      JobRunStatus
+		expectScheduleStatus ScheduleStatus
+		expectNewGroup       bool
+	}{
+		"succeed_moves_schedule_to_next_occurrence": {
+			status:               JobSucceed,
+			runStatus:            JobWaiting,
+			runAttempt:           1,
+			expectRunStatus:      JobSucceed,
+			expectScheduleStatus: Waiting,
+			expectNewGroup:       true,
+		},
+		"failed_with_retries_keeps_occurrence": {
+			status:               JobFailed,
+			runStatus:            JobWaiting,
+			runAttempt:           1,
+			withRetries:          true,
+			expectRunStatus:      JobFailed,
+			expectScheduleStatus: Waiting,
+		},
+		"failed_after_succeed_is_rejected": {
+			status:               JobFailed,
+			runStatus:            JobSucceed,
+			runAttempt:           1,
+			expectErr:            ErrIllegalJobRunTransition,
+			expectRunStatus:      JobSucceed,
+			expectScheduleStatus: Scheduled,
+		},
+		"status_of_stale_group_is_rejected": {
+			status:               JobFailed,
+			runStatus:            JobWaiting,
+			runAttempt:           1,
+			staleGroup:           true,
+			expectErr:            ErrStaleJobRunGroup,
+			expectRunStatus:      JobWaiting,
+			expectScheduleStatus: Scheduled,
+		},
+		"status_of_superseded_attempt_does_not_change_schedule": {
+			status:               JobFailed,
+			runStatus:            JobWaiting,
+			runAttempt:           0,
+			withRetries:          true,
+			expectRunStatus:      JobFailed,
+			expectScheduleStatus: Scheduled,
+		},
+		"status_of_unknown_job_run_is_rejected": {
+			status:               JobSucceed,
+			unknownRun:           true,
+			expectErr:            ErrReceivedStatusForUnknownJobRun,
+			expectScheduleStatus: Scheduled,
+		},
+		"invalid_status_is_rejected": {
+			status:               JobWaiting,
+			runStatus:            JobWaiting,
+			runAttempt:           1,
+			expectErr:            ErrInvalidJobStatus,
+			expectRunStatus:      JobWaiting,
+			expectScheduleStatus: Scheduled,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			schedule := NewSchedule("test", "*/10 * * * * *", getStubDate)
+			if test.withRetries {
+				schedule.RetryPolicy, _ = NewRetryPolicy(Constant, 3, "10s")
+			}
+			schedule.Start(getStubDate)
+			groupId := schedule.GroupId
+
+			var jobRun *JobRun
+			if !test.unknownRun {
+				run := NewJobRun(schedule.Id, schedule.GroupId, test.runAttempt, getStubDate(), getStubDate)
+				run.Status = test.runStatus
+				jobRun = &run
+			}
+
+			event := JobStatusEvent{
+				EventId:    uuid.New(),
+				ScheduleId: schedule.Id,
+				GroupId:    schedule.GroupId,
+				JobRunId:   uuid.New(),
+				Status:     string(test.status),
+			}
+
+			if test.staleGroup {
+				schedule.GroupId = uuid.New()
+				groupId = schedule.GroupId
+			}
+
+			err := applyJobStatus(event, &schedule, jobRun, getStubDate)
+
+			if !errors.Is(err, test.expectErr) {
+				t.Errorf("expect error %+v, got %+v", test.expectErr, err)
+			}
+
+			if jobRun != nil && jobRun.Status != test.expectRunStatus {
+				t.Errorf("expect job run status %+v, got %+v", test.expectRunStatus, jobRun.Status)
+			}
+
+			if schedule.Status != test.expectScheduleStatus {
+				t.Errorf("expect schedule status %+v, got %+v", test.expectScheduleStatus, schedule.Status)
+			}
+
+			if (schedule.GroupId != groupId) != test.expectNewGroup {
+				t.Errorf("expect new group %v, got %v", test.expectNewGroup, schedule.GroupId != groupId)
+			}
+		})
+	}
+}
+
+func TestJobStatusEventIdWithoutEventId(t *testing.T) {
+	event := JobStatusEvent{JobRunId: uuid.New(), Status: string(JobSucceed)}
+	duplicate := event
+
+	if event.id() != duplicate.id() {
+		t.Errorf("expect equal ids, got %s and %s", event.id(), duplicate.id())
+	}
+
+	failed := event
+	failed.Status = string(JobFailed)
+	if event.id() == failed.id() {
+		t.Errorf("expect different ids for different statuses, got %s", event.id())
+	}
+}
+
+// txStorageFake keeps schedules, job runs, inbox and outbox in memory, changes of failed transaction are rolled back
+type txStorageFake struct {
+	StorageDriver
+	schedules map[uuid.UUID]Schedule
+	jobRuns   map[uuid.UUID]JobRun
+	inbox     map[uuid.UUID]bool
+	outbox    map[uuid.UUID]OutboxMessage
+}
+
+type storageTxFake struct {
+	StorageTx
+	storage *txStorageFake
+}
+
+func newTxStorageFake() *txStorageFake {
+	return &txStorageFake{
+		schedules: map[uuid.UUID]Schedule{},
+		jobRuns:   map[uuid.UUID]JobRun{},
+		inbox:     map[uuid.UUID]bool{},
+		outbox:    map[uuid.UUID]OutboxMessage{},
+	}
+}
+
+func (s *txStorageFake) WithTx(ctx context.Context, fn func(tx StorageTx) error) error {
+	schedules, jobRuns, inbox, outbox := maps.Clone(s.schedules), maps.Clone(s.jobRuns), maps.Clone(s.inbox),
+		maps.Clone(s.outbox)
+
+	if err := fn(storageTxFake{storage: s}); err != nil {
+		s.schedules, s.jobRuns, s.inbox, s.outbox = schedules, jobRuns, inbox, outbox
+		return err
+	}
+
+	return nil
+}
+
+func (t storageTxFake) GetScheduleForUpdate(ctx context.Context, id uuid.UUID) (*Schedule, error) {
+	if schedule, ok := t.storage.schedules[id]; ok {
+		return &schedule, nil
+	}
+
+	return nil, nil
+}
+
+func (t storageTxFake) GetJobRunForUpdate(ctx context.Context, id uuid.UUID) (*JobRun, error) {
+	if jobRun, ok := t.storage.jobRuns[id]; ok {
+		return &jobRun, nil
+	}
+
+	return nil, nil
+}
+
+func (t storageTxFake) UpdateSchedule(ctx context.Context, schedule Schedule) error {
+	t.storage.schedules[schedule.Id] = schedule
+	return nil
+}
+
+func (t storageTxFake) UpdateJobRun(ctx context.Context, jobRun JobRun) error {
+	t.storage.jobRuns[jobRun.Id] = jobRun
+	return nil
+}
+
+func (t storageTxFake) DeletePullJob(ctx context.Context, jobRunId uuid.UUID) error {
+	return nil
+}
+
+func (t storageTxFake) AddInboxMessage(ctx context.Context, eventId uuid.UUID, jobRunId uuid.UUID) (bool, error) {
+	if t.storage.inbox[eventId] {
+		return false, nil
+	}
+
+	t.storage.inbox[eventId] = true
+	return true, nil
+}
+
+func TestApplyRejectedJobStatusEvent(t *testing.T) {
+	schedule := NewSchedule("description", "once", getStubDate, WithJob("slug", nil))
+	schedule.Start(time.Now)
+
+	staleGroupId := uuid.New()
+	staleJobRun := NewJobRun(schedule.Id, staleGroupId, 1, getStubDate(), getStubDate)
+	jobRun := NewJobRun(schedule.Id, schedule.GroupId, schedule.Attempt, getStubDate(), getStubDate)
+
+	tests := map[string]struct {
+		event JobStatusEvent
+	}{
+		"stale_group": {
+			event: JobStatusEvent{ScheduleId: schedule.Id, GroupId: staleGroupId, JobRunId: staleJobRun.Id,
+				Status: string(JobSucceed)},
+		},
+		"invalid_status": {
+			event: JobStatusEvent{ScheduleId: schedule.Id, GroupId: schedule.GroupId, JobRunId: jobRun.Id,
+				Status: "unknown"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			storage := newTxStorageFake()
+			storage.schedules[schedule.Id] = schedule
+			storage.jobRuns[staleJobRun.Id] = staleJobRun
+			storage.jobRuns[jobRun.Id] = jobRun
+
+			core, logs := observer.New(zap.InfoLevel)
+			s := &Scheduler{Storage: storage, logger: zap.New(core).Sugar()}
+
+			if err := s.ApplyJobStatusEvent(context.Background(), test.event); err != nil {
+				t.Errorf("expect error %+v, got %+v", nil, err)
+			}
+
+			if !storage.inbox[test.event.id()] {
+				t.Errorf("expect result %+v, got %+v", "recorded event", storage.inbox)
+			}
+
+			if storage.jobRuns[test.event.JobRunId].Status != JobWaiting {
+				t.Errorf("expect result %+v, got %+v", JobWaiting, storage.jobRuns[test.event.JobRunId].Status)
+			}
+
+			// rejected event is not a duplicate, only its redelivery is
+			if skipped := logs.FilterMessageSnippet("already processed").Len(); skipped != 0 {
+				t.Errorf("expect result %+v, got %+v", 0, skipped)
+			}
+
+			if err := s.ApplyJobStatusEvent(context.Background(), test.event); err != nil {
+				t.Errorf("expect error %+v, got %+v", nil, err)
+			}
+
+			if skipped := logs.FilterMessageSnippet("already processed").Len(); skipped != 1 {
+				t.Errorf("expect result %+v, got %+v", 1, skipped)
+			}
+		})
+	}
+}
+
+func TestApplyIllegalJobRunTransition(t *testing.T) {
+	schedule := NewSchedule("description", "*/10 * * * * *", getStubDate, WithJob("slug", nil))
+	schedule.Start(time.Now)
+	jobRun := NewJobRun(schedule.Id, schedule.GroupId, schedule.Attempt, getStubDate(), getStubDate)
+
+	storage := newTxStorageFake()
+	storage.schedules[schedule.Id] = schedule
+	storage.jobRuns[jobRun.Id] = jobRun
+	s := &Scheduler{Storage: storage, logger: zap.NewNop().Sugar()}
+
+	succeed := JobStatusEvent{ScheduleId: schedule.Id, GroupId: schedule.GroupId, JobRunId: jobRun.Id,
+		Status: string(JobSucceed)}
+	if err := s.ApplyJobStatusEvent(context.Background(), succeed); err != nil {
+		t.Fatal(err)
+	}
+
+	// failed status of already succeeded run can never be applied
+	failed := succeed
+	failed.Status = string(JobFailed)
+	if err := s.ApplyJobStatusEvent(context.Background(), failed); err != nil {
+		t.Errorf("expect error %+v, got %+v", nil, err)
+	}
+
+	if !storage.inbox[failed.id()] || storage.jobRuns[jobRun.Id].Status != JobSucceed {
+		t.Errorf("expect result %+v, got %+v", JobSucceed, storage.jobRuns[jobRun.Id].Status)
+	}
+}
+
+func TestSchedulerTransports(t *testing.T) {
+	Supports = []string{string(Http), string(Kafka), string(Pull)}
+	websocket := NewWebsocketTransport(WebsocketConfig{}, zap.NewNop().Sugar())
+
+	tests := map[string]struct {
+		scheduler *Scheduler
+
+		expected []TransportType
+	}{
+		"without_websocket": {
+			scheduler: &Scheduler{
+				SyncTransports:  map[TransportType]SyncTransportDriver{Http: &HttpTransport{}},
+				AsyncTransports: map[TransportType]AsyncTransportDriver{Kafka: &publishFake{}},
+			},
+			expected: []TransportType{Http, Kafka},
+		},
+		"unsupported_websocket": {
+			scheduler: &Scheduler{
+				SyncTransports: map[TransportType]SyncTransportDriver{Http: &HttpTransport{}, Websocket: websocket},
+				pull:           &PullConfig{},
+			},
+			expected: []TransportType{Http, Pull},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if result := test.scheduler.transports(); !slices.Equal(result, test.expected) {
+				t.Errorf("expect result %+v, got %+v", test.expected, result)
+			}
+		})
+	}
+}
+
+func TestProcessUnsupportedSchedule(t *testing.T) {
+	Supports = []string{string(Http)}
+
+	schedule := NewSchedule("description", "once", getStubDate,
+		WithConfiguration(Websocket, ""),
+		WithJob("slug", nil))
+
+	storage := newTxStorageFake()
+	storage.schedules[schedule.Id] = schedule
+
+	// scheduler of instance without websocket workers
+	s := &Scheduler{
+		Storage:        storage,
+		SyncTransports: map[TransportType]SyncTransportDriver{Http: &HttpTransport{}},
+		logger:         zap.NewNop().Sugar(),
+	}
+
+	sem := make(chan struct{}, 1)
+	sem <- struct{}{}
+	s.processSchedule(context.Background(), &schedule, sem)
+
+	if len(storage.jobRuns) != 0 {
+		t.Errorf("expect result %+v, got %+v", "no job runs", storage.jobRuns)
+	}
+
+	if result := storage.schedules[schedule.Id]; result.Status != Waiting || result.Attempt != schedule.Attempt {
+		t.Errorf("expect result %+v, got %+v", schedule, result)
+	}
+}
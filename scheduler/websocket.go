@@ -0,0 +1,358 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultWebsocketHeartbeatTimeout = time.Second * 30
+	defaultWebsocketWriteTimeout     = time.Second * 10
+	defaultWebsocketMaxMessageSize   = 1 << 20
+
+	WorkerDisconnectedReason = "worker disconnected"
+)
+
+type FrameType string
+
+const (
+	// worker declares slugs it handles, it has to be the first frame of connection
+	RegisterFrame FrameType = "register"
+
+	// worker keeps connection alive between job statuses
+	HeartbeatFrame FrameType = "heartbeat"
+
+	// worker reports job status
+	StatusFrame FrameType = "status"
+
+	// timely pushes job to worker
+	JobFrame FrameType = "job"
+
+	// timely confirms processing of job status, status acked with error has to be resent by worker
+	AckFrame FrameType = "ack"
+)
+
+var (
+	ErrNoWorkerConnected = &Error{
+		Code: "NO_WORKER_CONNECTED",
+		Msg:  "no worker handling job is connected"}
+	ErrInvalidFrame = &Error{
+		Code: "INVALID_FRAME",
+		Msg:  "frame is not valid"}
+	ErrWebsocketInstanceRunning = &Error{
+		Code: "WEBSOCKET_INSTANCE_RUNNING",
+		Msg:  "websocket transport is already served by another instance"}
+)
+
+// WebsocketFrame is message exchanged with worker, fields are filled depending on type
+type WebsocketFrame struct {
+	Type     FrameType         `json:"type"`
+	Slugs    []string          `json:"slugs,omitempty"`
+	Job      *ScheduleJobEvent `json:"job,omitempty"`
+	Status   *JobStatusEvent   `json:"status,omitempty"`
+	JobRunId *uuid.UUID        `json:"jobRunId,omitempty"`
+	Error    string            `json:"error,omitempty"`
+}
+
+type WebsocketConfig struct {
+	HeartbeatTimeout time.Duration // connection without any frame for this long is dropped
+	WriteTimeout     time.Duration // deadline of pushing single job
+	MaxMessageSize   int64         // largest frame accepted from worker
+}
+
+// WebsocketTransport pushes jobs to workers connected over websocket, jobs of slug are spread over workers
+// which declared it, runs of dropped worker are failed. workers are known only to instance which accepted
+// them, so transport can be enabled on single instance only, see Pgsql.LockWebsocketInstance
+type WebsocketTransport struct {
+	config   WebsocketConfig
+	upgrader websocket.Upgrader
+	logger   *zap.SugaredLogger
+
+	mu      sync.Mutex
+	workers map[string][]*websocketWorker
+	next    atomic.Uint64
+}
+
+type websocketWorker struct {
+	id    uuid.UUID
+	conn  *websocket.Conn
+	slugs []string
+
+	writeMu sync.Mutex
+
+	mu       sync.Mutex
+	runs     map[uuid.UUID]ScheduleJobRequest // jobs pushed to worker without final status
+	reported map[uuid.UUID]bool               // runs whose status was received but could not be applied
+}
+
+func NewWebsocketTransport(config WebsocketConfig, logger *zap.SugaredLogger) *WebsocketTransport {
+	if config.HeartbeatTimeout <= 0 {
+		config.HeartbeatTimeout = defaultWebsocketHeartbeatTimeout
+	}
+
+	if config.WriteTimeout <= 0 {
+		config.WriteTimeout = defaultWebsocketWriteTimeout
+	}
+
+	if config.MaxMessageSize <= 0 {
+		config.MaxMessageSize = defaultWebsocketMaxMessageSize
+	}
+
+	return &WebsocketTransport{
+		config:  config,
+		logger:  logger,
+		workers: map[string][]*websocketWorker{},
+	}
+}
+
+// Start pushes job to connected worker of its slug with the fewest running jobs
+func (t *WebsocketTransport) Start(ctx context.Context, endpoint string, request ScheduleJobRequest) error {
+	worker := t.pick(request.Job)
+	if worker == nil {
+		return ErrNoWorkerConnected
+	}
+
+	// run is tracked before it is pushed, so status can't arrive for unknown run
+	worker.track(request)
+
+	err := worker.write(WebsocketFrame{Type: JobFrame, Job: &ScheduleJobEvent{
+		Job:           request.Job,
+		ScheduleId:    request.ScheduleId,
+		GroupId:       request.GroupId,
+		JobRunId:      request.JobRunId,
+		Attempt:       request.Attempt,
+		ScheduledDate: request.ScheduledDate,
+		Data:          request.Data,
+	}}, t.config.WriteTimeout)
+	if err != nil {
+		// failed push is reported by caller, so it is not failed again when connection drops
+		worker.untrack(request.JobRunId)
+		return fmt.Errorf("error during pushing job to worker %s - %w", worker.id, err)
+	}
+
+	return nil
+}
+
+// pick returns worker of slug with the fewest running jobs, ties are rotated
+func (t *WebsocketTransport) pick(slug string) *websocketWorker {
+	t.mu.Lock()
+	workers := t.workers[slug]
+	t.mu.Unlock()
+
+	if len(workers) == 0 {
+		return nil
+	}
+
+	offset := int(t.next.Add(1) % uint64(len(workers)))
+	var picked *websocketWorker
+	least := 0
+	for i := range workers {
+		worker := workers[(offset+i)%len(workers)]
+		if running := worker.running(); picked == nil || running < least {
+			picked, least = worker, running
+		}
+	}
+
+	return picked
+}
+
+// Serve upgrades request to websocket and serves worker until connection is dropped, statuses reported
+// by worker and failures of its unfinished runs are passed to apply
+func (t *WebsocketTransport) Serve(w http.ResponseWriter, req *http.Request,
+	apply func(ctx context.Context, event JobStatusEvent) error) {
+	conn, err := t.upgrader.Upgrade(w, req, nil)
+	if err != nil {
+		t.logger.Errorf("error during websocket upgrade - %v", err)
+		return
+	}
+	defer conn.Close()
+
+	conn.SetReadLimit(t.config.MaxMessageSize)
+	worker := &websocketWorker{id: uuid.New(), conn: conn, runs: map[uuid.UUID]ScheduleJobRequest{},
+		reported: map[uuid.UUID]bool{}}
+
+	ctx := context.WithoutCancel(req.Context())
+	if err = t.register(worker); err != nil {
+		t.logger.Errorf("error during registering worker %s - %v", worker.id, err)
+		return
+	}
+	t.logger.Infof("worker %s connected for %v", worker.id, worker.slugs)
+
+	err = t.receive(ctx, worker, apply)
+	t.unregister(worker)
+
+	// runs without status can't be finished by dropped worker, retry policy decides about them, runs with
+	// received status are not failed as worker reported their result
+	for _, run := range worker.drain() {
+		applyErr := apply(ctx, JobStatusEvent{
+			ScheduleId: run.ScheduleId,
+			GroupId:    run.GroupId,
+			JobRunId:   run.JobRunId,
+			Status:     string(JobFailed),
+			Reason:     WorkerDisconnectedReason,
+		})
+		if applyErr != nil {
+			t.logger.Errorf("error during failing job run %s of worker %s - %v", run.JobRunId, worker.id,
+				applyErr)
+		}
+	}
+
+	t.logger.Infof("worker %s disconnected - %v", worker.id, err)
+}
+
+// register reads register frame and adds worker to workers of its slugs
+func (t *WebsocketTransport) register(worker *websocketWorker) error {
+	frame, err := t.read(worker)
+	if err != nil {
+		return err
+	}
+
+	if frame.Type != RegisterFrame || len(frame.Slugs) == 0 {
+		return ErrInvalidFrame
+	}
+	worker.slugs = frame.Slugs
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, slug := range worker.slugs {
+		t.workers[slug] = append(t.workers[slug], worker)
+	}
+
+	return nil
+}
+
+func (t *WebsocketTransport) unregister(worker *websocketWorker) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, slug := range worker.slugs {
+		workers := make([]*websocketWorker, 0, len(t.workers[slug]))
+		for _, w := range t.workers[slug] {
+			if w != worker {
+				workers = append(workers, w)
+			}
+		}
+
+		if len(workers) == 0 {
+			delete(t.workers, slug)
+			continue
+		}
+		t.workers[slug] = workers
+	}
+}
+
+// receive processes frames of worker until connection is dropped or heartbeat is missed
+func (t *WebsocketTransport) receive(ctx context.Context, worker *websocketWorker,
+	apply func(ctx context.Context, event JobStatusEvent) error) error {
+	for {
+		frame, err := t.read(worker)
+		if err != nil {
+			return err
+		}
+
+		switch frame.Type {
+		case HeartbeatFrame:
+		case StatusFrame:
+			if frame.Status == nil {
+				return ErrInvalidFrame
+			}
+
+			ack := WebsocketFrame{Type: AckFrame, JobRunId: &frame.Status.JobRunId}
+			if err = apply(ctx, *frame.Status); err != nil {
+				t.logger.Errorf("error during job status processing from worker %s - %v", worker.id, err)
+				worker.report(frame.Status.JobRunId)
+				ack.Error = err.Error()
+			} else {
+				// run is finished by the status, so it is not failed when worker drops afterwards
+				worker.untrack(frame.Status.JobRunId)
+			}
+
+			if err = worker.write(ack, t.config.WriteTimeout); err != nil {
+				return err
+			}
+		default:
+			return ErrInvalidFrame
+		}
+	}
+}
+
+// read waits for next frame, any frame postpones heartbeat deadline
+func (t *WebsocketTransport) read(worker *websocketWorker) (WebsocketFrame, error) {
+	if err := worker.conn.SetReadDeadline(time.Now().Add(t.config.HeartbeatTimeout)); err != nil {
+		return WebsocketFrame{}, err
+	}
+
+	var frame WebsocketFrame
+	err := worker.conn.ReadJSON(&frame)
+
+	return frame, err
+}
+
+func (w *websocketWorker) write(frame WebsocketFrame, timeout time.Duration) error {
+	w.writeMu.Lock()
+	defer w.writeMu.Unlock()
+
+	if err := w.conn.SetWriteDeadline(time.Now().Add(timeout)); err != nil {
+		return err
+	}
+
+	return w.conn.WriteJSON(frame)
+}
+
+func (w *websocketWorker) track(run ScheduleJobRequest) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.runs[run.JobRunId] = run
+}
+
+func (w *websocketWorker) untrack(jobRunId uuid.UUID) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	delete(w.runs, jobRunId)
+	delete(w.reported, jobRunId)
+}
+
+// report marks run whose status was received, run stays tracked until its status is applied
+func (w *websocketWorker) report(jobRunId uuid.UUID) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if _, ok := w.runs[jobRunId]; ok {
+		w.reported[jobRunId] = true
+	}
+}
+
+func (w *websocketWorker) running() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return len(w.runs)
+}
+
+// drain removes all unfinished runs and returns those without received status
+func (w *websocketWorker) drain() []ScheduleJobRequest {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	runs := make([]ScheduleJobRequest, 0, len(w.runs))
+	for id, run := range w.runs {
+		if !w.reported[id] {
+			runs = append(runs, run)
+		}
+		delete(w.runs, id)
+	}
+	clear(w.reported)
+
+	return runs
+}
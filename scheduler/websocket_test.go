@@ -0,0 +1,236 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+)
+
+func startWebsocketTransport(t *testing.T) (*WebsocketTransport, string, chan JobStatusEvent) {
+	t.Helper()
+
+	return startFailingWebsocketTransport(t, nil)
+}
+
+// startFailingWebsocketTransport serves worker with apply which records status and returns given error
+func startFailingWebsocketTransport(t *testing.T, applyErr error) (*WebsocketTransport, string, chan JobStatusEvent) {
+	t.Helper()
+
+	transport := NewWebsocketTransport(WebsocketConfig{}, zap.NewNop().Sugar())
+	statuses := make(chan JobStatusEvent, 10)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		transport.Serve(w, req, func(ctx context.Context, event JobStatusEvent) error {
+			statuses <- event
+			return applyErr
+		})
+	}))
+	t.Cleanup(server.Close)
+
+	return transport, "ws" + strings.TrimPrefix(server.URL, "http"), statuses
+}
+
+// connectWorker registers worker for slug and waits until transport knows about it
+func connectWorker(t *testing.T, transport *WebsocketTransport, url string, slug string) *websocket.Conn {
+	t.Helper()
+
+	connected := workerCount(transport, slug)
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+
+	if err = conn.WriteJSON(WebsocketFrame{Type: RegisterFrame, Slugs: []string{slug}}); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 100; i++ {
+		if workerCount(transport, slug) > connected {
+			return conn
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+
+	t.Fatal("worker was not registered")
+	return nil
+}
+
+func workerCount(transport *WebsocketTransport, slug string) int {
+	transport.mu.Lock()
+	defer transport.mu.Unlock()
+
+	return len(transport.workers[slug])
+}
+
+func readFrame(t *testing.T, conn *websocket.Conn, frameType FrameType) WebsocketFrame {
+	t.Helper()
+
+	_ = conn.SetReadDeadline(time.Now().Add(time.Second * 5))
+
+	var frame WebsocketFrame
+	if err := conn.ReadJSON(&frame); err != nil {
+		t.Fatal(err)
+	}
+
+	if frame.Type != frameType {
+		t.Fatalf("expect result %+v, got %+v", frameType, frame)
+	}
+
+	return frame
+}
+
+func readJob(t *testing.T, conn *websocket.Conn) *ScheduleJobEvent {
+	t.Helper()
+
+	frame := readFrame(t, conn, JobFrame)
+	if frame.Job == nil {
+		t.Fatalf("expect result %+v, got %+v", JobFrame, frame)
+	}
+
+	return frame.Job
+}
+
+func writeStatus(t *testing.T, conn *websocket.Conn, job *ScheduleJobEvent, status JobRunStatus) {
+	t.Helper()
+
+	err := conn.WriteJSON(WebsocketFrame{Type: StatusFrame, Status: &JobStatusEvent{
+		ScheduleId: job.ScheduleId,
+		GroupId:    job.GroupId,
+		JobRunId:   job.JobRunId,
+		Status:     string(status),
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func newWebsocketJobRequest(slug string) ScheduleJobRequest {
+	return ScheduleJobRequest{
+		ScheduleId: uuid.New(),
+		GroupId:    uuid.New(),
+		JobRunId:   uuid.New(),
+		Attempt:    1,
+		Job:        slug,
+	}
+}
+
+func TestWebsocketStartWithoutWorker(t *testing.T) {
+	transport, _, _ := startWebsocketTransport(t)
+
+	err := transport.Start(context.Background(), "", newWebsocketJobRequest("slug"))
+	if !errors.Is(err, ErrNoWorkerConnected) {
+		t.Errorf("expect error %+v, got %+v", ErrNoWorkerConnected, err)
+	}
+}
+
+func TestWebsocketStatus(t *testing.T) {
+	transport, url, statuses := startWebsocketTransport(t)
+	conn := connectWorker(t, transport, url, "slug")
+
+	request := newWebsocketJobRequest("slug")
+	if err := transport.Start(context.Background(), "", request); err != nil {
+		t.Fatal(err)
+	}
+
+	job := readJob(t, conn)
+	if job.JobRunId != request.JobRunId || job.Job != "slug" {
+		t.Errorf("expect result %+v, got %+v", request, job)
+	}
+
+	writeStatus(t, conn, job, JobSucceed)
+
+	status := <-statuses
+	if status.JobRunId != request.JobRunId || status.Status != string(JobSucceed) {
+		t.Errorf("expect result %+v, got %+v", JobSucceed, status)
+	}
+
+	ack := readFrame(t, conn, AckFrame)
+	if ack.JobRunId == nil || *ack.JobRunId != request.JobRunId || ack.Error != "" {
+		t.Errorf("expect result %+v, got %+v", request.JobRunId, ack)
+	}
+
+	// finished run is not failed when worker disconnects
+	_ = conn.Close()
+	select {
+	case status = <-statuses:
+		t.Errorf("expect result %+v, got %+v", nil, status)
+	case <-time.After(time.Millisecond * 200):
+	}
+}
+
+func TestWebsocketLoadBalancing(t *testing.T) {
+	transport, url, _ := startWebsocketTransport(t)
+	first := connectWorker(t, transport, url, "slug")
+	second := connectWorker(t, transport, url, "slug")
+
+	for i := 0; i < 2; i++ {
+		if err := transport.Start(context.Background(), "", newWebsocketJobRequest("slug")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// worker with running job is skipped, so each worker gets one job
+	readJob(t, first)
+	readJob(t, second)
+}
+
+func TestWebsocketDisconnect(t *testing.T) {
+	transport, url, statuses := startWebsocketTransport(t)
+	conn := connectWorker(t, transport, url, "slug")
+
+	request := newWebsocketJobRequest("slug")
+	if err := transport.Start(context.Background(), "", request); err != nil {
+		t.Fatal(err)
+	}
+	readJob(t, conn)
+
+	_ = conn.Close()
+
+	status := <-statuses
+	if status.JobRunId != request.JobRunId || status.Status != string(JobFailed) ||
+		status.Reason != WorkerDisconnectedReason {
+		t.Errorf("expect result %+v, got %+v", JobFailed, status)
+	}
+
+	err := transport.Start(context.Background(), "", newWebsocketJobRequest("slug"))
+	if !errors.Is(err, ErrNoWorkerConnected) {
+		t.Errorf("expect error %+v, got %+v", ErrNoWorkerConnected, err)
+	}
+}
+
+func TestWebsocketStatusNotApplied(t *testing.T) {
+	transport, url, statuses := startFailingWebsocketTransport(t, errors.New("connection refused"))
+	conn := connectWorker(t, transport, url, "slug")
+
+	request := newWebsocketJobRequest("slug")
+	if err := transport.Start(context.Background(), "", request); err != nil {
+		t.Fatal(err)
+	}
+
+	job := readJob(t, conn)
+	writeStatus(t, conn, job, JobSucceed)
+	<-statuses
+
+	// worker is told to resend status
+	ack := readFrame(t, conn, AckFrame)
+	if ack.JobRunId == nil || *ack.JobRunId != request.JobRunId || ack.Error == "" {
+		t.Errorf("expect result %+v, got %+v", "ack with error", ack)
+	}
+
+	// run with received status is not failed when worker disconnects
+	_ = conn.Close()
+	select {
+	case status := <-statuses:
+		t.Errorf("expect result %+v, got %+v", nil, status)
+	case <-time.After(time.Millisecond * 200):
+	}
+}
@@ -2,7 +2,6 @@ package integration
 
 import (
 	"context"
-	"path/filepath"
 	"reflect"
 	"testing"
 	"time"
@@ -37,6 +36,10 @@ func TestCreateSchedule(t *testing.T) {
 		t.Fatal(err)
 	}
 
+	if err = pgStorage.MigrateUp(ctx); err != nil {
+		t.Fatal(err)
+	}
+
 	rp, _ := scheduler.NewRetryPolicy("constant", 5, "10s")
 	date := getStubDate().Add(time.Minute * 10)
 	newSchedule := scheduler.NewSchedule("test-description", "*/10 * * * * *", getStubDate,
@@ -67,7 +70,6 @@ func getStubDate() time.Time {
 func startPostgres(ctx context.Context) (*postgres.PostgresContainer, error) {
 	pgContainer, err := postgres.Run(ctx,
 		"postgres:15.3-alpine",
-		postgres.WithInitScripts(filepath.Join("../..", "database_schema.sql")),
 		postgres.WithDatabase("timely-integration-test-db"),
 		postgres.WithUsername("postgres"),
 		postgres.WithPassword("postgres"),
@@ -0,0 +1,73 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+	"timely/scheduler"
+
+	"github.com/google/uuid"
+	"github.com/testcontainers/testcontainers-go/modules/kafka"
+	"go.uber.org/zap"
+)
+
+func TestKafkaTransport(t *testing.T) {
+	ctx := context.Background()
+	kafkaContainer, err := kafka.Run(ctx, "confluentinc/confluent-local:7.5.0",
+		kafka.WithClusterID("timely-integration-test"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		if err := kafkaContainer.Terminate(ctx); err != nil {
+			t.Fatalf("failed to terminate kafkaContainer: %s", err)
+		}
+	})
+
+	brokers, err := kafkaContainer.Brokers(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	transport, err := scheduler.NewKafkaTransport(scheduler.KafkaConfig{
+		Brokers:     brokers,
+		TopicPrefix: "test-",
+		PartitionBy: scheduler.PartitionBySchedule,
+	}, zap.NewNop().Sugar())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = transport.Close() })
+
+	schedule := scheduler.NewSchedule("test-description", "*/10 * * * * *", getStubDate,
+		scheduler.WithJob("test-slug", nil),
+		scheduler.WithConfiguration(scheduler.Kafka, ""))
+
+	if err = transport.Prepare(ctx, &schedule); err != nil {
+		t.Fatal(err)
+	}
+
+	topic, key := transport.Route(&schedule)
+	expected := scheduler.ScheduleJobEvent{Job: schedule.Job.Slug, ScheduleId: schedule.Id, JobRunId: uuid.New()}
+	if err = transport.Publish(ctx, topic, key, expected); err != nil {
+		t.Fatal(err)
+	}
+
+	subCtx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+
+	var received scheduler.ScheduleJobEvent
+	err = transport.Subscribe(subCtx, topic, func(message []byte) error {
+		defer cancel()
+		return json.Unmarshal(message, &received)
+	})
+	if subCtx.Err() == context.DeadlineExceeded {
+		t.Fatalf("message not received - %v", err)
+	}
+
+	if received != expected {
+		t.Fatalf("expected %+v, got %+v", expected, received)
+	}
+}
@@ -0,0 +1,175 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"timely/scheduler"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// baselineSchema is database_schema.sql of releases preceding migrations
+const baselineSchema = `
+CREATE TABLE schedules
+(
+    id UUID NOT NULL PRIMARY KEY,
+    group_id UUID NOT NULL,
+    description CHARACTER VARYING(1024),
+    status CHARACTER VARYING(64) NOT NULL,
+    frequency CHARACTER VARYING(256) NOT NULL,
+    schedule_start TIMESTAMP WITH TIME ZONE,
+    retry_policy_strategy CHARACTER VARYING(32),
+    retry_policy_count INT,
+    retry_policy_interval CHARACTER VARYING(32),
+    transport_type CHARACTER VARYING(32),
+    url CHARACTER VARYING(1024),
+    last_execution_date TIMESTAMP WITH TIME ZONE,
+    next_execution_date TIMESTAMP WITH TIME ZONE
+);
+
+CREATE TABLE jobs
+(
+    id UUID NOT NULL PRIMARY KEY,
+    schedule_id UUID NOT NULL REFERENCES schedules(id) ON DELETE CASCADE,
+    slug CHARACTER VARYING(256) NOT NULL,
+    data TEXT
+);
+
+CREATE TABLE job_runs
+(
+    id UUID NOT NULL PRIMARY KEY,
+    group_id UUID NOT NULL,
+    schedule_id UUID NOT NULL REFERENCES schedules(id) ON DELETE CASCADE,
+    status CHARACTER VARYING(128) NOT NULL,
+    reason CHARACTER VARYING(1024),
+    start_date TIMESTAMP WITH TIME ZONE NOT NULL,
+    end_date TIMESTAMP WITH TIME ZONE
+);
+
+INSERT INTO schedules (id, group_id, description, status, frequency, transport_type, url)
+    VALUES ('00000000-0000-0000-0000-000000000001', '00000000-0000-0000-0000-000000000002', 'baseline',
+        'waiting', 'once', 'http', 'http://example.com');
+
+INSERT INTO jobs (id, schedule_id, slug)
+    VALUES ('00000000-0000-0000-0000-000000000003', '00000000-0000-0000-0000-000000000001', 'baseline-slug');
+
+INSERT INTO job_runs (id, group_id, schedule_id, status, start_date)
+    VALUES ('00000000-0000-0000-0000-000000000004', '00000000-0000-0000-0000-000000000002',
+        '00000000-0000-0000-0000-000000000001', 'succeed', now());
+`
+
+func TestMigrateBaselineSchema(t *testing.T) {
+	ctx := context.Background()
+	pgContainer, err := startPostgres(ctx)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		if err := pgContainer.Terminate(ctx); err != nil {
+			t.Fatalf("failed to terminate pgContainer: %s", err)
+		}
+	})
+
+	connStr, err := pgContainer.ConnectionString(ctx, "sslmode=disable")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conn, err := pgx.Connect(ctx, connStr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close(ctx)
+
+	if _, err = conn.Exec(ctx, baselineSchema); err != nil {
+		t.Fatal(err)
+	}
+
+	pgStorage, err := scheduler.NewPgsqlConnection(ctx, connStr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = pgStorage.MigrateUp(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = pgStorage.CheckSchemaVersion(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	schedule, err := pgStorage.GetScheduleById(ctx, uuid.MustParse("00000000-0000-0000-0000-000000000001"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if schedule == nil || schedule.Namespace != "default" || schedule.Job.Slug != "baseline-slug" {
+		t.Fatalf("expected %+v, got %+v", "migrated schedule", schedule)
+	}
+
+	jobRun, err := pgStorage.GetJobRun(ctx, uuid.MustParse("00000000-0000-0000-0000-000000000004"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if jobRun == nil || jobRun.Attempt != 1 || !jobRun.ScheduledDate.Equal(jobRun.StartDate) {
+		t.Fatalf("expected %+v, got %+v", "migrated job run", jobRun)
+	}
+
+	partitions, err := pgStorage.GetJobRunPartitions(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(partitions) != 1 || partitions[0] != "job_runs_default" {
+		t.Fatalf("expected %+v, got %+v", []string{"job_runs_default"}, partitions)
+	}
+}
+
+func TestCheckSchemaVersionMismatch(t *testing.T) {
+	ctx := context.Background()
+	pgContainer, err := startPostgres(ctx)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		if err := pgContainer.Terminate(ctx); err != nil {
+			t.Fatalf("failed to terminate pgContainer: %s", err)
+		}
+	})
+
+	connStr, err := pgContainer.ConnectionString(ctx, "sslmode=disable")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pgStorage, err := scheduler.NewPgsqlConnection(ctx, connStr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = pgStorage.MigrateUp(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	conn, err := pgx.Connect(ctx, connStr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close(ctx)
+
+	// database migrated with numbering preceding adoption of baseline schema
+	if _, err = conn.Exec(ctx, `UPDATE schema_migrations SET name = 'outbox' WHERE version = 2`); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = pgStorage.CheckSchemaVersion(ctx); !errors.Is(err, scheduler.ErrMigrationMismatch) {
+		t.Fatalf("expected %+v, got %+v", scheduler.ErrMigrationMismatch, err)
+	}
+}
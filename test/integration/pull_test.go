@@ -0,0 +1,91 @@
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+	"timely/scheduler"
+)
+
+func TestClaimPullJob(t *testing.T) {
+	ctx := context.Background()
+	pgContainer, err := startPostgres(ctx)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		if err := pgContainer.Terminate(ctx); err != nil {
+			t.Fatalf("failed to terminate pgContainer: %s", err)
+		}
+	})
+
+	connStr, err := pgContainer.ConnectionString(ctx, "sslmode=disable")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pgStorage, err := scheduler.NewPgsqlConnection(ctx, connStr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = pgStorage.MigrateUp(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	schedule := scheduler.NewSchedule("test-description", "once", getStubDate,
+		scheduler.WithJob("test-slug", nil),
+		scheduler.WithConfiguration(scheduler.Pull, ""))
+	jobRun := scheduler.NewJobRun(schedule.Id, schedule.GroupId, 1, getStubDate(), getStubDate)
+
+	job, err := scheduler.NewPullJob(&schedule, &jobRun, getStubDate)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = pgStorage.WithTx(ctx, func(tx scheduler.StorageTx) error {
+		return tx.AddPullJob(ctx, job)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	claimed, err := pgStorage.ClaimPullJob(ctx, []string{"other-slug", "test-slug"}, time.Minute, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if claimed == nil || claimed.JobRunId != jobRun.Id || claimed.Deliveries != 1 {
+		t.Fatalf("expected %+v, got %+v", job, claimed)
+	}
+
+	// leased job is not available until its lease expires
+	claimed, err = pgStorage.ClaimPullJob(ctx, []string{"test-slug"}, time.Minute, now)
+	if err != nil || claimed != nil {
+		t.Fatalf("expected %+v, got %+v %v", nil, claimed, err)
+	}
+
+	claimed, err = pgStorage.ClaimPullJob(ctx, []string{"test-slug"}, time.Minute, now.Add(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if claimed == nil || claimed.Deliveries != 2 {
+		t.Fatalf("expected %+v, got %+v", 2, claimed)
+	}
+
+	err = pgStorage.WithTx(ctx, func(tx scheduler.StorageTx) error {
+		return tx.DeletePullJob(ctx, jobRun.Id)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claimed, err = pgStorage.ClaimPullJob(ctx, []string{"test-slug"}, time.Minute, now.Add(time.Hour))
+	if err != nil || claimed != nil {
+		t.Fatalf("expected %+v, got %+v %v", nil, claimed, err)
+	}
+}
@@ -0,0 +1,214 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+	"timely/scheduler"
+
+	"github.com/google/uuid"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+	"go.uber.org/zap"
+)
+
+func TestRabbitMqRecovery(t *testing.T) {
+	ctx := context.Background()
+	rabbitContainer, url, err := startRabbitMq(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		if err := rabbitContainer.Terminate(ctx); err != nil {
+			t.Fatalf("failed to terminate rabbitContainer: %s", err)
+		}
+	})
+
+	transport, err := scheduler.NewRabbitMqTransport(url, zap.NewNop().Sugar())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = transport.CreateExchange(string(scheduler.JobScheduleExchange)); err != nil {
+		t.Fatal(err)
+	}
+
+	schedule := scheduler.NewSchedule("test-description", "*/10 * * * * *", getStubDate,
+		scheduler.WithJob("test-slug", nil),
+		scheduler.WithConfiguration(scheduler.Rabbitmq, ""))
+
+	if err = transport.Prepare(ctx, &schedule); err != nil {
+		t.Fatal(err)
+	}
+
+	subCtx, cancel := context.WithTimeout(ctx, time.Minute*2)
+	defer cancel()
+
+	received := make(chan scheduler.ScheduleJobEvent, 2)
+	go func() {
+		_ = transport.Subscribe(subCtx, schedule.Job.Slug, func(message []byte) error {
+			var event scheduler.ScheduleJobEvent
+			if err := json.Unmarshal(message, &event); err != nil {
+				return err
+			}
+
+			received <- event
+			return nil
+		})
+	}()
+
+	exchange, routingKey := transport.Route(&schedule)
+	publish := func() scheduler.ScheduleJobEvent {
+		expected := scheduler.ScheduleJobEvent{Job: schedule.Job.Slug, ScheduleId: schedule.Id, JobRunId: uuid.New()}
+
+		// publish fails fast while connection is being recovered
+		for {
+			err := transport.Publish(subCtx, exchange, routingKey, expected)
+			if err == nil {
+				return expected
+			}
+
+			select {
+			case <-subCtx.Done():
+				t.Fatalf("message not published - %v", err)
+			case <-time.After(time.Millisecond * 200):
+			}
+		}
+	}
+
+	expectReceived := func(expected scheduler.ScheduleJobEvent) {
+		select {
+		case event := <-received:
+			if event != expected {
+				t.Fatalf("expected %+v, got %+v", expected, event)
+			}
+		case <-subCtx.Done():
+			t.Fatal("message not received")
+		}
+	}
+
+	expectReceived(publish())
+
+	// broker drops connection, transport re-dials, redeclares topology and consumer is re-attached
+	code, _, err := rabbitContainer.Exec(ctx, []string{"rabbitmqctl", "close_all_connections", "test"})
+	if err != nil || code != 0 {
+		t.Fatalf("failed to close connections: %d %v", code, err)
+	}
+
+	expectReceived(publish())
+
+	if transport.State() != scheduler.Connected {
+		t.Fatalf("expected %+v, got %+v", scheduler.Connected, transport.State())
+	}
+}
+
+func TestRabbitMqPublishErrors(t *testing.T) {
+	ctx := context.Background()
+	rabbitContainer, url, err := startRabbitMq(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		if err := rabbitContainer.Terminate(ctx); err != nil {
+			t.Fatalf("failed to terminate rabbitContainer: %s", err)
+		}
+	})
+
+	transport, err := scheduler.NewRabbitMqTransport(url, zap.NewNop().Sugar())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = transport.CreateExchange(string(scheduler.JobScheduleExchange)); err != nil {
+		t.Fatal(err)
+	}
+
+	// queue refusing every message makes broker nack the publish
+	if err = declareRejectingQueue(url, "test-rejecting"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]struct {
+		exchange   string
+		routingKey string
+		err        error
+	}{
+		"unroutable": {
+			exchange:   string(scheduler.JobScheduleExchange),
+			routingKey: "unbound-slug",
+			err:        scheduler.ErrMessageUnroutable,
+		},
+		"nacked": {
+			exchange:   "",
+			routingKey: "test-rejecting",
+			err:        scheduler.ErrPublishNacked,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			message := scheduler.ScheduleJobEvent{Job: "test-slug", ScheduleId: uuid.New(), JobRunId: uuid.New()}
+
+			err := transport.Publish(ctx, test.exchange, test.routingKey, message)
+			if !errors.Is(err, test.err) {
+				t.Fatalf("expected %+v, got %+v", test.err, err)
+			}
+
+			if !scheduler.IsDispatchFailure(err) {
+				t.Fatalf("expected dispatch failure, got %+v", err)
+			}
+		})
+	}
+}
+
+func startRabbitMq(ctx context.Context) (testcontainers.Container, string, error) {
+	container, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: testcontainers.ContainerRequest{
+			Image:        "rabbitmq:3.13-alpine",
+			ExposedPorts: []string{"5672/tcp"},
+			WaitingFor:   wait.ForLog("Server startup complete").WithStartupTimeout(time.Minute),
+		},
+		Started: true,
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	host, err := container.Host(ctx)
+	if err != nil {
+		return container, "", err
+	}
+
+	port, err := container.MappedPort(ctx, "5672/tcp")
+	if err != nil {
+		return container, "", err
+	}
+
+	return container, fmt.Sprintf("amqp://guest:guest@%s:%s/", host, port.Port()), nil
+}
+
+func declareRejectingQueue(url, queue string) error {
+	conn, err := amqp.Dial(url)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	chann, err := conn.Channel()
+	if err != nil {
+		return err
+	}
+
+	_, err = chann.QueueDeclare(queue, true, false, false, false, amqp.Table{
+		"x-max-length": int32(0),
+		"x-overflow":   "reject-publish",
+	})
+
+	return err
+}
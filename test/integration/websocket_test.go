@@ -0,0 +1,47 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"timely/scheduler"
+)
+
+func TestLockWebsocketInstance(t *testing.T) {
+	ctx := context.Background()
+	pgContainer, err := startPostgres(ctx)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		if err := pgContainer.Terminate(ctx); err != nil {
+			t.Fatalf("failed to terminate pgContainer: %s", err)
+		}
+	})
+
+	connStr, err := pgContainer.ConnectionString(ctx, "sslmode=disable")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first, err := scheduler.NewPgsqlConnection(ctx, connStr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	second, err := scheduler.NewPgsqlConnection(ctx, connStr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = first.LockWebsocketInstance(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// lock is held by the first instance, so the second one can't serve websocket workers
+	if err = second.LockWebsocketInstance(ctx); !errors.Is(err, scheduler.ErrWebsocketInstanceRunning) {
+		t.Fatalf("expected %+v, got %+v", scheduler.ErrWebsocketInstanceRunning, err)
+	}
+}